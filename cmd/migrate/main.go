@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/nathanmocogni/core-banking-system/db"
+	"github.com/nathanmocogni/core-banking-system/internal/database"
+)
+
+// migrate applies or rolls back the embedded database migrations.
+//
+// Usage:
+//
+//	migrate up              apply all pending migrations
+//	migrate down [-steps N] roll back the last N migrations (default 1)
+//	migrate status          list migrations and when they were applied
+func main() {
+	steps := flag.Int("steps", 1, "number of migrations to roll back with down")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: migrate [-steps N] up|down|status")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	dbHost := os.Getenv("DB_HOST")
+	if dbHost == "" {
+		dbHost = "localhost"
+	}
+	dbPortStr := os.Getenv("DB_PORT")
+	if dbPortStr == "" {
+		dbPortStr = "5432"
+	}
+	dbPort, _ := strconv.Atoi(dbPortStr)
+
+	conn, err := database.Connect(dbHost, os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"), os.Getenv("DB_NAME"), dbPort)
+	if err != nil {
+		log.Fatalf("Could not connect to database: %v", err)
+	}
+	defer conn.Close()
+
+	migrator, err := database.NewMigrator(conn, db.Migrations)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	ctx := context.Background()
+	switch flag.Arg(0) {
+	case "up":
+		n, err := migrator.Up(ctx)
+		if err != nil {
+			log.Fatalf("Migration failed after %d applied: %v", n, err)
+		}
+		fmt.Printf("Applied %d migration(s)\n", n)
+	case "down":
+		n, err := migrator.Down(ctx, *steps)
+		if err != nil {
+			log.Fatalf("Rollback failed after %d reverted: %v", n, err)
+		}
+		fmt.Printf("Rolled back %d migration(s)\n", n)
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatalf("Failed to read migration status: %v", err)
+		}
+		for _, s := range status {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d  %-40s %s\n", s.Version, s.Name, applied)
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/google/uuid"
+	"github.com/nathanmocogni/core-banking-system/internal/common"
 	"github.com/nathanmocogni/core-banking-system/internal/ledger"
 )
 
@@ -35,12 +36,19 @@ func (h *ClientHandler) CreateClient(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *ClientHandler) ListClients(w http.ResponseWriter, r *http.Request) {
-	clients, err := h.Service.Repo.ListClients(r.Context())
+	page, err := common.ParsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	clients, next, err := h.Service.Repo.ListClients(r.Context(), page)
 	if err != nil {
 		http.Error(w, "Failed to list clients: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	common.SetNextCursor(w, next)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(clients)
 }
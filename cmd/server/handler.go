@@ -2,13 +2,17 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/nathanmocogni/core-banking-system/internal/auth"
 	"github.com/nathanmocogni/core-banking-system/internal/batch"
+	"github.com/nathanmocogni/core-banking-system/internal/common"
 	"github.com/nathanmocogni/core-banking-system/internal/ledger"
 	"github.com/nathanmocogni/core-banking-system/internal/payment"
 	"github.com/nathanmocogni/core-banking-system/internal/workflow"
@@ -35,6 +39,7 @@ type CreateAccountRequest struct {
 	AccountCategory string             `json:"account_category"`
 	OwnershipType   string             `json:"ownership_type"`
 	ClientID        string             `json:"client_id"`
+	Status          string             `json:"status"` // ACTIVE (default) or PENDING
 }
 
 func (h *Handler) CreateAccount(w http.ResponseWriter, r *http.Request) {
@@ -59,9 +64,19 @@ func (h *Handler) CreateAccount(w http.ResponseWriter, r *http.Request) {
 		clientID = &id
 	}
 
-	account, err := h.service.CreateAccount(req.Name, req.Type, req.Currency, req.AccountCategory, req.OwnershipType, clientID)
+	create := h.service.CreateAccount
+	switch ledger.AccountStatus(req.Status) {
+	case "", ledger.AccountActive:
+	case ledger.AccountPending:
+		create = h.service.CreatePendingAccount
+	default:
+		http.Error(w, "Invalid status, expected ACTIVE or PENDING", http.StatusBadRequest)
+		return
+	}
+
+	account, err := create(req.Name, req.Type, req.Currency, req.AccountCategory, req.OwnershipType, clientID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusInternalServerError))
 		return
 	}
 
@@ -109,20 +124,30 @@ func (h *Handler) ListAccounts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	accounts, err := h.service.ListAccounts()
+	page, err := common.ParsePageRequest(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
+	accounts, next, err := h.service.ListAccounts(page)
+	if err != nil {
+		http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	common.SetNextCursor(w, next)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(accounts)
 }
 
 type PostTransactionRequest struct {
-	Reference   string         `json:"reference"`
-	Description string         `json:"description"`
-	Entries     []ledger.Entry `json:"entries"`
+	Reference     string          `json:"reference"`
+	Description   string          `json:"description"`
+	Entries       []ledger.Entry  `json:"entries"`
+	ValueDate     string          `json:"value_date"`     // YYYY-MM-DD, defaults to today
+	EffectiveDate string          `json:"effective_date"` // YYYY-MM-DD, defaults to today
+	Metadata      ledger.Metadata `json:"metadata"`
 }
 
 func (h *Handler) PostTransaction(w http.ResponseWriter, r *http.Request) {
@@ -136,6 +161,16 @@ func (h *Handler) PostTransaction(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+	valueDate, err := parseDate(req.ValueDate)
+	if err != nil {
+		http.Error(w, "Invalid value_date, expected YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	effectiveDate, err := parseDate(req.EffectiveDate)
+	if err != nil {
+		http.Error(w, "Invalid effective_date, expected YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
 
 	// Calculate total amount for workflow check (sum of debits usually, or just max amount)
 	// For simplicity, we sum all amounts. In double entry, sum is 2x actual transfer.
@@ -154,6 +189,15 @@ func (h *Handler) PostTransaction(w http.ResponseWriter, r *http.Request) {
 		"description": req.Description,
 		"entries":     req.Entries,
 	}
+	if len(req.Metadata) > 0 {
+		payload["metadata"] = req.Metadata
+	}
+	if !valueDate.IsZero() {
+		payload["value_date"] = req.ValueDate
+	}
+	if !effectiveDate.IsZero() {
+		payload["effective_date"] = req.EffectiveDate
+	}
 
 	def, err := h.workflowEngine.CheckWorkflow("TRANSACTION_POSTED", payload)
 	if err != nil {
@@ -180,9 +224,16 @@ func (h *Handler) PostTransaction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	transaction, err := h.service.PostTransaction(req.Reference, req.Description, req.Entries)
+	transaction, err := h.service.Post(ledger.PostingRequest{
+		Reference:     req.Reference,
+		Description:   req.Description,
+		Entries:       req.Entries,
+		ValueDate:     valueDate,
+		EffectiveDate: effectiveDate,
+		Metadata:      req.Metadata,
+	})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusBadRequest))
 		return
 	}
 
@@ -190,6 +241,78 @@ func (h *Handler) PostTransaction(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(transaction)
 }
 
+type ReverseTransactionRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (h *Handler) ReverseTransaction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := r.URL.Query().Get("id")
+	if idStr == "" {
+		http.Error(w, "Missing id parameter", http.StatusBadRequest)
+		return
+	}
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+
+	var req ReverseTransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	reversal, err := h.service.ReverseTransaction(id, req.Reason)
+	if err != nil {
+		http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reversal)
+}
+
+// parseDate parses an optional YYYY-MM-DD date; an empty string is the zero time.
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
+
+// ledgerErrorStatus maps ledger sentinel errors to HTTP status codes.
+// Anything unrecognised gets fallback.
+func ledgerErrorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, ledger.ErrTransactionNotFound), errors.Is(err, ledger.ErrAccountNotFound), errors.Is(err, ledger.ErrHoldNotFound),
+		errors.Is(err, ledger.ErrPeriodNotFound), errors.Is(err, ledger.ErrGLAccountNotFound), errors.Is(err, ledger.ErrTemplateNotFound),
+		errors.Is(err, ledger.ErrStatementNotFound), errors.Is(err, ledger.ErrImportNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ledger.ErrDuplicateReference), errors.Is(err, ledger.ErrAlreadyReversed),
+		errors.Is(err, ledger.ErrReversalOfReversal), errors.Is(err, ledger.ErrHoldNotActive),
+		errors.Is(err, ledger.ErrPeriodOverlap), errors.Is(err, ledger.ErrInvalidPeriodStatus),
+		errors.Is(err, ledger.ErrInvalidAccountState), errors.Is(err, ledger.ErrAccountNotEmpty), errors.Is(err, ledger.ErrGLAccountInUse),
+		errors.Is(err, ledger.ErrInvalidTxStatus):
+		return http.StatusConflict
+	case errors.Is(err, ledger.ErrUnknownCurrency), errors.Is(err, ledger.ErrCurrencyMismatch), errors.Is(err, ledger.ErrInvalidGLAccount),
+		errors.Is(err, ledger.ErrInvalidTemplate), errors.Is(err, ledger.ErrInvalidImport), errors.Is(err, ledger.ErrInvalidMetadata),
+		errors.Is(err, ledger.ErrInvalidInterestTerms), errors.Is(err, common.ErrInvalidCursor):
+		return http.StatusBadRequest
+	case errors.Is(err, ledger.ErrInsufficientFunds), errors.Is(err, ledger.ErrFXRateNotFound), errors.Is(err, ledger.ErrDateOutOfPolicy),
+		errors.Is(err, ledger.ErrPeriodClosed), errors.Is(err, ledger.ErrNoRetainedEarnings), errors.Is(err, ledger.ErrAccountNotPostable),
+		errors.Is(err, ledger.ErrNoPostingTemplate):
+		return http.StatusUnprocessableEntity
+	default:
+		return fallback
+	}
+}
+
 type CreateProductRequest struct {
 	Name            string `json:"name"`
 	InterestRateBPS int64  `json:"interest_rate_bps"`
@@ -569,15 +692,26 @@ func (h *Handler) AssignProduct(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(`{"status": "assigned"}`))
 }
 
+// CalculateInterest serves POST /interest/calculate, accruing interest for the
+// business_date query parameter (YYYY-MM-DD, default today) and any days missed before it.
 func (h *Handler) CalculateInterest(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	transactions, err := h.service.CalculateInterest()
+	businessDate, err := parseDate(r.URL.Query().Get("business_date"))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Invalid business_date, expected YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	if businessDate.IsZero() {
+		businessDate = time.Now()
+	}
+
+	transactions, err := h.service.CalculateInterestAsOf(businessDate)
+	if err != nil {
+		http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusInternalServerError))
 		return
 	}
 
@@ -615,7 +749,7 @@ func (h *PaymentHandler) Deposit(w http.ResponseWriter, r *http.Request) {
 
 	tx, err := h.service.Deposit(req.AccountID, req.Amount, req.Currency)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusInternalServerError))
 		return
 	}
 
@@ -637,7 +771,7 @@ func (h *PaymentHandler) Withdraw(w http.ResponseWriter, r *http.Request) {
 
 	tx, err := h.service.Withdraw(req.AccountID, req.Amount, req.Currency)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusInternalServerError))
 		return
 	}
 
@@ -666,7 +800,7 @@ func (h *PaymentHandler) Transfer(w http.ResponseWriter, r *http.Request) {
 
 	tx, err := h.service.Transfer(req.FromAccountID, req.ToAccountID, req.Amount, req.Currency)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusInternalServerError))
 		return
 	}
 
@@ -674,48 +808,95 @@ func (h *PaymentHandler) Transfer(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(tx)
 }
 
+// GetTransactions serves GET /transactions?account_id=...&limit=&cursor= with optional
+// filters from, to (value date), min_amount, max_amount, direction, reference_prefix
+// and counterparty_id. The next page's cursor is returned in X-Next-Cursor.
 func (h *Handler) GetTransactions(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	// Parse Account ID
-	// Path: /accounts/{id}/transactions (but we are using query params for now: /transactions?account_id=...)
-	// Let's stick to query params for simplicity in this prototype: /transactions?account_id=...
-	accountIDStr := r.URL.Query().Get("account_id")
-	if accountIDStr == "" {
+	q := r.URL.Query()
+	filter := ledger.TransactionFilter{ReferencePrefix: q.Get("reference_prefix")}
+	// metadata=key:value matches a string value, metadata=key only requires the key
+	for _, m := range q["metadata"] {
+		key, value, _ := strings.Cut(m, ":")
+		if key == "" {
+			http.Error(w, "Invalid metadata, expected key or key:value", http.StatusBadRequest)
+			return
+		}
+		if filter.Metadata == nil {
+			filter.Metadata = make(map[string]string)
+		}
+		filter.Metadata[key] = value
+	}
+
+	// Without an account only a metadata search is allowed
+	accountIDStr := q.Get("account_id")
+	if accountIDStr == "" && filter.Metadata == nil {
 		http.Error(w, "Missing account_id parameter", http.StatusBadRequest)
 		return
 	}
+	var err error
+	if accountIDStr != "" {
+		if filter.AccountID, err = uuid.Parse(accountIDStr); err != nil {
+			http.Error(w, "Invalid account_id", http.StatusBadRequest)
+			return
+		}
+	}
 
-	accountID, err := uuid.Parse(accountIDStr)
-	if err != nil {
-		http.Error(w, "Invalid account_id", http.StatusBadRequest)
+	if filter.FromDate, err = parseDate(q.Get("from")); err != nil {
+		http.Error(w, "Invalid from, expected YYYY-MM-DD", http.StatusBadRequest)
 		return
 	}
-
-	// Parse Limit & Offset
-	limit := 10
-	if l := r.URL.Query().Get("limit"); l != "" {
-		if val, err := strconv.Atoi(l); err == nil && val > 0 {
-			limit = val
+	if filter.ToDate, err = parseDate(q.Get("to")); err != nil {
+		http.Error(w, "Invalid to, expected YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	for _, a := range []struct {
+		name string
+		dst  *int64
+	}{{"min_amount", &filter.MinAmount}, {"max_amount", &filter.MaxAmount}} {
+		if v := q.Get(a.name); v != "" {
+			if *a.dst, err = strconv.ParseInt(v, 10, 64); err != nil || *a.dst <= 0 {
+				http.Error(w, "Invalid "+a.name, http.StatusBadRequest)
+				return
+			}
 		}
 	}
-
-	offset := 0
-	if o := r.URL.Query().Get("offset"); o != "" {
-		if val, err := strconv.Atoi(o); err == nil && val >= 0 {
-			offset = val
+	switch d := ledger.EntryDirection(q.Get("direction")); d {
+	case "", ledger.Debit, ledger.Credit:
+		filter.Direction = d
+	default:
+		http.Error(w, "Invalid direction, expected DEBIT or CREDIT", http.StatusBadRequest)
+		return
+	}
+	if c := q.Get("counterparty_id"); c != "" {
+		if filter.CounterpartyID, err = uuid.Parse(c); err != nil {
+			http.Error(w, "Invalid counterparty_id", http.StatusBadRequest)
+			return
 		}
 	}
 
-	transactions, err := h.service.GetTransactions(accountID, limit, offset)
+	// Offset paging was replaced by cursors; reject it rather than serve the first page again
+	if q.Has("offset") {
+		http.Error(w, "offset is no longer supported, use cursor from X-Next-Cursor", http.StatusBadRequest)
+		return
+	}
+	page, err := common.ParsePageRequest(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	transactions, next, err := h.service.GetTransactions(filter, page)
+	if err != nil {
+		http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusInternalServerError))
 		return
 	}
 
+	common.SetNextCursor(w, next)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(transactions)
 }
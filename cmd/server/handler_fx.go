@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+type SetFXRateRequest struct {
+	BaseCurrency  string      `json:"base_currency"`
+	QuoteCurrency string      `json:"quote_currency"`
+	Rate          json.Number `json:"rate"` // Number or string, kept as an exact decimal
+}
+
+// HandleFXRates returns the latest rate (GET /fx/rates?base=EUR&quote=USD) or records a new one (POST /fx/rates).
+func (h *Handler) HandleFXRates(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		base, quote := r.URL.Query().Get("base"), r.URL.Query().Get("quote")
+		if base == "" || quote == "" {
+			http.Error(w, "Missing base or quote parameter", http.StatusBadRequest)
+			return
+		}
+		rate, err := h.service.GetFXRate(base, quote)
+		if err != nil {
+			http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusInternalServerError))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(rate)
+
+	case http.MethodPost:
+		var req SetFXRateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		rate, err := h.service.SetFXRate(req.BaseCurrency, req.QuoteCurrency, req.Rate.String())
+		if err != nil {
+			http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusBadRequest))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(rate)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
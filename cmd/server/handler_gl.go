@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/nathanmocogni/core-banking-system/internal/ledger"
+)
+
+type CreateGLAccountRequest struct {
+	Code       string             `json:"code"`
+	Name       string             `json:"name"`
+	Type       ledger.AccountType `json:"type"`
+	ParentCode string             `json:"parent_code"`
+	IsHeader   bool               `json:"is_header"`
+	IsPostable bool               `json:"is_postable"`
+}
+
+// HandleGLAccounts serves the chart of accounts: GET lists it, POST creates an account,
+// PUT ?code=... updates one and DELETE ?code=... removes an unused one.
+func (h *Handler) HandleGLAccounts(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		chart, err := h.service.ListGLAccounts()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(chart)
+
+	case http.MethodPost:
+		var req CreateGLAccountRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		g, err := h.service.CreateGLAccount(req.Code, req.Name, req.Type, req.ParentCode, req.IsHeader, req.IsPostable)
+		if err != nil {
+			http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusInternalServerError))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(g)
+
+	case http.MethodPut:
+		var req ledger.GLAccountUpdate
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		g, err := h.service.UpdateGLAccount(r.URL.Query().Get("code"), req)
+		if err != nil {
+			http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusInternalServerError))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(g)
+
+	case http.MethodDelete:
+		if err := h.service.DeleteGLAccount(r.URL.Query().Get("code")); err != nil {
+			http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusInternalServerError))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+type GLMappingRequest struct {
+	GLCode string `json:"gl_code"` // Empty clears the mapping
+}
+
+// SetAccountGL serves PUT /accounts/gl?id=... to map an account to a GL account.
+func (h *Handler) SetAccountGL(w http.ResponseWriter, r *http.Request) {
+	h.setGLMapping(w, r, h.service.SetAccountGL)
+}
+
+// SetProductControlGL serves PUT /products/control-gl?id=... to set a product's control GL account.
+func (h *Handler) SetProductControlGL(w http.ResponseWriter, r *http.Request) {
+	h.setGLMapping(w, r, h.service.SetProductControlGL)
+}
+
+func (h *Handler) setGLMapping(w http.ResponseWriter, r *http.Request, set func(uuid.UUID, string) error) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+
+	var req GLMappingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := set(id, req.GLCode); err != nil {
+		http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusBadRequest))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetGLReport serves GET /reports/gl?currency=USD&from=...&to=...&level=N[&format=csv].
+// Level 0 (the default) returns the full tree.
+func (h *Handler) GetGLReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	currency, from, to, err := reportParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	level := 0
+	if l := r.URL.Query().Get("level"); l != "" {
+		if level, err = strconv.Atoi(l); err != nil || level < 0 {
+			http.Error(w, "Invalid level", http.StatusBadRequest)
+			return
+		}
+	}
+
+	report, err := h.service.GetGLReport(currency, from, to, level)
+	if err != nil {
+		http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	if wantsCSV(r) {
+		rows := [][]string{{"code", "name", "type", "level", "opening_balance", "debits", "credits", "closing_balance", "balance"}}
+		lines := report.Lines
+		if level == 0 {
+			lines = ledger.FlattenGL(report.Lines, 0)
+		}
+		if report.Unmapped != nil {
+			lines = append(lines, report.Unmapped)
+		}
+		for _, l := range lines {
+			rows = append(rows, []string{l.Code, l.Name, string(l.Type), strconv.Itoa(l.Level), i64(l.OpeningBalance),
+				i64(l.Debits), i64(l.Credits), i64(l.ClosingBalance), i64(l.Balance)})
+		}
+		rows = append(rows, checkRows(report.Check)...)
+		writeCSV(w, fmt.Sprintf("gl-%s-%s.csv", currency, to.Format("2006-01-02")), rows)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
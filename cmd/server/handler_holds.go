@@ -0,0 +1,262 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nathanmocogni/core-banking-system/internal/ledger"
+)
+
+type CreateHoldRequest struct {
+	AccountID   uuid.UUID  `json:"account_id"`
+	Amount      int64      `json:"amount"`
+	Reference   string     `json:"reference"`
+	Description string     `json:"description"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+// HandleHolds lists holds (GET /holds?account_id=...&active=true) or creates one (POST /holds).
+func (h *Handler) HandleHolds(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		accountID, err := uuid.Parse(r.URL.Query().Get("account_id"))
+		if err != nil {
+			http.Error(w, "Invalid account_id", http.StatusBadRequest)
+			return
+		}
+		holds, err := h.service.ListHolds(accountID, r.URL.Query().Get("active") == "true")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(holds)
+
+	case http.MethodPost:
+		var req CreateHoldRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		hold, err := h.service.CreateHold(req.AccountID, req.Amount, req.Reference, req.Description, req.ExpiresAt)
+		if err != nil {
+			http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusBadRequest))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(hold)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+type CaptureHoldRequest struct {
+	Amount                int64     `json:"amount"` // 0 captures the full hold
+	CounterpartyAccountID uuid.UUID `json:"counterparty_account_id"`
+	Description           string    `json:"description"`
+}
+
+func (h *Handler) CaptureHold(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+
+	var req CaptureHoldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.service.CaptureHold(id, req.Amount, req.CounterpartyAccountID, req.Description)
+	if err != nil {
+		http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tx)
+}
+
+func (h *Handler) ReleaseHold(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+
+	hold, err := h.service.ReleaseHold(id)
+	if err != nil {
+		http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hold)
+}
+
+// BalanceAsOfResponse is the point-in-time balance of an account.
+type BalanceAsOfResponse struct {
+	AccountID     uuid.UUID `json:"account_id"`
+	AsOf          string    `json:"as_of"`
+	LedgerBalance int64     `json:"ledger_balance"` // Signed, as stored (debit +, credit -)
+	Balance       int64     `json:"balance"`        // In the account's normal direction
+}
+
+// GetAccountBalance returns the available balance view of an account (GET /accounts/balance?id=...).
+// With as_of=YYYY-MM-DD it returns the value-dated balance at the end of that day instead.
+func (h *Handler) GetAccountBalance(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+
+	if asOfStr := r.URL.Query().Get("as_of"); asOfStr != "" {
+		asOf, err := parseDate(asOfStr)
+		if err != nil {
+			http.Error(w, "Invalid as_of, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		account, err := h.service.GetAccount(id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if account == nil {
+			http.Error(w, "Account not found", http.StatusNotFound)
+			return
+		}
+		balance, err := h.service.GetBalanceAsOf(id, asOf)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(BalanceAsOfResponse{
+			AccountID:     id,
+			AsOf:          asOfStr,
+			LedgerBalance: balance,
+			Balance:       ledger.NaturalBalance(account.Type, balance),
+		})
+		return
+	}
+
+	balance, err := h.service.GetAvailableBalance(id)
+	if err != nil {
+		http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(balance)
+}
+
+type AccountOverdraftRequest struct {
+	OverdraftLimit *int64 `json:"overdraft_limit"` // null falls back to the product limit
+}
+
+func (h *Handler) SetAccountOverdraft(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+
+	var req AccountOverdraftRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.SetAccountOverdraftLimit(id, req.OverdraftLimit); err != nil {
+		http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+type ProductOverdraftRequest struct {
+	OverdraftLimit int64 `json:"overdraft_limit"`
+}
+
+func (h *Handler) SetProductOverdraft(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+
+	var req ProductOverdraftRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.SetProductOverdraftLimit(id, req.OverdraftLimit); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// SetAccountStatus serves PUT /accounts/status?id=... to activate, freeze, mark dormant or close an account.
+func (h *Handler) SetAccountStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+
+	var req ledger.AccountStatusChange
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	account, err := h.service.SetAccountStatus(id, req)
+	if err != nil {
+		http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(account)
+}
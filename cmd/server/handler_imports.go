@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/nathanmocogni/core-banking-system/internal/batch"
+	"github.com/nathanmocogni/core-banking-system/internal/ledger"
+)
+
+// maxImportSize bounds the size of an uploaded import file.
+const maxImportSize = 10 << 20
+
+// HandleImports serves transaction imports:
+//   - POST /admin/imports?format=csv|json&mode=atomic|best_effort&dry_run=true uploads a
+//     file (multipart field "file", or the raw request body) and starts the import as a
+//     batch. The response is the batch record; the file is parsed before it is accepted.
+//   - GET /admin/imports?id={batch_id} returns the report of a finished import.
+func (h *Handler) HandleImports(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.startImport(w, r)
+	case http.MethodGet:
+		id, err := uuid.Parse(r.URL.Query().Get("id"))
+		if err != nil {
+			http.Error(w, "Invalid UUID", http.StatusBadRequest)
+			return
+		}
+		report, err := h.service.GetImportReport(id)
+		if err != nil {
+			http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusInternalServerError))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) startImport(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	opts := ledger.ImportOptions{
+		Mode:   ledger.ImportMode(strings.ToUpper(q.Get("mode"))),
+		DryRun: q.Get("dry_run") == "true",
+	}
+	if opts.Mode == "" {
+		opts.Mode = ledger.ImportAtomic
+	}
+	if opts.Mode != ledger.ImportAtomic && opts.Mode != ledger.ImportBestEffort {
+		http.Error(w, "Invalid mode", http.StatusBadRequest)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	var file io.Reader = r.Body
+	fileName := q.Get("file_name")
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		f, header, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, "Missing file", http.StatusBadRequest)
+			return
+		}
+		defer f.Close()
+		file, fileName = f, header.Filename
+	}
+
+	format := strings.ToLower(q.Get("format"))
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(fileName)), ".")
+	}
+	var lines []ledger.ImportLine
+	var err error
+	switch format {
+	case "csv":
+		lines, err = ledger.ParseImportCSV(file)
+	case "json":
+		lines, err = ledger.ParseImportJSON(file)
+	default:
+		http.Error(w, "Invalid format: use csv or json", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(lines) == 0 {
+		http.Error(w, "Import file has no lines", http.StatusBadRequest)
+		return
+	}
+	if fileName == "" {
+		fileName = "upload." + format
+	}
+
+	record, err := h.batchEngine.Submit(r.Context(), batch.NewImportJob(h.service, fileName, strings.ToUpper(format), lines, opts))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(record)
+}
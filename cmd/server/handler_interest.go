@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/nathanmocogni/core-banking-system/internal/ledger"
+)
+
+// SetProductInterest serves PUT /products/interest?id=... to set a product's day-count
+// convention, balance tiers, minimum balance and rounding. Omitted fields take the defaults.
+func (h *Handler) SetProductInterest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+
+	terms := ledger.DefaultInterestTerms
+	if err := json.NewDecoder(r.Body).Decode(&terms); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.SetProductInterestTerms(id, terms); err != nil {
+		http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(terms)
+}
+
+// CapitalizeInterest serves POST /interest/capitalize, capitalizing the interest
+// whose capitalization period has ended.
+func (h *Handler) CapitalizeInterest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	transactions, err := h.service.CapitalizeInterest()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transactions)
+}
+
+// GetAccruedInterest serves GET /interest/accrued?account_id=..., the interest
+// accrued on an account and not yet capitalized.
+func (h *Handler) GetAccruedInterest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	accountID, err := uuid.Parse(r.URL.Query().Get("account_id"))
+	if err != nil {
+		http.Error(w, "Invalid account_id", http.StatusBadRequest)
+		return
+	}
+
+	accrued, err := h.service.GetAccruedInterest(accountID)
+	if err != nil {
+		http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(accrued)
+}
+
+// ReconcileInterest serves GET /interest/reconciliation, comparing the accrual
+// sub-ledger with the Interest Payable and Receivable GL accounts.
+func (h *Handler) ReconcileInterest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	recon, err := h.service.ReconcileInterestAccruals()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(recon)
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/nathanmocogni/core-banking-system/internal/loan"
+)
+
+type LoanHandler struct {
+	service *loan.Service
+}
+
+func NewLoanHandler(s *loan.Service) *LoanHandler {
+	return &LoanHandler{service: s}
+}
+
+type RepayLoanRequest struct {
+	Amount        int64     `json:"amount"`
+	FromAccountID uuid.UUID `json:"from_account_id"` // Defaults to the customer account
+}
+
+// loanErrorStatus maps loan errors to HTTP status codes, and ledger errors as
+// ledgerErrorStatus does.
+func loanErrorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, loan.ErrNotFound), errors.Is(err, loan.ErrProductNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, loan.ErrInvalidProduct), errors.Is(err, loan.ErrInvalidLoan), errors.Is(err, loan.ErrInvalidRepayment):
+		return http.StatusBadRequest
+	case errors.Is(err, loan.ErrInvalidStatus), errors.Is(err, loan.ErrRepaymentInProgress):
+		return http.StatusConflict
+	default:
+		return ledgerErrorStatus(err, fallback)
+	}
+}
+
+// HandleProducts serves loan products:
+//   - GET /loans/products lists them.
+//   - POST /loans/products creates one.
+func (h *LoanHandler) HandleProducts(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		products, err := h.service.ListProducts()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(products)
+
+	case http.MethodPost:
+		var p loan.Product
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		created, err := h.service.CreateProduct(p)
+		if err != nil {
+			http.Error(w, err.Error(), loanErrorStatus(err, http.StatusInternalServerError))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(created)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleLoans serves loans:
+//   - GET /loans?account_id={id} lists the loans disbursed to a customer account; ?id={id} returns one.
+//   - POST /loans disburses a loan.
+func (h *LoanHandler) HandleLoans(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	switch r.Method {
+	case http.MethodGet:
+		if q.Get("id") != "" {
+			id, err := uuid.Parse(q.Get("id"))
+			if err != nil {
+				http.Error(w, "Invalid UUID", http.StatusBadRequest)
+				return
+			}
+			l, err := h.service.Get(id)
+			if err != nil {
+				http.Error(w, err.Error(), loanErrorStatus(err, http.StatusInternalServerError))
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(l)
+			return
+		}
+		accountID, err := uuid.Parse(q.Get("account_id"))
+		if err != nil {
+			http.Error(w, "Invalid account_id", http.StatusBadRequest)
+			return
+		}
+		loans, err := h.service.List(accountID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(loans)
+
+	case http.MethodPost:
+		var req loan.DisbursementRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		l, err := h.service.Disburse(req)
+		if err != nil {
+			http.Error(w, err.Error(), loanErrorStatus(err, http.StatusInternalServerError))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(l)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GetSchedule returns a loan's amortization schedule (GET /loans/schedule?id=...).
+func (h *LoanHandler) GetSchedule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+	schedule, err := h.service.Schedule(id)
+	if err != nil {
+		http.Error(w, err.Error(), loanErrorStatus(err, http.StatusInternalServerError))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedule)
+}
+
+// HandleRepayments serves a loan's repayments:
+//   - GET /loans/repayments?id={id} lists them, newest first.
+//   - POST /loans/repayments?id={id} repays the loan.
+func (h *LoanHandler) HandleRepayments(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if _, err := h.service.Get(id); err != nil {
+			http.Error(w, err.Error(), loanErrorStatus(err, http.StatusInternalServerError))
+			return
+		}
+		repayments, err := h.service.ListRepayments(id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(repayments)
+
+	case http.MethodPost:
+		var req RepayLoanRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		repayment, err := h.service.Repay(id, req.FromAccountID, req.Amount)
+		if err != nil {
+			http.Error(w, err.Error(), loanErrorStatus(err, http.StatusInternalServerError))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(repayment)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nathanmocogni/core-banking-system/internal/ledger"
+)
+
+type AuthorizeTransactionRequest struct {
+	PostTransactionRequest
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// AuthorizeTransaction records a pending transaction (POST /transactions/authorize).
+func (h *Handler) AuthorizeTransaction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req AuthorizeTransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	valueDate, err := parseDate(req.ValueDate)
+	if err != nil {
+		http.Error(w, "Invalid value_date, expected YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	effectiveDate, err := parseDate(req.EffectiveDate)
+	if err != nil {
+		http.Error(w, "Invalid effective_date, expected YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	if req.ExpiresAt.IsZero() {
+		http.Error(w, "Missing expires_at", http.StatusBadRequest)
+		return
+	}
+
+	transaction, err := h.service.Authorize(ledger.PostingRequest{
+		Reference:     req.Reference,
+		Description:   req.Description,
+		Entries:       req.Entries,
+		ValueDate:     valueDate,
+		EffectiveDate: effectiveDate,
+		Metadata:      req.Metadata,
+	}, req.ExpiresAt)
+	if err != nil {
+		http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(transaction)
+}
+
+type PostPendingRequest struct {
+	Amount int64 `json:"amount"` // 0 posts the authorized amount
+}
+
+// PostPendingTransaction posts a pending transaction (POST /transactions/post-pending?id=...).
+func (h *Handler) PostPendingTransaction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+
+	var req PostPendingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	transaction, err := h.service.PostPending(id, req.Amount)
+	if err != nil {
+		http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transaction)
+}
+
+type VoidPendingRequest struct {
+	Reason string `json:"reason"`
+}
+
+// VoidPendingTransaction voids a pending transaction (POST /transactions/void?id=...).
+func (h *Handler) VoidPendingTransaction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+
+	var req VoidPendingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Reason == "" {
+		http.Error(w, "Missing reason", http.StatusBadRequest)
+		return
+	}
+
+	transaction, err := h.service.VoidPending(id, req.Reason)
+	if err != nil {
+		http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transaction)
+}
+
+// GetPendingTransactions returns one transaction in any status
+// (GET /transactions/pending?id=...) or an account's pending transactions
+// (GET /transactions/pending?account_id=...).
+func (h *Handler) GetPendingTransactions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	if q.Get("id") != "" {
+		id, err := uuid.Parse(q.Get("id"))
+		if err != nil {
+			http.Error(w, "Invalid UUID", http.StatusBadRequest)
+			return
+		}
+		transaction, err := h.service.GetTransaction(id)
+		if err != nil {
+			http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusInternalServerError))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(transaction)
+		return
+	}
+
+	accountID, err := uuid.Parse(q.Get("account_id"))
+	if err != nil {
+		http.Error(w, "Invalid account_id", http.StatusBadRequest)
+		return
+	}
+	transactions, err := h.service.ListPendingTransactions(accountID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transactions)
+}
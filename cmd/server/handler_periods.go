@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/nathanmocogni/core-banking-system/internal/ledger"
+)
+
+type CreatePeriodRequest struct {
+	Name      string `json:"name"`
+	StartDate string `json:"start_date"` // YYYY-MM-DD
+	EndDate   string `json:"end_date"`   // YYYY-MM-DD, inclusive
+}
+
+// HandlePeriods lists accounting periods (GET /periods) or opens a new one (POST /periods).
+func (h *Handler) HandlePeriods(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		periods, err := h.service.ListPeriods()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(periods)
+
+	case http.MethodPost:
+		var req CreatePeriodRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		start, err := parseDate(req.StartDate)
+		if err != nil {
+			http.Error(w, "Invalid start_date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		end, err := parseDate(req.EndDate)
+		if err != nil {
+			http.Error(w, "Invalid end_date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		period, err := h.service.CreatePeriod(req.Name, start, end)
+		if err != nil {
+			http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusBadRequest))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(period)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+type PeriodStatusRequest struct {
+	Status ledger.PeriodStatus `json:"status"` // OPEN, SOFT_CLOSED or HARD_CLOSED
+}
+
+// SetPeriodStatus serves PUT /periods/status?id=... to soft-close, reopen or hard-close a period.
+func (h *Handler) SetPeriodStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+
+	var req PeriodStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	period, err := h.service.SetPeriodStatus(id, req.Status)
+	if err != nil {
+		http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(period)
+}
+
+type RetainedEarningsRequest struct {
+	AccountID uuid.UUID `json:"account_id"`
+}
+
+// HandleRetainedEarnings lists (GET) or sets (PUT) the retained earnings account per currency.
+func (h *Handler) HandleRetainedEarnings(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		accounts, err := h.service.GetRetainedEarningsAccounts()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(accounts)
+
+	case http.MethodPut:
+		var req RetainedEarningsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := h.service.SetRetainedEarningsAccount(req.AccountID); err != nil {
+			http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusBadRequest))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+type YearEndCloseRequest struct {
+	Currency string `json:"currency"`
+	YearEnd  string `json:"year_end"` // YYYY-MM-DD, the last day of a SOFT_CLOSED period
+}
+
+// CloseYear serves POST /periods/year-end-close.
+func (h *Handler) CloseYear(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req YearEndCloseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	yearEnd, err := parseDate(req.YearEnd)
+	if err != nil || yearEnd.IsZero() {
+		http.Error(w, "Invalid year_end, expected YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.service.CloseYear(req.Currency, yearEnd)
+	if err != nil {
+		http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
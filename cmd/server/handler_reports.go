@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/nathanmocogni/core-banking-system/internal/ledger"
+)
+
+// reportParams reads currency, from and to (or as_of) shared by the report endpoints.
+func reportParams(r *http.Request) (currency string, from, to time.Time, err error) {
+	q := r.URL.Query()
+	currency = q.Get("currency")
+	if currency == "" {
+		return "", from, to, fmt.Errorf("missing currency parameter")
+	}
+	if from, err = parseDate(q.Get("from")); err != nil {
+		return "", from, to, fmt.Errorf("invalid from, expected YYYY-MM-DD")
+	}
+	toStr := q.Get("to")
+	if toStr == "" {
+		toStr = q.Get("as_of")
+	}
+	if to, err = parseDate(toStr); err != nil {
+		return "", from, to, fmt.Errorf("invalid to, expected YYYY-MM-DD")
+	}
+	if to.IsZero() {
+		to = ledger.DateOf(time.Now())
+	}
+	return currency, from, to, nil
+}
+
+// wantsCSV reports whether the client asked for CSV (?format=csv or Accept: text/csv).
+func wantsCSV(r *http.Request) bool {
+	return r.URL.Query().Get("format") == "csv" || r.Header.Get("Accept") == "text/csv"
+}
+
+func writeCSV(w http.ResponseWriter, filename string, rows [][]string) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	cw := csv.NewWriter(w)
+	cw.WriteAll(rows)
+}
+
+func i64(n int64) string { return strconv.FormatInt(n, 10) }
+
+func checkRows(c ledger.IntegrityCheck) [][]string {
+	return [][]string{
+		{"check", "total_debits", i64(c.TotalDebits)},
+		{"check", "total_credits", i64(c.TotalCredits)},
+		{"check", "balanced", strconv.FormatBool(c.Balanced)},
+	}
+}
+
+// GetTrialBalance serves GET /reports/trial-balance?currency=USD&from=...&to=...[&format=csv].
+func (h *Handler) GetTrialBalance(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	currency, from, to, err := reportParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tb, err := h.service.GetTrialBalance(currency, from, to)
+	if err != nil {
+		http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	if wantsCSV(r) {
+		rows := [][]string{{"account_id", "name", "type", "opening_balance", "debits", "credits", "closing_balance", "debit", "credit"}}
+		for _, l := range tb.Lines {
+			rows = append(rows, []string{l.AccountID.String(), l.Name, string(l.Type), i64(l.OpeningBalance),
+				i64(l.Debits), i64(l.Credits), i64(l.ClosingBalance), i64(l.Debit), i64(l.Credit)})
+		}
+		rows = append(rows, []string{"", "TOTAL", "", "", "", "", "", i64(tb.TotalDebit), i64(tb.TotalCredit)})
+		rows = append(rows, checkRows(tb.Check)...)
+		writeCSV(w, fmt.Sprintf("trial-balance-%s-%s.csv", currency, to.Format("2006-01-02")), rows)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tb)
+}
+
+// GetBalanceSheet serves GET /reports/balance-sheet?currency=USD&as_of=...[&format=csv].
+func (h *Handler) GetBalanceSheet(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	currency, _, asOf, err := reportParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	bs, err := h.service.GetBalanceSheet(currency, asOf)
+	if err != nil {
+		http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	if wantsCSV(r) {
+		rows := [][]string{{"section", "account_id", "name", "amount"}}
+		for _, sec := range []struct {
+			name  string
+			lines []ledger.ReportLine
+			total int64
+		}{{"ASSETS", bs.Assets, bs.TotalAssets}, {"LIABILITIES", bs.Liabilities, bs.TotalLiabilities}, {"EQUITY", bs.Equity, bs.TotalEquity}} {
+			for _, l := range sec.lines {
+				rows = append(rows, []string{sec.name, l.AccountID.String(), l.Name, i64(l.Amount)})
+			}
+			rows = append(rows, []string{sec.name, "", "TOTAL", i64(sec.total)})
+		}
+		rows = append(rows, []string{"EQUITY", "", "CURRENT EARNINGS", i64(bs.CurrentEarnings)})
+		rows = append(rows, []string{"check", "", "balance_sheet_balanced", strconv.FormatBool(bs.Balanced)})
+		for _, c := range checkRows(bs.Check) {
+			rows = append(rows, []string{c[0], "", c[1], c[2]})
+		}
+		writeCSV(w, fmt.Sprintf("balance-sheet-%s-%s.csv", currency, asOf.Format("2006-01-02")), rows)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(bs)
+}
+
+// GetIncomeStatement serves GET /reports/income-statement?currency=USD&from=...&to=...[&format=csv].
+func (h *Handler) GetIncomeStatement(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	currency, from, to, err := reportParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	is, err := h.service.GetIncomeStatement(currency, from, to)
+	if err != nil {
+		http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	if wantsCSV(r) {
+		rows := [][]string{{"section", "account_id", "name", "amount"}}
+		for _, l := range is.Income {
+			rows = append(rows, []string{"INCOME", l.AccountID.String(), l.Name, i64(l.Amount)})
+		}
+		rows = append(rows, []string{"INCOME", "", "TOTAL", i64(is.TotalIncome)})
+		for _, l := range is.Expenses {
+			rows = append(rows, []string{"EXPENSES", l.AccountID.String(), l.Name, i64(l.Amount)})
+		}
+		rows = append(rows, []string{"EXPENSES", "", "TOTAL", i64(is.TotalExpenses)})
+		rows = append(rows, []string{"NET", "", "NET INCOME", i64(is.NetIncome)})
+		for _, c := range checkRows(is.Check) {
+			rows = append(rows, []string{c[0], "", c[1], c[2]})
+		}
+		writeCSV(w, fmt.Sprintf("income-statement-%s-%s.csv", currency, to.Format("2006-01-02")), rows)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(is)
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nathanmocogni/core-banking-system/internal/standingorder"
+)
+
+type StandingOrderHandler struct {
+	service *standingorder.Service
+}
+
+func NewStandingOrderHandler(s *standingorder.Service) *StandingOrderHandler {
+	return &StandingOrderHandler{service: s}
+}
+
+type CreateStandingOrderRequest struct {
+	FromAccountID        uuid.UUID               `json:"from_account_id"`
+	ToAccountID          uuid.UUID               `json:"to_account_id"`
+	Amount               int64                   `json:"amount"`
+	Currency             string                  `json:"currency"`
+	Description          string                  `json:"description"`
+	Frequency            standingorder.Frequency `json:"frequency"`
+	Interval             int                     `json:"interval"`
+	DayOfMonth           int                     `json:"day_of_month"`
+	StartDate            string                  `json:"start_date"` // YYYY-MM-DD, defaults to today
+	EndDate              string                  `json:"end_date"`   // YYYY-MM-DD
+	MaxExecutions        *int                    `json:"max_executions"`
+	MaxRetries           *int                    `json:"max_retries"`            // Defaults to 3
+	RetryIntervalMinutes *int                    `json:"retry_interval_minutes"` // Defaults to 240
+}
+
+type UpdateStandingOrderRequest struct {
+	Amount               *int64  `json:"amount"`
+	Description          *string `json:"description"`
+	EndDate              string  `json:"end_date"` // YYYY-MM-DD
+	MaxExecutions        *int    `json:"max_executions"`
+	MaxRetries           *int    `json:"max_retries"`
+	RetryIntervalMinutes *int    `json:"retry_interval_minutes"`
+}
+
+// retryPolicy applies the requested retry settings to base.
+func retryPolicy(base standingorder.RetryPolicy, maxRetries, intervalMinutes *int) standingorder.RetryPolicy {
+	if maxRetries != nil {
+		base.MaxRetries = *maxRetries
+	}
+	if intervalMinutes != nil {
+		base.RetryInterval = time.Duration(*intervalMinutes) * time.Minute
+	}
+	return base
+}
+
+// standingOrderErrorStatus maps standing order errors to HTTP status codes, and
+// ledger errors as ledgerErrorStatus does.
+func standingOrderErrorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, standingorder.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, standingorder.ErrInvalidOrder):
+		return http.StatusBadRequest
+	case errors.Is(err, standingorder.ErrInvalidStatus):
+		return http.StatusConflict
+	default:
+		return ledgerErrorStatus(err, fallback)
+	}
+}
+
+// HandleStandingOrders serves standing orders:
+//   - GET /standing-orders?account_id={id} lists an account's orders; ?id={id} returns one.
+//   - POST /standing-orders creates an order.
+//   - PUT /standing-orders?id={id} changes its amount, description, end or retry policy.
+//   - DELETE /standing-orders?id={id} cancels it.
+func (h *StandingOrderHandler) HandleStandingOrders(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	switch r.Method {
+	case http.MethodGet:
+		if q.Get("id") != "" {
+			id, err := uuid.Parse(q.Get("id"))
+			if err != nil {
+				http.Error(w, "Invalid UUID", http.StatusBadRequest)
+				return
+			}
+			order, err := h.service.Get(id)
+			if err != nil {
+				http.Error(w, err.Error(), standingOrderErrorStatus(err, http.StatusInternalServerError))
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(order)
+			return
+		}
+		accountID, err := uuid.Parse(q.Get("account_id"))
+		if err != nil {
+			http.Error(w, "Invalid account_id", http.StatusBadRequest)
+			return
+		}
+		orders, err := h.service.List(accountID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(orders)
+
+	case http.MethodPost:
+		var req CreateStandingOrderRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		startDate, err := parseDate(req.StartDate)
+		if err != nil {
+			http.Error(w, "Invalid start_date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		endDate, err := parseDate(req.EndDate)
+		if err != nil {
+			http.Error(w, "Invalid end_date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		order := standingorder.StandingOrder{
+			FromAccountID: req.FromAccountID,
+			ToAccountID:   req.ToAccountID,
+			Amount:        req.Amount,
+			Currency:      req.Currency,
+			Description:   req.Description,
+			Frequency:     req.Frequency,
+			Interval:      req.Interval,
+			DayOfMonth:    req.DayOfMonth,
+			StartDate:     startDate,
+			MaxExecutions: req.MaxExecutions,
+			Retry:         retryPolicy(standingorder.DefaultRetryPolicy, req.MaxRetries, req.RetryIntervalMinutes),
+		}
+		if !endDate.IsZero() {
+			order.EndDate = &endDate
+		}
+		created, err := h.service.Create(order)
+		if err != nil {
+			http.Error(w, err.Error(), standingOrderErrorStatus(err, http.StatusInternalServerError))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(created)
+
+	case http.MethodPut:
+		id, err := uuid.Parse(q.Get("id"))
+		if err != nil {
+			http.Error(w, "Invalid UUID", http.StatusBadRequest)
+			return
+		}
+		var req UpdateStandingOrderRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		endDate, err := parseDate(req.EndDate)
+		if err != nil {
+			http.Error(w, "Invalid end_date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		changes := standingorder.Changes{
+			Amount:        req.Amount,
+			Description:   req.Description,
+			MaxExecutions: req.MaxExecutions,
+		}
+		if !endDate.IsZero() {
+			changes.EndDate = &endDate
+		}
+		if req.MaxRetries != nil || req.RetryIntervalMinutes != nil {
+			current, err := h.service.Get(id)
+			if err != nil {
+				http.Error(w, err.Error(), standingOrderErrorStatus(err, http.StatusInternalServerError))
+				return
+			}
+			retry := retryPolicy(current.Retry, req.MaxRetries, req.RetryIntervalMinutes)
+			changes.Retry = &retry
+		}
+		order, err := h.service.Update(id, changes)
+		if err != nil {
+			http.Error(w, err.Error(), standingOrderErrorStatus(err, http.StatusInternalServerError))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(order)
+
+	case http.MethodDelete:
+		id, err := uuid.Parse(q.Get("id"))
+		if err != nil {
+			http.Error(w, "Invalid UUID", http.StatusBadRequest)
+			return
+		}
+		order, err := h.service.SetStatus(id, standingorder.StatusCancelled)
+		if err != nil {
+			http.Error(w, err.Error(), standingOrderErrorStatus(err, http.StatusInternalServerError))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(order)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+type StandingOrderStatusRequest struct {
+	Status standingorder.Status `json:"status"` // ACTIVE or PAUSED
+}
+
+// SetStatus pauses or resumes a standing order (PUT /standing-orders/status?id=...).
+func (h *StandingOrderHandler) SetStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+	var req StandingOrderStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.service.SetStatus(id, req.Status)
+	if err != nil {
+		http.Error(w, err.Error(), standingOrderErrorStatus(err, http.StatusInternalServerError))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+// ListExecutions returns the attempts made for a standing order
+// (GET /standing-orders/executions?id=...), newest first.
+func (h *StandingOrderHandler) ListExecutions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.service.Get(id); err != nil {
+		http.Error(w, err.Error(), standingOrderErrorStatus(err, http.StatusInternalServerError))
+		return
+	}
+	executions, err := h.service.ListExecutions(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(executions)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/nathanmocogni/core-banking-system/internal/ledger"
+)
+
+// GetAccountStatement serves GET /accounts/statement?id=...&from=...&to=...[&format=csv|html].
+// The statement is generated on the fly and not stored.
+func (h *Handler) GetAccountStatement(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	id, err := uuid.Parse(q.Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+	from, err := parseDate(q.Get("from"))
+	if err != nil || from.IsZero() {
+		http.Error(w, "Invalid from date", http.StatusBadRequest)
+		return
+	}
+	to, err := parseDate(q.Get("to"))
+	if err != nil || to.IsZero() {
+		http.Error(w, "Invalid to date", http.StatusBadRequest)
+		return
+	}
+
+	st, err := h.service.GenerateStatement(id, from, to)
+	if err != nil {
+		http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusBadRequest))
+		return
+	}
+	writeStatement(w, r, st)
+}
+
+// HandleStatements serves stored statements: GET /statements?account_id=... lists an
+// account's statements, GET /statements?id=...[&format=csv|html] returns one.
+func (h *Handler) HandleStatements(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	if q.Get("id") == "" {
+		accountID, err := uuid.Parse(q.Get("account_id"))
+		if err != nil {
+			http.Error(w, "Invalid UUID", http.StatusBadRequest)
+			return
+		}
+		statements, err := h.service.ListStatements(accountID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(statements)
+		return
+	}
+
+	id, err := uuid.Parse(q.Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+	st, err := h.service.GetStatement(id)
+	if err != nil {
+		http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusInternalServerError))
+		return
+	}
+	writeStatement(w, r, st)
+}
+
+func writeStatement(w http.ResponseWriter, r *http.Request, st *ledger.Statement) {
+	switch {
+	case wantsCSV(r):
+		writeCSV(w, fmt.Sprintf("statement-%s-%s.csv", st.AccountID, st.To.Format("2006-01-02")), st.CSVRows())
+	case r.URL.Query().Get("format") == "html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		st.WriteHTML(w)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(st)
+	}
+}
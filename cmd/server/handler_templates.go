@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/nathanmocogni/core-banking-system/internal/ledger"
+)
+
+type UpdatePostingTemplateRequest struct {
+	Description string                       `json:"description"`
+	Lines       []ledger.PostingTemplateLine `json:"lines"`
+}
+
+// HandlePostingTemplates serves the posting templates: GET lists them, POST creates one,
+// PUT ?id=... replaces a template's description and lines and DELETE ?id=... removes it.
+func (h *Handler) HandlePostingTemplates(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		templates, err := h.service.ListPostingTemplates()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(templates)
+
+	case http.MethodPost:
+		var req ledger.PostingTemplate
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		t, err := h.service.CreatePostingTemplate(req)
+		if err != nil {
+			http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusInternalServerError))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(t)
+
+	case http.MethodPut:
+		id, err := uuid.Parse(r.URL.Query().Get("id"))
+		if err != nil {
+			http.Error(w, "Invalid UUID", http.StatusBadRequest)
+			return
+		}
+		var req UpdatePostingTemplateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		t, err := h.service.UpdatePostingTemplate(id, req.Description, req.Lines)
+		if err != nil {
+			http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusInternalServerError))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(t)
+
+	case http.MethodDelete:
+		id, err := uuid.Parse(r.URL.Query().Get("id"))
+		if err != nil {
+			http.Error(w, "Invalid UUID", http.StatusBadRequest)
+			return
+		}
+		if err := h.service.DeletePostingTemplate(id); err != nil {
+			http.Error(w, err.Error(), ledgerErrorStatus(err, http.StatusInternalServerError))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -7,17 +7,21 @@ import (
 	"net/http"
 	"os"
 	"strconv"
-
 	"strings"
+	"time"
 
+	migrations "github.com/nathanmocogni/core-banking-system/db"
 	"github.com/nathanmocogni/core-banking-system/internal/audit"
 	"github.com/nathanmocogni/core-banking-system/internal/auth"
 	"github.com/nathanmocogni/core-banking-system/internal/batch"
 	"github.com/nathanmocogni/core-banking-system/internal/database"
 	"github.com/nathanmocogni/core-banking-system/internal/events"
+	"github.com/nathanmocogni/core-banking-system/internal/idempotency"
 	"github.com/nathanmocogni/core-banking-system/internal/integration"
 	"github.com/nathanmocogni/core-banking-system/internal/ledger"
+	"github.com/nathanmocogni/core-banking-system/internal/loan"
 	"github.com/nathanmocogni/core-banking-system/internal/payment"
+	"github.com/nathanmocogni/core-banking-system/internal/standingorder"
 	"github.com/nathanmocogni/core-banking-system/internal/workflow"
 )
 
@@ -40,6 +44,20 @@ func main() {
 	}
 	defer db.Close()
 
+	// Schema Migrations
+	// Set RUN_MIGRATIONS=true to apply pending migrations on startup (also available as cmd/migrate).
+	if os.Getenv("RUN_MIGRATIONS") == "true" {
+		migrator, err := database.NewMigrator(db, migrations.Migrations)
+		if err != nil {
+			log.Fatalf("Could not load migrations: %v", err)
+		}
+		n, err := migrator.Up(context.Background())
+		if err != nil {
+			log.Fatalf("Could not apply migrations: %v", err)
+		}
+		fmt.Printf("Applied %d migration(s)\n", n)
+	}
+
 	// Kafka Setup
 	kafkaBrokers := os.Getenv("KAFKA_BROKERS")
 	var producer *events.Producer
@@ -49,7 +67,7 @@ func main() {
 		defer producer.Close()
 		fmt.Printf("Kafka Producer initialized for brokers: %v\n", brokers)
 	} else {
-		fmt.Println("Warning: KAFKA_BROKERS not set, events will not be published.")
+		fmt.Println("Warning: KAFKA_BROKERS not set, events will stay in the outbox until a relay runs.")
 	}
 
 	// Audit Consumer Setup
@@ -63,21 +81,75 @@ func main() {
 		}()
 	}
 
+	// Outbox Relay Setup
+	// Ledger events are written to the outbox table; the relay delivers them to Kafka.
+	if producer != nil {
+		relay := events.NewOutboxRelay(db, producer)
+		go func() {
+			fmt.Println("Starting Outbox Relay...")
+			relay.Start(context.Background())
+		}()
+	}
+
 	fmt.Println("Starting server on :8080...")
 
-	service := ledger.NewService(db, producer)
+	service := ledger.NewService(db)
+
+	// Posting Policy
+	// How many days value and effective dates may be back- or forward-dated.
+	policy := ledger.DefaultPostingPolicy
+	if v := os.Getenv("POSTING_MAX_BACKDATE_DAYS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			log.Fatalf("Invalid POSTING_MAX_BACKDATE_DAYS %q", v)
+		}
+		policy.MaxBackdateDays = n
+	}
+	if v := os.Getenv("POSTING_MAX_FORWARD_DAYS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			log.Fatalf("Invalid POSTING_MAX_FORWARD_DAYS %q", v)
+		}
+		policy.MaxForwardDays = n
+	}
+	service.SetPostingPolicy(policy)
+
 	// Batch Engine Setup
 	batchEngine := batch.NewEngine(db, service)
 	batchEngine.RegisterJob(batch.NewDailyAccrualJob(service))
 	batchEngine.RegisterJob(batch.NewCapitalizationJob(service))
 	batchEngine.RegisterJob(batch.NewFeeSweeperJob(service))
+	batchEngine.RegisterJob(batch.NewHoldExpiryJob(service))
+	batchEngine.RegisterJob(batch.NewStatementJob(service))
+	// Drifted balance caches are only rewritten when explicitly enabled.
+	batchEngine.RegisterJob(batch.NewIntegrityCheckJob(service, os.Getenv("LEDGER_INTEGRITY_REPAIR") == "true"))
 
 	// Workflow Engine Setup
 	workflowEngine := workflow.NewEngine(db)
 
+	// Idempotency Setup
+	// Retried POSTs with the same Idempotency-Key replay the stored response until the key expires.
+	idempotencyRetention := 24 * time.Hour
+	if v := os.Getenv("IDEMPOTENCY_RETENTION"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("Invalid IDEMPOTENCY_RETENTION %q: %v", v, err)
+		}
+		idempotencyRetention = d
+	}
+	idempotencyStore := idempotency.NewPostgresStore(db)
+	idempotent := idempotency.Middleware(idempotencyStore, idempotencyRetention)
+	batchEngine.RegisterJob(batch.NewIdempotencyPurgeJob(idempotencyStore))
+
 	handler := NewHandler(service, batchEngine, workflowEngine)
 	paymentService := payment.NewService(service)
 	paymentHandler := NewPaymentHandler(paymentService)
+	standingOrderService := standingorder.NewService(db, service, paymentService)
+	standingOrderHandler := NewStandingOrderHandler(standingOrderService)
+	batchEngine.RegisterJob(batch.NewStandingOrderJob(standingOrderService))
+	loanService := loan.NewService(db, service)
+	loanHandler := NewLoanHandler(loanService)
+	batchEngine.RegisterJob(batch.NewLoanDelinquencyJob(loanService))
 
 	// Security Service Setup
 	marketData := integration.NewMockMarketDataProvider()
@@ -116,7 +188,7 @@ func main() {
 		}
 	})))
 
-	http.Handle("/transactions", auth.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	http.Handle("/transactions", auth.Middleware(idempotent(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodPost {
 			handler.PostTransaction(w, r)
 		} else if r.Method == http.MethodGet {
@@ -124,7 +196,12 @@ func main() {
 		} else {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
-	})))
+	}))))
+	http.Handle("/transactions/reverse", auth.Middleware(idempotent(http.HandlerFunc(handler.ReverseTransaction))))
+	http.Handle("/transactions/authorize", auth.Middleware(idempotent(http.HandlerFunc(handler.AuthorizeTransaction))))
+	http.Handle("/transactions/post-pending", auth.Middleware(idempotent(http.HandlerFunc(handler.PostPendingTransaction))))
+	http.Handle("/transactions/void", auth.Middleware(idempotent(http.HandlerFunc(handler.VoidPendingTransaction))))
+	http.Handle("/transactions/pending", auth.Middleware(http.HandlerFunc(handler.GetPendingTransactions)))
 	http.Handle("/products", auth.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodGet {
 			handler.ListProducts(w, r)
@@ -163,11 +240,50 @@ func main() {
 	})))
 	http.Handle("/rules/clone", auth.Middleware(http.HandlerFunc(handler.CloneRule)))
 	http.Handle("/accounts/product", auth.Middleware(http.HandlerFunc(handler.AssignProduct)))
+	http.Handle("/accounts/balance", auth.Middleware(http.HandlerFunc(handler.GetAccountBalance)))
+	http.Handle("/accounts/status", auth.Middleware(http.HandlerFunc(handler.SetAccountStatus)))
+	http.Handle("/accounts/overdraft", auth.Middleware(http.HandlerFunc(handler.SetAccountOverdraft)))
+	http.Handle("/accounts/statement", auth.Middleware(http.HandlerFunc(handler.GetAccountStatement)))
+	http.Handle("/statements", auth.Middleware(http.HandlerFunc(handler.HandleStatements)))
+	http.Handle("/products/overdraft", auth.Middleware(http.HandlerFunc(handler.SetProductOverdraft)))
+	http.Handle("/products/interest", auth.Middleware(http.HandlerFunc(handler.SetProductInterest)))
+
+	http.Handle("/reports/trial-balance", auth.Middleware(http.HandlerFunc(handler.GetTrialBalance)))
+	http.Handle("/reports/balance-sheet", auth.Middleware(http.HandlerFunc(handler.GetBalanceSheet)))
+	http.Handle("/reports/income-statement", auth.Middleware(http.HandlerFunc(handler.GetIncomeStatement)))
+	http.Handle("/reports/gl", auth.Middleware(http.HandlerFunc(handler.GetGLReport)))
+
+	http.Handle("/gl-accounts", auth.Middleware(http.HandlerFunc(handler.HandleGLAccounts)))
+	http.Handle("/accounts/gl", auth.Middleware(http.HandlerFunc(handler.SetAccountGL)))
+	http.Handle("/products/control-gl", auth.Middleware(http.HandlerFunc(handler.SetProductControlGL)))
+	http.Handle("/posting-templates", auth.Middleware(http.HandlerFunc(handler.HandlePostingTemplates)))
+
+	http.Handle("/periods", auth.Middleware(http.HandlerFunc(handler.HandlePeriods)))
+	http.Handle("/periods/status", auth.Middleware(http.HandlerFunc(handler.SetPeriodStatus)))
+	http.Handle("/periods/retained-earnings", auth.Middleware(http.HandlerFunc(handler.HandleRetainedEarnings)))
+	http.Handle("/periods/year-end-close", auth.Middleware(idempotent(http.HandlerFunc(handler.CloseYear))))
+
+	http.Handle("/fx/rates", auth.Middleware(http.HandlerFunc(handler.HandleFXRates)))
+
+	http.Handle("/holds", auth.Middleware(idempotent(http.HandlerFunc(handler.HandleHolds))))
+	http.Handle("/holds/capture", auth.Middleware(idempotent(http.HandlerFunc(handler.CaptureHold))))
+	http.Handle("/holds/release", auth.Middleware(idempotent(http.HandlerFunc(handler.ReleaseHold))))
 	http.Handle("/interest/calculate", auth.Middleware(http.HandlerFunc(handler.CalculateInterest)))
+	http.Handle("/interest/capitalize", auth.Middleware(http.HandlerFunc(handler.CapitalizeInterest)))
+	http.Handle("/interest/accrued", auth.Middleware(http.HandlerFunc(handler.GetAccruedInterest)))
+	http.Handle("/interest/reconciliation", auth.Middleware(http.HandlerFunc(handler.ReconcileInterest)))
+
+	http.Handle("/payments/deposit", auth.Middleware(idempotent(http.HandlerFunc(paymentHandler.Deposit))))
+	http.Handle("/payments/withdraw", auth.Middleware(idempotent(http.HandlerFunc(paymentHandler.Withdraw))))
+	http.Handle("/payments/transfer", auth.Middleware(idempotent(http.HandlerFunc(paymentHandler.Transfer))))
+	http.Handle("/standing-orders", auth.Middleware(idempotent(http.HandlerFunc(standingOrderHandler.HandleStandingOrders))))
+	http.Handle("/standing-orders/status", auth.Middleware(http.HandlerFunc(standingOrderHandler.SetStatus)))
+	http.Handle("/standing-orders/executions", auth.Middleware(http.HandlerFunc(standingOrderHandler.ListExecutions)))
 
-	http.Handle("/payments/deposit", auth.Middleware(http.HandlerFunc(paymentHandler.Deposit)))
-	http.Handle("/payments/withdraw", auth.Middleware(http.HandlerFunc(paymentHandler.Withdraw)))
-	http.Handle("/payments/transfer", auth.Middleware(http.HandlerFunc(paymentHandler.Transfer)))
+	http.Handle("/loans", auth.Middleware(idempotent(http.HandlerFunc(loanHandler.HandleLoans))))
+	http.Handle("/loans/products", auth.Middleware(http.HandlerFunc(loanHandler.HandleProducts)))
+	http.Handle("/loans/schedule", auth.Middleware(http.HandlerFunc(loanHandler.GetSchedule)))
+	http.Handle("/loans/repayments", auth.Middleware(idempotent(http.HandlerFunc(loanHandler.HandleRepayments))))
 
 	http.Handle("/securities", auth.Middleware(http.HandlerFunc(securityHandler.HandleSecurities)))
 	http.Handle("/securities/sync", auth.Middleware(http.HandlerFunc(securityHandler.SyncPrice)))
@@ -177,6 +293,9 @@ func main() {
 	// Batch & Workflow Endpoints
 	http.Handle("/admin/batches", auth.Middleware(http.HandlerFunc(handler.ListBatches)))
 	http.Handle("/admin/batches/trigger", auth.Middleware(http.HandlerFunc(handler.TriggerBatch)))
+	http.Handle("/admin/imports", auth.Middleware(http.HandlerFunc(handler.HandleImports)))
+	http.Handle("/admin/integrity/verify", auth.Middleware(http.HandlerFunc(handler.VerifyIntegrity)))
+	http.Handle("/admin/integrity/reports", auth.Middleware(http.HandlerFunc(handler.GetIntegrityReports)))
 	http.Handle("/workflow/approvals", auth.Middleware(http.HandlerFunc(handler.ListPendingApprovals)))
 	http.Handle("/workflow/approve", auth.Middleware(http.HandlerFunc(handler.ApproveWorkflow)))
 	http.Handle("/workflow/reject", auth.Middleware(http.HandlerFunc(handler.RejectWorkflow)))
@@ -194,7 +313,8 @@ func corsMiddleware(next http.Handler) http.Handler {
 		// TODO: Change "*" to your specific domain in production
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
-		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, Idempotency-Key")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Next-Cursor")
 
 		// Handle preflight requests
 		if r.Method == "OPTIONS" {
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/nathanmocogni/core-banking-system/internal/common"
 	"github.com/nathanmocogni/core-banking-system/internal/ledger"
 )
 
@@ -35,12 +36,19 @@ func (h *SecurityHandler) CreateSecurity(w http.ResponseWriter, r *http.Request)
 }
 
 func (h *SecurityHandler) ListSecurities(w http.ResponseWriter, r *http.Request) {
-	securities, err := h.Service.Repo.ListSecurities(r.Context())
+	page, err := common.ParsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	securities, next, err := h.Service.Repo.ListSecurities(r.Context(), page)
 	if err != nil {
 		http.Error(w, "Failed to list securities: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	common.SetNextCursor(w, next)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(securities)
 }
@@ -0,0 +1,9 @@
+// Package db holds the versioned SQL migrations for the ledger database.
+package db
+
+import "embed"
+
+// Migrations contains every migration file, named <version>_<name>.<up|down>.sql.
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
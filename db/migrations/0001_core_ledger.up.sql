@@ -0,0 +1,74 @@
+-- Core Ledger: products, clients, accounts and the journal.
+-- Statements use IF NOT EXISTS so databases created from the legacy
+-- schema.sql/update_*.sql scripts can be adopted without a rebuild.
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+CREATE EXTENSION IF NOT EXISTS "pgcrypto";
+
+-- Products Table
+CREATE TABLE IF NOT EXISTS products (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL,
+    interest_rate_bps BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS status VARCHAR(20) DEFAULT 'DRAFT';
+ALTER TABLE products ADD COLUMN IF NOT EXISTS version INT DEFAULT 1;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS parent_product_id UUID;
+
+-- Clients Table
+CREATE TABLE IF NOT EXISTS clients (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    external_id VARCHAR(50) UNIQUE NOT NULL, -- For mapping to external CRM
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(20) NOT NULL, -- Individual, Corporate
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING', -- Active, Suspended, Pending
+    risk_rating VARCHAR(20) NOT NULL DEFAULT 'MEDIUM', -- Low, Medium, High
+    tax_domicile CHAR(2) NOT NULL, -- ISO Country Code
+    classification VARCHAR(20) NOT NULL DEFAULT 'RETAIL', -- Retail, Professional, Institutional
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_clients_external_id ON clients(external_id);
+
+-- Accounts Table
+CREATE TABLE IF NOT EXISTS accounts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(50) NOT NULL CHECK (type IN ('ASSET', 'LIABILITY', 'EQUITY', 'INCOME', 'EXPENSE')),
+    currency CHAR(3) NOT NULL,
+    balance BIGINT NOT NULL DEFAULT 0, -- Cached balance (read model), maintained by PostTransaction
+    product_id UUID REFERENCES products(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE accounts
+ADD COLUMN IF NOT EXISTS account_category VARCHAR(20) DEFAULT 'CASH', -- Cash, Custody, System
+ADD COLUMN IF NOT EXISTS client_id UUID REFERENCES clients(id),
+ADD COLUMN IF NOT EXISTS ownership_type VARCHAR(20) DEFAULT 'INDIVIDUAL', -- Individual, Joint
+ADD COLUMN IF NOT EXISTS fee_schedule_id VARCHAR(50); -- Placeholder for Fee Schedule link
+
+CREATE INDEX IF NOT EXISTS idx_accounts_client_id ON accounts(client_id);
+
+-- Transactions Table (The Journal)
+CREATE TABLE IF NOT EXISTS transactions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    reference VARCHAR(255) UNIQUE NOT NULL,
+    description TEXT,
+    posted_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    metadata JSONB
+);
+
+-- Entries Table (The Ledger Lines)
+CREATE TABLE IF NOT EXISTS entries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    transaction_id UUID NOT NULL REFERENCES transactions(id),
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    direction VARCHAR(10) NOT NULL CHECK (direction IN ('DEBIT', 'CREDIT')),
+    amount BIGINT NOT NULL CHECK (amount > 0), -- Always positive, direction determines sign
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_entries_account_id ON entries(account_id);
+CREATE INDEX IF NOT EXISTS idx_entries_transaction_id ON entries(transaction_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS security_prices;
+DROP TABLE IF EXISTS securities;
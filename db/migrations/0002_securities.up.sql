@@ -0,0 +1,44 @@
+-- Securities Table
+CREATE TABLE IF NOT EXISTS securities (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    symbol VARCHAR(20) UNIQUE NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(50) NOT NULL, -- e.g., 'STOCK', 'BOND', 'ETF'
+    currency CHAR(3) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE securities
+ADD COLUMN IF NOT EXISTS isin VARCHAR(12),
+ADD COLUMN IF NOT EXISTS cusip VARCHAR(9),
+ADD COLUMN IF NOT EXISTS sedol VARCHAR(7),
+ADD COLUMN IF NOT EXISTS bloom_reuters_code VARCHAR(50),
+ADD COLUMN IF NOT EXISTS asset_class VARCHAR(50), -- Equity, Fixed Income, etc.
+ADD COLUMN IF NOT EXISTS country_of_issue CHAR(2), -- ISO Country Code
+ADD COLUMN IF NOT EXISTS quotational_basis VARCHAR(20), -- Per Unit, Percentage of Par
+ADD COLUMN IF NOT EXISTS coupon_rate DECIMAL(10, 6),
+ADD COLUMN IF NOT EXISTS coupon_type VARCHAR(20), -- Fixed, Floating, Zero
+ADD COLUMN IF NOT EXISTS frequency VARCHAR(20), -- Annual, Semi-Annual
+ADD COLUMN IF NOT EXISTS day_count_convention VARCHAR(20), -- 30/360, Actual/360
+ADD COLUMN IF NOT EXISTS issue_date DATE,
+ADD COLUMN IF NOT EXISTS maturity_date DATE,
+ADD COLUMN IF NOT EXISTS primary_exchange VARCHAR(50),
+ADD COLUMN IF NOT EXISTS trading_lot_size INTEGER,
+ADD COLUMN IF NOT EXISTS price_source VARCHAR(50);
+
+CREATE INDEX IF NOT EXISTS idx_securities_isin ON securities(isin);
+CREATE INDEX IF NOT EXISTS idx_securities_asset_class ON securities(asset_class);
+
+-- Security Prices Table
+CREATE TABLE IF NOT EXISTS security_prices (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    security_id UUID NOT NULL REFERENCES securities(id),
+    price DECIMAL(19, 4) NOT NULL,
+    currency CHAR(3) NOT NULL,
+    timestamp TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    source VARCHAR(50) NOT NULL -- e.g., 'MOCK', 'YAHOO'
+);
+
+CREATE INDEX IF NOT EXISTS idx_security_prices_security_id ON security_prices(security_id);
+CREATE INDEX IF NOT EXISTS idx_security_prices_timestamp ON security_prices(timestamp);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS audit_logs;
+DROP TABLE IF EXISTS languages;
+DROP TABLE IF EXISTS countries;
+DROP TABLE IF EXISTS currencies;
@@ -0,0 +1,57 @@
+-- Reference Data Tables
+CREATE TABLE IF NOT EXISTS currencies (
+    code CHAR(3) PRIMARY KEY,
+    numeric_code INT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    decimals INT NOT NULL,
+    is_active BOOLEAN DEFAULT TRUE
+);
+
+CREATE TABLE IF NOT EXISTS countries (
+    alpha2 CHAR(2) PRIMARY KEY,
+    alpha3 CHAR(3) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    risk_score INT DEFAULT 0 CHECK (risk_score >= 0 AND risk_score <= 100),
+    phone_prefix VARCHAR(10)
+);
+
+CREATE TABLE IF NOT EXISTS languages (
+    code VARCHAR(10) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    direction VARCHAR(3) DEFAULT 'LTR' CHECK (direction IN ('LTR', 'RTL'))
+);
+
+ALTER TABLE currencies ADD COLUMN IF NOT EXISTS status VARCHAR(20) DEFAULT 'ACTIVE';
+ALTER TABLE countries ADD COLUMN IF NOT EXISTS status VARCHAR(20) DEFAULT 'ACTIVE';
+ALTER TABLE languages ADD COLUMN IF NOT EXISTS status VARCHAR(20) DEFAULT 'ACTIVE';
+
+-- Seed Currencies
+INSERT INTO currencies (code, numeric_code, name, decimals, is_active) VALUES
+('USD', 840, 'United States Dollar', 2, TRUE),
+('EUR', 978, 'Euro', 2, TRUE),
+('GBP', 826, 'Pound Sterling', 2, TRUE),
+('CHF', 756, 'Swiss Franc', 2, TRUE),
+('JPY', 392, 'Japanese Yen', 0, TRUE)
+ON CONFLICT (code) DO NOTHING;
+
+-- Seed Countries
+INSERT INTO countries (alpha2, alpha3, name, risk_score, phone_prefix) VALUES
+('US', 'USA', 'United States', 10, '+1'),
+('GB', 'GBR', 'United Kingdom', 10, '+44'),
+('CH', 'CHE', 'Switzerland', 5, '+41'),
+('DE', 'DEU', 'Germany', 10, '+49'),
+('FR', 'FRA', 'France', 10, '+33')
+ON CONFLICT (alpha2) DO NOTHING;
+
+-- Audit Log Table
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    entity_name VARCHAR(100) NOT NULL,
+    entity_id UUID NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    actor_id UUID NOT NULL,
+    timestamp TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    changes JSONB
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_entity ON audit_logs(entity_name, entity_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS rules;
+DROP TABLE IF EXISTS fees;
@@ -1,4 +1,4 @@
--- Add status and version columns to fees table
+-- Fee Engine
 CREATE TABLE IF NOT EXISTS fees (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
     name VARCHAR(255) NOT NULL,
@@ -12,7 +12,7 @@ CREATE TABLE IF NOT EXISTS fees (
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
 
--- Add status and version columns to rules table (if it exists, or create it)
+-- Rules Engine
 CREATE TABLE IF NOT EXISTS rules (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
     name VARCHAR(255) NOT NULL,
@@ -24,8 +24,3 @@ CREATE TABLE IF NOT EXISTS rules (
     parent_rule_id UUID,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
-
--- Add status and version columns to reference data tables
-ALTER TABLE currencies ADD COLUMN IF NOT EXISTS status VARCHAR(20) DEFAULT 'ACTIVE'; -- Reference data usually starts active
-ALTER TABLE countries ADD COLUMN IF NOT EXISTS status VARCHAR(20) DEFAULT 'ACTIVE';
-ALTER TABLE languages ADD COLUMN IF NOT EXISTS status VARCHAR(20) DEFAULT 'ACTIVE';
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS workflow_approvals;
+DROP TABLE IF EXISTS workflow_instances;
+DROP TABLE IF EXISTS workflow_steps;
+DROP TABLE IF EXISTS workflow_definitions;
+DROP TABLE IF EXISTS batches;
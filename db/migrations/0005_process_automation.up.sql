@@ -48,10 +48,7 @@ CREATE TABLE IF NOT EXISTS workflow_approvals (
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
 
--- Seed some default workflows
+-- Seed default workflows
 INSERT INTO workflow_definitions (name, trigger_event, description)
-VALUES ('High Value Transfer', 'PAYMENT_INITIATED', 'Requires approval for transfers over 10,000');
-
--- We need to get the ID of the inserted definition to seed steps. 
--- In a real migration script we'd use a DO block or separate calls. 
--- For this prototype, we will handle seeding in the Go code or manually.
+VALUES ('High Value Transfer', 'PAYMENT_INITIATED', 'Requires approval for transfers over 10,000')
+ON CONFLICT (name) DO NOTHING;
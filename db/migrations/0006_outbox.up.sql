@@ -0,0 +1,19 @@
+-- Transactional Outbox
+-- Events are written here in the same database transaction as the change that
+-- produced them and delivered to Kafka by the outbox relay.
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id UUID PRIMARY KEY,
+    seq BIGSERIAL NOT NULL, -- Delivery order
+    aggregate_type VARCHAR(100) NOT NULL, -- e.g., TRANSACTION
+    aggregate_id VARCHAR(255) NOT NULL, -- Used as the Kafka message key
+    event_type VARCHAR(100) NOT NULL, -- e.g., TransactionPosted
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING', -- PENDING, SENT, FAILED
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    sent_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending ON outbox_events(next_attempt_at, seq) WHERE status = 'PENDING';
@@ -0,0 +1,9 @@
+-- Transaction Reversals
+-- A reversal is a mirror transaction linked to the original; both rows carry the link.
+ALTER TABLE transactions
+ADD COLUMN IF NOT EXISTS reversal_of_id UUID REFERENCES transactions(id), -- Set on the mirror transaction
+ADD COLUMN IF NOT EXISTS reversed_by_id UUID REFERENCES transactions(id), -- Set on the original transaction
+ADD COLUMN IF NOT EXISTS reversal_reason TEXT;
+
+-- A transaction can only be reversed once.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_reversal_of_id ON transactions(reversal_of_id) WHERE reversal_of_id IS NOT NULL;
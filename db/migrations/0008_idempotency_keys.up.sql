@@ -0,0 +1,15 @@
+-- Idempotency Keys
+-- Response cache for requests sent with an Idempotency-Key header.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    key VARCHAR(255) PRIMARY KEY,
+    request_hash CHAR(64) NOT NULL, -- SHA-256 of method, path, query and body
+    status VARCHAR(20) NOT NULL, -- IN_PROGRESS, COMPLETED
+    response_status INT,
+    response_content_type VARCHAR(255),
+    response_body BYTEA,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
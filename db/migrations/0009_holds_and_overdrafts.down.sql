@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS holds;
+ALTER TABLE accounts DROP COLUMN IF EXISTS overdraft_limit;
+ALTER TABLE products DROP COLUMN IF EXISTS overdraft_limit;
@@ -0,0 +1,23 @@
+-- Holds and Overdraft Limits
+-- Overdraft limits are in minor units. An account-level limit overrides the product
+-- limit; NULL means "inherit from the product" (0 when the account has no product).
+ALTER TABLE products ADD COLUMN IF NOT EXISTS overdraft_limit BIGINT NOT NULL DEFAULT 0 CHECK (overdraft_limit >= 0);
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS overdraft_limit BIGINT CHECK (overdraft_limit >= 0);
+
+-- Holds (reservations) reduce the available balance until captured, released or expired.
+CREATE TABLE IF NOT EXISTS holds (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    reference VARCHAR(255),
+    description TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE' CHECK (status IN ('ACTIVE', 'CAPTURED', 'RELEASED', 'EXPIRED')),
+    expires_at TIMESTAMP WITH TIME ZONE,
+    captured_amount BIGINT,
+    transaction_id UUID REFERENCES transactions(id), -- Set when captured
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_holds_account_active ON holds(account_id) WHERE status = 'ACTIVE';
+CREATE INDEX IF NOT EXISTS idx_holds_expires_at ON holds(expires_at) WHERE status = 'ACTIVE';
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_accounts_system_name;
+DROP TABLE IF EXISTS fx_rates;
+ALTER TABLE entries DROP COLUMN IF EXISTS currency;
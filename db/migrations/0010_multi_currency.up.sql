@@ -0,0 +1,22 @@
+-- Multi-Currency Postings
+-- Every entry records the currency it was posted in (always the account's currency),
+-- so a transaction can be checked to balance per currency.
+ALTER TABLE entries ADD COLUMN IF NOT EXISTS currency CHAR(3);
+UPDATE entries e SET currency = a.currency FROM accounts a WHERE e.account_id = a.id AND e.currency IS NULL;
+ALTER TABLE entries ALTER COLUMN currency SET NOT NULL;
+
+-- FX Rates: 1 unit of base_currency = rate units of quote_currency (major units).
+CREATE TABLE IF NOT EXISTS fx_rates (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    base_currency CHAR(3) NOT NULL REFERENCES currencies(code),
+    quote_currency CHAR(3) NOT NULL REFERENCES currencies(code),
+    rate NUMERIC(24, 12) NOT NULL CHECK (rate > 0),
+    as_of TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (base_currency <> quote_currency)
+);
+
+CREATE INDEX IF NOT EXISTS idx_fx_rates_pair ON fx_rates(base_currency, quote_currency, as_of DESC);
+
+-- System accounts are resolved by name and currency.
+CREATE INDEX IF NOT EXISTS idx_accounts_system_name ON accounts(name, currency) WHERE account_category = 'SYSTEM';
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_entries_account_transaction;
+DROP INDEX IF EXISTS idx_transactions_value_date;
+ALTER TABLE transactions
+DROP COLUMN IF EXISTS effective_date,
+DROP COLUMN IF EXISTS value_date;
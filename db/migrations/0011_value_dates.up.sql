@@ -0,0 +1,19 @@
+-- Value-Dated Postings
+-- value_date: when the money counts (interest, point-in-time balances).
+-- effective_date: the accounting date the transaction is booked to.
+-- Both default to the posting date; existing rows take it from posted_at.
+ALTER TABLE transactions
+ADD COLUMN IF NOT EXISTS value_date DATE,
+ADD COLUMN IF NOT EXISTS effective_date DATE;
+
+UPDATE transactions SET value_date = posted_at::DATE WHERE value_date IS NULL;
+UPDATE transactions SET effective_date = posted_at::DATE WHERE effective_date IS NULL;
+
+ALTER TABLE transactions
+ALTER COLUMN value_date SET NOT NULL,
+ALTER COLUMN value_date SET DEFAULT CURRENT_DATE,
+ALTER COLUMN effective_date SET NOT NULL,
+ALTER COLUMN effective_date SET DEFAULT CURRENT_DATE;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_value_date ON transactions(value_date);
+CREATE INDEX IF NOT EXISTS idx_entries_account_transaction ON entries(account_id, transaction_id);
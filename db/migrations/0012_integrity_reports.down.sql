@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_integrity_reports_started_at;
+DROP TABLE IF EXISTS integrity_reports;
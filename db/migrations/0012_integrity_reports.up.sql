@@ -0,0 +1,16 @@
+-- Ledger Integrity Reports
+-- One row per verification run. The full discrepancy list is kept in report.
+CREATE TABLE IF NOT EXISTS integrity_reports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    finished_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    repair BOOLEAN NOT NULL DEFAULT FALSE,
+    accounts_checked INT NOT NULL DEFAULT 0,
+    drift_count INT NOT NULL DEFAULT 0,
+    unbalanced_count INT NOT NULL DEFAULT 0,
+    orphan_count INT NOT NULL DEFAULT 0,
+    repaired_count INT NOT NULL DEFAULT 0,
+    report JSONB NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_integrity_reports_started_at ON integrity_reports(started_at DESC);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS retained_earnings_accounts;
+DROP TABLE IF EXISTS accounting_periods;
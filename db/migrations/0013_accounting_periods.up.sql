@@ -0,0 +1,23 @@
+-- Accounting Periods
+-- Postings are checked against the period containing their effective date:
+-- OPEN accepts everything, SOFT_CLOSED only the year-end closing entries,
+-- HARD_CLOSED nothing. Dates outside any period are not restricted.
+CREATE TABLE IF NOT EXISTS accounting_periods (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(50) NOT NULL UNIQUE,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'OPEN' CHECK (status IN ('OPEN', 'SOFT_CLOSED', 'HARD_CLOSED')),
+    closed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (end_date >= start_date),
+    CONSTRAINT accounting_periods_no_overlap EXCLUDE USING gist (daterange(start_date, end_date, '[]') WITH &&)
+);
+
+-- Year-end close moves income and expense balances into this EQUITY account, one per currency.
+CREATE TABLE IF NOT EXISTS retained_earnings_accounts (
+    currency CHAR(3) PRIMARY KEY REFERENCES currencies(code),
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,12 @@
+-- Account Lifecycle
+-- Existing accounts are ACTIVE. freeze_scope says which entry directions a
+-- FROZEN account blocks and is only set while the account is frozen.
+ALTER TABLE accounts
+ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE'
+    CHECK (status IN ('PENDING', 'ACTIVE', 'DORMANT', 'FROZEN', 'CLOSED')),
+ADD COLUMN IF NOT EXISTS freeze_scope VARCHAR(10)
+    CHECK (freeze_scope IN ('DEBIT', 'CREDIT', 'ALL')),
+ADD COLUMN IF NOT EXISTS status_reason TEXT,
+ADD COLUMN IF NOT EXISTS status_changed_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_accounts_status ON accounts(status) WHERE status <> 'ACTIVE';
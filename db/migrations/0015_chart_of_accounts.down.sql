@@ -0,0 +1,4 @@
+ALTER TABLE products DROP COLUMN IF EXISTS control_gl_account_id;
+DROP INDEX IF EXISTS idx_accounts_gl_account_id;
+ALTER TABLE accounts DROP COLUMN IF EXISTS gl_account_id;
+DROP TABLE IF EXISTS gl_accounts;
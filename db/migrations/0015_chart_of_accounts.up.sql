@@ -0,0 +1,58 @@
+-- Chart of Accounts
+-- GL accounts form a tree by parent_id. Header accounts only group their
+-- children; ledger accounts are mapped to non-header GL accounts, and postings
+-- to accounts mapped to a non-postable GL account are rejected.
+CREATE TABLE IF NOT EXISTS gl_accounts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code VARCHAR(20) NOT NULL UNIQUE,
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(50) NOT NULL CHECK (type IN ('ASSET', 'LIABILITY', 'EQUITY', 'INCOME', 'EXPENSE')),
+    parent_id UUID REFERENCES gl_accounts(id),
+    is_header BOOLEAN NOT NULL DEFAULT FALSE,
+    is_postable BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (NOT (is_header AND is_postable))
+);
+
+CREATE INDEX IF NOT EXISTS idx_gl_accounts_parent_id ON gl_accounts(parent_id);
+
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS gl_account_id UUID REFERENCES gl_accounts(id);
+CREATE INDEX IF NOT EXISTS idx_accounts_gl_account_id ON accounts(gl_account_id);
+
+-- Accounts assigned to a product are mapped to its control GL account.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS control_gl_account_id UUID REFERENCES gl_accounts(id);
+
+-- Default chart. System accounts are looked up by these codes.
+INSERT INTO gl_accounts (code, name, type, is_header, is_postable) VALUES
+('1000', 'Assets', 'ASSET', TRUE, FALSE),
+('2000', 'Liabilities', 'LIABILITY', TRUE, FALSE),
+('3000', 'Equity', 'EQUITY', TRUE, FALSE),
+('4000', 'Income', 'INCOME', TRUE, FALSE),
+('5000', 'Expenses', 'EXPENSE', TRUE, FALSE)
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO gl_accounts (code, name, type, parent_id)
+SELECT v.code, v.name, v.type, p.id
+FROM (VALUES
+    ('1200', 'Payment Gateway Settlement', 'ASSET', '1000'),
+    ('1300', 'FX Position', 'ASSET', '1000'),
+    ('1400', 'Customer Loans Control', 'ASSET', '1000'),
+    ('2100', 'Customer Deposits Control', 'LIABILITY', '2000'),
+    ('3100', 'Retained Earnings', 'EQUITY', '3000'),
+    ('4100', 'Fee Income', 'INCOME', '4000'),
+    ('4200', 'Interest Income', 'INCOME', '4000'),
+    ('5100', 'Bank Interest Expense', 'EXPENSE', '5000')
+) AS v(code, name, type, parent_code)
+JOIN gl_accounts p ON p.code = v.parent_code
+ON CONFLICT (code) DO NOTHING;
+
+-- Map existing system accounts and customer accounts.
+UPDATE accounts a SET gl_account_id = g.id
+FROM gl_accounts g
+WHERE a.gl_account_id IS NULL AND a.account_category = 'SYSTEM' AND a.name = g.name AND g.code IN ('1200', '1300', '5100');
+
+UPDATE accounts a SET gl_account_id = g.id
+FROM gl_accounts g
+WHERE a.gl_account_id IS NULL AND a.client_id IS NOT NULL
+  AND ((a.type = 'LIABILITY' AND g.code = '2100') OR (a.type = 'ASSET' AND g.code = '1400'));
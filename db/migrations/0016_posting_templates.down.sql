@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS posting_template_lines;
+DROP TABLE IF EXISTS posting_templates;
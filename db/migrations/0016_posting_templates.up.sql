@@ -0,0 +1,61 @@
+-- Posting Templates
+-- A template turns a business event (deposit, withdrawal, interest accrual...) into
+-- ledger entries. Templates may be scoped to a product and/or currency; the most
+-- specific match wins.
+CREATE TABLE IF NOT EXISTS posting_templates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    event_type VARCHAR(50) NOT NULL,
+    product_id UUID REFERENCES products(id),
+    currency VARCHAR(3) REFERENCES currencies(code),
+    description VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_posting_templates_scope
+    ON posting_templates (event_type, COALESCE(product_id, '00000000-0000-0000-0000-000000000000'), COALESCE(currency, ''));
+
+-- Each line posts the event amount. account_role picks the account: the event's
+-- ACCOUNT, its COUNTERPARTY (transfers), or the system account of a GL code.
+CREATE TABLE IF NOT EXISTS posting_template_lines (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    template_id UUID NOT NULL REFERENCES posting_templates(id) ON DELETE CASCADE,
+    line_no INT NOT NULL,
+    direction VARCHAR(10) NOT NULL CHECK (direction IN ('DEBIT', 'CREDIT')),
+    account_role VARCHAR(20) NOT NULL CHECK (account_role IN ('ACCOUNT', 'COUNTERPARTY', 'GL')),
+    gl_code VARCHAR(20),
+    UNIQUE (template_id, line_no),
+    CHECK ((account_role = 'GL') = (gl_code IS NOT NULL))
+);
+
+-- Defaults matching the previously hard-coded postings.
+INSERT INTO posting_templates (event_type, description)
+SELECT v.event_type, v.description
+FROM (VALUES
+    ('DEPOSIT', 'External Deposit'),
+    ('WITHDRAWAL', 'External Withdrawal'),
+    ('TRANSFER', 'Transfer'),
+    ('INTEREST_ACCRUAL', 'Daily Interest Accrual'),
+    ('FEE', 'Fee Charge')
+) AS v(event_type, description)
+WHERE NOT EXISTS (
+    SELECT 1 FROM posting_templates t
+    WHERE t.event_type = v.event_type AND t.product_id IS NULL AND t.currency IS NULL
+);
+
+INSERT INTO posting_template_lines (template_id, line_no, direction, account_role, gl_code)
+SELECT t.id, v.line_no, v.direction, v.account_role, v.gl_code
+FROM (VALUES
+    ('DEPOSIT', 1, 'DEBIT', 'GL', '1200'),
+    ('DEPOSIT', 2, 'CREDIT', 'ACCOUNT', NULL),
+    ('WITHDRAWAL', 1, 'DEBIT', 'ACCOUNT', NULL),
+    ('WITHDRAWAL', 2, 'CREDIT', 'GL', '1200'),
+    ('TRANSFER', 1, 'DEBIT', 'ACCOUNT', NULL),
+    ('TRANSFER', 2, 'CREDIT', 'COUNTERPARTY', NULL),
+    ('INTEREST_ACCRUAL', 1, 'DEBIT', 'GL', '5100'),
+    ('INTEREST_ACCRUAL', 2, 'CREDIT', 'ACCOUNT', NULL),
+    ('FEE', 1, 'DEBIT', 'ACCOUNT', NULL),
+    ('FEE', 2, 'CREDIT', 'GL', '4100')
+) AS v(event_type, line_no, direction, account_role, gl_code)
+JOIN posting_templates t ON t.event_type = v.event_type AND t.product_id IS NULL AND t.currency IS NULL
+ON CONFLICT (template_id, line_no) DO NOTHING;
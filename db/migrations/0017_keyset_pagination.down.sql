@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_transactions_reference_prefix;
+DROP INDEX IF EXISTS idx_clients_name_id;
+DROP INDEX IF EXISTS idx_accounts_created_at_id;
+DROP INDEX IF EXISTS idx_transactions_posted_at_id;
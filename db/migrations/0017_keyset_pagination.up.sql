@@ -0,0 +1,9 @@
+-- Keyset Pagination
+-- Lists page by (sort key, id); these indexes serve the ORDER BY and the cursor
+-- comparison without sorting the whole table.
+CREATE INDEX IF NOT EXISTS idx_transactions_posted_at_id ON transactions(posted_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_accounts_created_at_id ON accounts(created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_clients_name_id ON clients(name, id);
+
+-- Reference prefix search (LIKE 'prefix%') regardless of the database collation.
+CREATE INDEX IF NOT EXISTS idx_transactions_reference_prefix ON transactions(reference text_pattern_ops);
@@ -0,0 +1,19 @@
+-- Account Statements
+-- Generated statements are stored whole so they can be re-issued exactly as sent,
+-- even if back-dated postings later change the period.
+CREATE TABLE IF NOT EXISTS account_statements (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    period_start DATE NOT NULL,
+    period_end DATE NOT NULL,
+    opening_balance BIGINT NOT NULL,
+    closing_balance BIGINT NOT NULL,
+    total_debits BIGINT NOT NULL,
+    total_credits BIGINT NOT NULL,
+    statement JSONB NOT NULL,
+    generated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (account_id, period_start, period_end),
+    CHECK (period_end >= period_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_statements_account ON account_statements(account_id, period_end DESC);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS transaction_imports;
+ALTER TABLE batches DROP COLUMN IF EXISTS items_processed;
+ALTER TABLE batches DROP COLUMN IF EXISTS items_total;
@@ -0,0 +1,17 @@
+-- Transaction Imports
+-- Imports run as batches; progress is tracked on the batch record.
+ALTER TABLE batches ADD COLUMN IF NOT EXISTS items_total INT;
+ALTER TABLE batches ADD COLUMN IF NOT EXISTS items_processed INT;
+
+CREATE TABLE IF NOT EXISTS transaction_imports (
+    batch_id UUID PRIMARY KEY REFERENCES batches(id),
+    file_name VARCHAR(255) NOT NULL,
+    format VARCHAR(10) NOT NULL, -- CSV, JSON
+    mode VARCHAR(20) NOT NULL, -- ATOMIC, BEST_EFFORT
+    dry_run BOOLEAN NOT NULL,
+    transactions INT NOT NULL,
+    posted INT NOT NULL,
+    failed INT NOT NULL,
+    report JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
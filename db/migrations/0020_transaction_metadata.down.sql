@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_transactions_metadata;
+ALTER TABLE entries DROP COLUMN IF EXISTS counterparty;
+ALTER TABLE entries DROP COLUMN IF EXISTS narrative;
@@ -0,0 +1,8 @@
+-- Transaction Metadata
+-- transactions.metadata holds arbitrary key/values from the posting request; entries
+-- carry their own narrative and counterparty details.
+ALTER TABLE entries ADD COLUMN IF NOT EXISTS narrative TEXT;
+ALTER TABLE entries ADD COLUMN IF NOT EXISTS counterparty JSONB;
+
+-- jsonb_ops (not jsonb_path_ops) so both containment (@>) and key existence (?) use the index
+CREATE INDEX IF NOT EXISTS idx_transactions_metadata ON transactions USING GIN (metadata);
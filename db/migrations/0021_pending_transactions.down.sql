@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_transactions_pending_expiry;
+ALTER TABLE transactions
+DROP COLUMN IF EXISTS void_reason,
+DROP COLUMN IF EXISTS pending_request,
+DROP COLUMN IF EXISTS expires_at,
+DROP COLUMN IF EXISTS authorized_at,
+DROP COLUMN IF EXISTS status;
@@ -0,0 +1,13 @@
+-- Pending Transactions
+-- A PENDING transaction is authorized but not posted: its entries are kept in
+-- pending_request and only written to entries when it is POSTED, while holds linked
+-- through holds.transaction_id reduce the available balance. VOIDED transactions
+-- never post.
+ALTER TABLE transactions
+ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'POSTED' CHECK (status IN ('PENDING', 'POSTED', 'VOIDED')),
+ADD COLUMN IF NOT EXISTS authorized_at TIMESTAMP WITH TIME ZONE,
+ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP WITH TIME ZONE,
+ADD COLUMN IF NOT EXISTS pending_request JSONB,
+ADD COLUMN IF NOT EXISTS void_reason TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_pending_expiry ON transactions(expires_at) WHERE status = 'PENDING';
@@ -0,0 +1,47 @@
+-- Standing Orders
+-- Recurring transfers between internal accounts. next_run_date is the next scheduled
+-- occurrence; after an insufficient-funds failure retry_at delays the next attempt
+-- of the same occurrence.
+CREATE TABLE IF NOT EXISTS standing_orders (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    from_account_id UUID NOT NULL REFERENCES accounts(id),
+    to_account_id UUID NOT NULL REFERENCES accounts(id),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL,
+    description TEXT,
+    frequency VARCHAR(10) NOT NULL CHECK (frequency IN ('DAILY', 'WEEKLY', 'MONTHLY')),
+    interval_count INT NOT NULL DEFAULT 1 CHECK (interval_count > 0),
+    day_of_month INT CHECK (day_of_month BETWEEN 1 AND 31), -- MONTHLY only; later than the month's last day means the last day
+    start_date DATE NOT NULL,
+    end_date DATE,
+    max_executions INT CHECK (max_executions > 0),
+    execution_count INT NOT NULL DEFAULT 0,
+    next_run_date DATE,
+    retry_at TIMESTAMP WITH TIME ZONE,
+    failed_attempts INT NOT NULL DEFAULT 0, -- For the current occurrence
+    max_retries INT NOT NULL DEFAULT 3,
+    retry_interval_minutes INT NOT NULL DEFAULT 240,
+    status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE' CHECK (status IN ('ACTIVE', 'PAUSED', 'COMPLETED', 'CANCELLED')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (from_account_id <> to_account_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_standing_orders_from_account ON standing_orders(from_account_id);
+CREATE INDEX IF NOT EXISTS idx_standing_orders_due ON standing_orders(next_run_date) WHERE status = 'ACTIVE';
+
+-- One row per attempt. An attempt is PROCESSING while its transfer runs; an order
+-- with a PROCESSING attempt is not picked up again, so a crash never pays twice.
+CREATE TABLE IF NOT EXISTS standing_order_executions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    standing_order_id UUID NOT NULL REFERENCES standing_orders(id),
+    scheduled_date DATE NOT NULL,
+    attempt INT NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('PROCESSING', 'SUCCEEDED', 'RETRY_SCHEDULED', 'FAILED')),
+    transaction_id UUID REFERENCES transactions(id),
+    error TEXT,
+    started_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_standing_order_executions_order ON standing_order_executions(standing_order_id, started_at);
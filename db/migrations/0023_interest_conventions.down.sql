@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS account_interest;
+ALTER TABLE products
+DROP COLUMN IF EXISTS interest_rounding,
+DROP COLUMN IF EXISTS min_interest_balance,
+DROP COLUMN IF EXISTS interest_tiers,
+DROP COLUMN IF EXISTS tier_mode,
+DROP COLUMN IF EXISTS day_count_convention;
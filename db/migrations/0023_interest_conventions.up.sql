@@ -0,0 +1,19 @@
+-- Interest Conventions
+-- Products accrue interest under a day-count convention, optionally on balance
+-- tiers, above a minimum balance, with a rounding policy. interest_tiers is a JSON
+-- array of {"min_balance", "rate_bps"} in ascending min_balance; without tiers the
+-- product's flat interest_rate_bps applies.
+ALTER TABLE products
+ADD COLUMN IF NOT EXISTS day_count_convention VARCHAR(10) NOT NULL DEFAULT 'ACT/365' CHECK (day_count_convention IN ('ACT/365', 'ACT/360', '30/360')),
+ADD COLUMN IF NOT EXISTS tier_mode VARCHAR(10) NOT NULL DEFAULT 'BANDED' CHECK (tier_mode IN ('BANDED', 'WHOLE')),
+ADD COLUMN IF NOT EXISTS interest_tiers JSONB,
+ADD COLUMN IF NOT EXISTS min_interest_balance BIGINT NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS interest_rounding VARCHAR(10) NOT NULL DEFAULT 'DOWN' CHECK (interest_rounding IN ('DOWN', 'HALF_UP', 'HALF_EVEN', 'UP'));
+
+-- Per-account interest state. carry is the fraction of a minor unit accrued but not
+-- yet posted, added to the next day's interest.
+CREATE TABLE IF NOT EXISTS account_interest (
+    account_id UUID PRIMARY KEY REFERENCES accounts(id),
+    carry NUMERIC(38, 18) NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
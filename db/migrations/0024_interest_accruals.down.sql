@@ -0,0 +1,14 @@
+DROP TABLE IF EXISTS interest_accruals;
+
+DELETE FROM posting_templates
+WHERE event_type IN ('INTEREST_CAPITALIZATION', 'DEBIT_INTEREST_ACCRUAL', 'DEBIT_INTEREST_CAPITALIZATION');
+
+UPDATE posting_template_lines l SET account_role = 'ACCOUNT', gl_code = NULL
+FROM posting_templates t
+WHERE l.template_id = t.id AND t.event_type = 'INTEREST_ACCRUAL' AND t.product_id IS NULL AND t.currency IS NULL
+  AND l.line_no = 2 AND l.gl_code = '2200';
+
+ALTER TABLE products DROP COLUMN IF EXISTS capitalization_frequency;
+
+DELETE FROM gl_accounts g
+WHERE g.code IN ('1500', '2200') AND NOT EXISTS (SELECT 1 FROM accounts a WHERE a.gl_account_id = g.id);
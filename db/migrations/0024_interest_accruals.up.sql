@@ -0,0 +1,62 @@
+-- Interest Accrual and Capitalization
+-- Daily interest accrues into Interest Payable (credit interest) or Interest
+-- Receivable (debit interest) instead of the customer account, and is moved to the
+-- customer account on the product's capitalization schedule.
+INSERT INTO gl_accounts (code, name, type, parent_id)
+SELECT v.code, v.name, v.type, p.id
+FROM (VALUES
+    ('1500', 'Interest Receivable', 'ASSET', '1000'),
+    ('2200', 'Interest Payable', 'LIABILITY', '2000')
+) AS v(code, name, type, parent_code)
+JOIN gl_accounts p ON p.code = v.parent_code
+ON CONFLICT (code) DO NOTHING;
+
+ALTER TABLE products
+ADD COLUMN IF NOT EXISTS capitalization_frequency VARCHAR(10) NOT NULL DEFAULT 'MONTHLY' CHECK (capitalization_frequency IN ('DAILY', 'MONTHLY', 'QUARTERLY', 'ANNUALLY'));
+
+-- The default accrual now credits Interest Payable; capitalization credits the account.
+UPDATE posting_template_lines l SET account_role = 'GL', gl_code = '2200'
+FROM posting_templates t
+WHERE l.template_id = t.id AND t.event_type = 'INTEREST_ACCRUAL' AND t.product_id IS NULL AND t.currency IS NULL
+  AND l.line_no = 2 AND l.account_role = 'ACCOUNT';
+
+INSERT INTO posting_templates (event_type, description)
+SELECT v.event_type, v.description
+FROM (VALUES
+    ('INTEREST_CAPITALIZATION', 'Interest Capitalization'),
+    ('DEBIT_INTEREST_ACCRUAL', 'Daily Debit Interest Accrual'),
+    ('DEBIT_INTEREST_CAPITALIZATION', 'Debit Interest Capitalization')
+) AS v(event_type, description)
+WHERE NOT EXISTS (
+    SELECT 1 FROM posting_templates t
+    WHERE t.event_type = v.event_type AND t.product_id IS NULL AND t.currency IS NULL
+);
+
+INSERT INTO posting_template_lines (template_id, line_no, direction, account_role, gl_code)
+SELECT t.id, v.line_no, v.direction, v.account_role, v.gl_code
+FROM (VALUES
+    ('INTEREST_CAPITALIZATION', 1, 'DEBIT', 'GL', '2200'),
+    ('INTEREST_CAPITALIZATION', 2, 'CREDIT', 'ACCOUNT', NULL),
+    ('DEBIT_INTEREST_ACCRUAL', 1, 'DEBIT', 'GL', '1500'),
+    ('DEBIT_INTEREST_ACCRUAL', 2, 'CREDIT', 'GL', '4200'),
+    ('DEBIT_INTEREST_CAPITALIZATION', 1, 'DEBIT', 'ACCOUNT', NULL),
+    ('DEBIT_INTEREST_CAPITALIZATION', 2, 'CREDIT', 'GL', '1500')
+) AS v(event_type, line_no, direction, account_role, gl_code)
+JOIN posting_templates t ON t.event_type = v.event_type AND t.product_id IS NULL AND t.currency IS NULL
+ON CONFLICT (template_id, line_no) DO NOTHING;
+
+-- Per-account sub-ledger of the accrual accounts: every accrual and capitalization
+-- with the transaction that posted it. Outstanding accrued interest is accruals
+-- minus capitalizations, and reconciles to the Interest Payable/Receivable balances.
+CREATE TABLE IF NOT EXISTS interest_accruals (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    transaction_id UUID NOT NULL REFERENCES transactions(id),
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('ACCRUAL', 'CAPITALIZATION')),
+    side VARCHAR(20) NOT NULL CHECK (side IN ('PAYABLE', 'RECEIVABLE')),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    value_date DATE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_interest_accruals_account ON interest_accruals(account_id, side, value_date);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_interest_accruals_business_date;
+ALTER TABLE account_interest DROP COLUMN IF EXISTS accrued_through;
@@ -0,0 +1,15 @@
+-- Business-Date Accrual
+-- Interest accrues once per account and business date. accrued_through is the last
+-- business date accrued; a run for a later date first catches up the days between.
+ALTER TABLE account_interest ADD COLUMN IF NOT EXISTS accrued_through DATE;
+
+UPDATE account_interest ai SET accrued_through = r.last_date
+FROM (
+    SELECT account_id, MAX(value_date) AS last_date
+    FROM interest_accruals WHERE kind = 'ACCRUAL'
+    GROUP BY account_id
+) r
+WHERE ai.account_id = r.account_id AND ai.accrued_through IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_interest_accruals_business_date
+    ON interest_accruals(account_id, value_date) WHERE kind = 'ACCRUAL';
@@ -0,0 +1,4 @@
+ALTER TABLE account_interest DROP COLUMN IF EXISTS debit_carry;
+ALTER TABLE products
+DROP COLUMN IF EXISTS overdraft_penalty_rate_bps,
+DROP COLUMN IF EXISTS debit_interest_rate_bps;
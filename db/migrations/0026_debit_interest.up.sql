@@ -0,0 +1,10 @@
+-- Debit Interest
+-- interest_rate_bps is the credit rate. Debit positions (overdrawn deposits, loans)
+-- are charged debit_interest_rate_bps, and the part of an overdraft beyond the
+-- arranged limit overdraft_penalty_rate_bps when it is set.
+ALTER TABLE products
+ADD COLUMN IF NOT EXISTS debit_interest_rate_bps BIGINT NOT NULL DEFAULT 0 CHECK (debit_interest_rate_bps >= 0),
+ADD COLUMN IF NOT EXISTS overdraft_penalty_rate_bps BIGINT NOT NULL DEFAULT 0 CHECK (overdraft_penalty_rate_bps >= 0);
+
+-- Credit and debit interest carry their sub-unit fractions separately.
+ALTER TABLE account_interest ADD COLUMN IF NOT EXISTS debit_carry NUMERIC(38, 18) NOT NULL DEFAULT 0;
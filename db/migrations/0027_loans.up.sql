@@ -0,0 +1,81 @@
+-- Loans
+-- A loan product holds the terms a loan copies when it is disbursed: a nominal annual
+-- rate, the amortization schedule, the number of monthly installments, the late fee
+-- and the order (waterfall) in which repayments pay an installment's components.
+CREATE TABLE IF NOT EXISTS loan_products (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL,
+    interest_rate_bps BIGINT NOT NULL DEFAULT 0 CHECK (interest_rate_bps >= 0),
+    schedule_type VARCHAR(20) NOT NULL DEFAULT 'ANNUITY' CHECK (schedule_type IN ('ANNUITY', 'STRAIGHT_LINE', 'BULLET')),
+    term_months INT NOT NULL CHECK (term_months > 0),
+    late_fee BIGINT NOT NULL DEFAULT 0 CHECK (late_fee >= 0),
+    grace_days INT NOT NULL DEFAULT 0 CHECK (grace_days >= 0),
+    waterfall JSONB NOT NULL DEFAULT '["FEES", "INTEREST", "PRINCIPAL"]',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- The loan account is an ASSET ledger account mapped to GL 1400 (Customer Loans)
+-- whose balance is the outstanding principal. The customer account receives the
+-- disbursement and pays the repayments by default.
+CREATE TABLE IF NOT EXISTS loans (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    product_id UUID NOT NULL REFERENCES loan_products(id),
+    customer_account_id UUID NOT NULL REFERENCES accounts(id),
+    loan_account_id UUID NOT NULL REFERENCES accounts(id),
+    currency VARCHAR(3) NOT NULL,
+    principal BIGINT NOT NULL CHECK (principal > 0),
+    interest_rate_bps BIGINT NOT NULL,
+    schedule_type VARCHAR(20) NOT NULL,
+    term_months INT NOT NULL,
+    late_fee BIGINT NOT NULL,
+    grace_days INT NOT NULL,
+    waterfall JSONB NOT NULL,
+    start_date DATE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'ACTIVE', 'PAID_OFF', 'CANCELLED')),
+    disbursement_transaction_id UUID REFERENCES transactions(id),
+    days_past_due INT NOT NULL DEFAULT 0,
+    delinquency_bucket VARCHAR(20) NOT NULL DEFAULT 'CURRENT',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_loans_customer_account ON loans(customer_account_id);
+CREATE INDEX IF NOT EXISTS idx_loans_active ON loans(id) WHERE status = 'ACTIVE';
+
+-- The amortization schedule. fees is zero until a late fee is charged on the
+-- installment; the *_paid columns only ever grow.
+CREATE TABLE IF NOT EXISTS loan_installments (
+    loan_id UUID NOT NULL REFERENCES loans(id),
+    number INT NOT NULL,
+    due_date DATE NOT NULL,
+    principal BIGINT NOT NULL CHECK (principal >= 0),
+    interest BIGINT NOT NULL CHECK (interest >= 0),
+    fees BIGINT NOT NULL DEFAULT 0 CHECK (fees >= 0),
+    principal_paid BIGINT NOT NULL DEFAULT 0 CHECK (principal_paid BETWEEN 0 AND principal),
+    interest_paid BIGINT NOT NULL DEFAULT 0 CHECK (interest_paid BETWEEN 0 AND interest),
+    fees_paid BIGINT NOT NULL DEFAULT 0 CHECK (fees_paid BETWEEN 0 AND fees),
+    late_fee_charged BOOLEAN NOT NULL DEFAULT FALSE,
+    paid_at TIMESTAMP WITH TIME ZONE,
+    PRIMARY KEY (loan_id, number)
+);
+
+-- One row per repayment. A repayment is PROCESSING while its transaction posts; a
+-- loan with a PROCESSING repayment accepts no other, so allocations never overlap.
+CREATE TABLE IF NOT EXISTS loan_repayments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    loan_id UUID NOT NULL REFERENCES loans(id),
+    from_account_id UUID NOT NULL REFERENCES accounts(id),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    fees BIGINT NOT NULL,
+    interest BIGINT NOT NULL,
+    principal BIGINT NOT NULL,
+    allocations JSONB NOT NULL, -- Per installment
+    status VARCHAR(20) NOT NULL CHECK (status IN ('PROCESSING', 'POSTED', 'FAILED')),
+    transaction_id UUID REFERENCES transactions(id),
+    error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    CHECK (fees + interest + principal = amount)
+);
+
+CREATE INDEX IF NOT EXISTS idx_loan_repayments_loan ON loan_repayments(loan_id, created_at);
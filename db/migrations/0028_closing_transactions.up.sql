@@ -0,0 +1,9 @@
+-- Year-End Closing Transactions
+-- Marks the transactions that zero income and expense accounts into retained
+-- earnings (and their reversals), so income statements can leave them out of a
+-- period's movement while every other report still includes them.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS is_closing BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Closes booked before the column existed are known by their reference
+UPDATE transactions SET is_closing = TRUE
+WHERE reference ~ '^(REV-)?YE-[A-Z]{3}-[0-9]{4}-[0-9]{2}-[0-9]{2}$' AND NOT is_closing;
@@ -0,0 +1,7 @@
+-- The same key may now be held by several subjects; the cached responses are
+-- disposable, so drop them rather than pick one.
+DELETE FROM idempotency_keys;
+
+ALTER TABLE idempotency_keys DROP CONSTRAINT IF EXISTS idempotency_keys_pkey;
+ALTER TABLE idempotency_keys ADD CONSTRAINT idempotency_keys_pkey PRIMARY KEY (key);
+ALTER TABLE idempotency_keys DROP COLUMN IF EXISTS subject;
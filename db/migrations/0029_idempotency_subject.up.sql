@@ -0,0 +1,7 @@
+-- Idempotency keys scoped to the caller
+-- Keys are unique per authenticated subject (the token's username), so one caller
+-- cannot replay or block another caller's request by reusing its key.
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS subject VARCHAR(255) NOT NULL DEFAULT '';
+
+ALTER TABLE idempotency_keys DROP CONSTRAINT IF EXISTS idempotency_keys_pkey;
+ALTER TABLE idempotency_keys ADD CONSTRAINT idempotency_keys_pkey PRIMARY KEY (subject, key);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_accounts_system_name;
+CREATE INDEX IF NOT EXISTS idx_accounts_system_name ON accounts(name, currency) WHERE account_category = 'SYSTEM';
+
+DROP INDEX IF EXISTS idx_accounts_system_gl;
@@ -0,0 +1,36 @@
+-- Unique System Accounts
+-- System accounts are resolved by GL account and currency, or by name and currency,
+-- and created on first use. Unique indexes let concurrent first uses agree on one
+-- account instead of each creating its own. GL-backed accounts are named after their
+-- GL account, and GL names need not be unique, so only unmapped accounts need unique
+-- names.
+
+-- Two system accounts backing the same GL account both carry postings, so they
+-- cannot be merged here; they have to be resolved by hand before migrating.
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM accounts
+        WHERE account_category = 'SYSTEM' AND gl_account_id IS NOT NULL
+        GROUP BY gl_account_id, currency
+        HAVING COUNT(*) > 1
+    ) THEN
+        RAISE EXCEPTION 'several SYSTEM accounts are mapped to the same GL account and currency; remap all but one before migrating';
+    END IF;
+END $$;
+
+-- Lookups by name always returned the oldest account, so later namesakes were never
+-- used again; they keep their balances under a distinct name.
+UPDATE accounts a
+SET name = LEFT(a.name, 244) || ' (' || LEFT(a.id::TEXT, 8) || ')'
+WHERE a.account_category = 'SYSTEM' AND a.gl_account_id IS NULL
+  AND EXISTS (
+      SELECT 1 FROM accounts o
+      WHERE o.account_category = 'SYSTEM' AND o.gl_account_id IS NULL AND o.name = a.name AND o.currency = a.currency
+        AND (o.created_at, o.id) < (a.created_at, a.id)
+  );
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_accounts_system_gl ON accounts(gl_account_id, currency) WHERE account_category = 'SYSTEM';
+
+DROP INDEX IF EXISTS idx_accounts_system_name;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_accounts_system_name ON accounts(name, currency) WHERE account_category = 'SYSTEM' AND gl_account_id IS NULL;
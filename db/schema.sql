@@ -1,67 +0,0 @@
--- Enable UUID extension
-CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
-
--- Products Table
-CREATE TABLE products (
-    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-    name VARCHAR(255) NOT NULL,
-    interest_rate_bps BIGINT NOT NULL DEFAULT 0,
-    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
-);
-
--- Accounts Table
-CREATE TABLE accounts (
-    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-    name VARCHAR(255) NOT NULL,
-    type VARCHAR(50) NOT NULL CHECK (type IN ('ASSET', 'LIABILITY', 'EQUITY', 'INCOME', 'EXPENSE')),
-    currency CHAR(3) NOT NULL,
-    balance BIGINT NOT NULL DEFAULT 0, -- Cached balance for performance (optional, but good for read speed)
-    product_id UUID REFERENCES products(id),
-    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
-);
-
--- Transactions Table (The Journal)
-CREATE TABLE transactions (
-    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-    reference VARCHAR(255) UNIQUE NOT NULL,
-    description TEXT,
-    posted_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-    metadata JSONB
-);
-
--- Entries Table (The Ledger Lines)
-CREATE TABLE entries (
-    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-    transaction_id UUID NOT NULL REFERENCES transactions(id),
-    account_id UUID NOT NULL REFERENCES accounts(id),
-    direction VARCHAR(10) NOT NULL CHECK (direction IN ('DEBIT', 'CREDIT')),
-    amount BIGINT NOT NULL CHECK (amount > 0), -- Always positive, direction determines sign
-    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
-);
-
--- Indexes
-CREATE INDEX idx_entries_account_id ON entries(account_id);
-
--- Securities Table
-CREATE TABLE securities (
-    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-    symbol VARCHAR(20) UNIQUE NOT NULL,
-    name VARCHAR(255) NOT NULL,
-    type VARCHAR(50) NOT NULL, -- e.g., 'STOCK', 'BOND', 'ETF'
-    currency CHAR(3) NOT NULL,
-    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
-);
-
--- Security Prices Table
-CREATE TABLE security_prices (
-    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-    security_id UUID NOT NULL REFERENCES securities(id),
-    price DECIMAL(19, 4) NOT NULL,
-    currency CHAR(3) NOT NULL,
-    timestamp TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-    source VARCHAR(50) NOT NULL -- e.g., 'MOCK', 'YAHOO'
-);
-
-CREATE INDEX idx_security_prices_security_id ON security_prices(security_id);
-CREATE INDEX idx_security_prices_timestamp ON security_prices(timestamp);
@@ -0,0 +1,34 @@
+// List endpoints return one page at a time and put the next page's cursor in the
+// X-Next-Cursor header (absent on the last page).
+const PAGE_SIZE = 500;
+
+export interface AllPages<T> {
+    ok: boolean;
+    status: number;
+    items: T[];
+}
+
+// fetchAllPages follows X-Next-Cursor until the last page and returns every row.
+// It stops at the first failed page and reports its status.
+export async function fetchAllPages<T>(url: string, token: string | null): Promise<AllPages<T>> {
+    const items: T[] = [];
+    let cursor = '';
+    for (;;) {
+        const pageUrl = new URL(url);
+        pageUrl.searchParams.set('limit', String(PAGE_SIZE));
+        if (cursor) {
+            pageUrl.searchParams.set('cursor', cursor);
+        }
+        const response = await fetch(pageUrl, {
+            headers: { Authorization: `Bearer ${token}` },
+        });
+        if (!response.ok) {
+            return { ok: false, status: response.status, items };
+        }
+        items.push(...((await response.json()) || []));
+        cursor = response.headers.get('X-Next-Cursor') || '';
+        if (!cursor) {
+            return { ok: true, status: response.status, items };
+        }
+    }
+}
@@ -1,5 +1,6 @@
 import React, { useEffect, useState } from 'react';
 import { useAuth } from '../context/AuthContext';
+import { fetchAllPages } from '../api/pagination';
 
 interface Transaction {
     id: string;
@@ -34,14 +35,9 @@ const TransactionHistoryModal: React.FC<TransactionHistoryModalProps> = ({ isOpe
     const fetchTransactions = async () => {
         setLoading(true);
         try {
-            const response = await fetch(`http://localhost:8080/transactions?account_id=${accountId}`, {
-                headers: {
-                    Authorization: `Bearer ${token}`,
-                },
-            });
+            const response = await fetchAllPages<Transaction>(`http://localhost:8080/transactions?account_id=${accountId}`, token);
             if (response.ok) {
-                const data = await response.json();
-                setTransactions(data || []);
+                setTransactions(response.items);
             }
         } catch (error) {
             console.error('Failed to fetch transactions', error);
@@ -1,6 +1,7 @@
 import React, { useEffect, useState } from 'react';
 import { useNavigate } from 'react-router-dom';
 import { useAuth } from '../context/AuthContext';
+import { fetchAllPages } from '../api/pagination';
 import Layout from '../components/Layout';
 
 interface Account {
@@ -53,12 +54,9 @@ const Accounts: React.FC = () => {
                 navigate('/login');
                 return;
             }
-            const response = await fetch('http://localhost:8080/accounts', {
-                headers: { Authorization: `Bearer ${token}` },
-            });
+            const response = await fetchAllPages<Account>('http://localhost:8080/accounts', token);
             if (response.ok) {
-                const data = await response.json();
-                setAccounts(data || []);
+                setAccounts(response.items);
             } else if (response.status === 401) {
                 navigate('/login');
             }
@@ -1,5 +1,6 @@
 import React, { useEffect, useState } from 'react';
 import { useAuth } from '../context/AuthContext';
+import { fetchAllPages } from '../api/pagination';
 import Layout from '../components/Layout';
 
 interface Client {
@@ -40,12 +41,9 @@ const Clients: React.FC = () => {
 
     const fetchClients = async () => {
         try {
-            const response = await fetch('http://localhost:8080/clients', {
-                headers: { Authorization: `Bearer ${token}` },
-            });
+            const response = await fetchAllPages<Client>('http://localhost:8080/clients', token);
             if (response.ok) {
-                const data = await response.json();
-                setClients(data || []);
+                setClients(response.items);
             }
         } catch (error) {
             console.error('Failed to fetch clients', error);
@@ -1,5 +1,6 @@
 import React, { useEffect, useState } from 'react';
 import { useAuth } from '../context/AuthContext';
+import { fetchAllPages } from '../api/pagination';
 
 import TransactionModal from '../components/TransactionModal';
 import TransactionHistoryModal from '../components/TransactionHistoryModal';
@@ -34,14 +35,9 @@ const Dashboard: React.FC = () => {
 
     const fetchAccounts = async () => {
         try {
-            const response = await fetch('http://localhost:8080/accounts', {
-                headers: {
-                    Authorization: `Bearer ${token}`,
-                },
-            });
+            const response = await fetchAllPages<Account>('http://localhost:8080/accounts', token);
             if (response.ok) {
-                const data = await response.json();
-                setAccounts(data || []);
+                setAccounts(response.items);
             }
         } catch (error) {
             console.error('Failed to fetch accounts', error);
@@ -1,6 +1,7 @@
 import React, { useEffect, useState } from 'react';
 import { useNavigate } from 'react-router-dom';
 import { useAuth } from '../context/AuthContext';
+import { fetchAllPages } from '../api/pagination';
 import Layout from '../components/Layout';
 
 interface Security {
@@ -98,12 +99,9 @@ const Securities: React.FC = () => {
                 navigate('/login');
                 return;
             }
-            const response = await fetch('http://localhost:8080/securities', {
-                headers: { Authorization: `Bearer ${token}` },
-            });
+            const response = await fetchAllPages<Security>('http://localhost:8080/securities', token);
             if (response.ok) {
-                const data = await response.json();
-                const detailedSecurities = await Promise.all(data.map(async (sec: Security) => {
+                const detailedSecurities = await Promise.all(response.items.map(async (sec: Security) => {
                     const detailRes = await fetch(`http://localhost:8080/securities?symbol=${sec.symbol}`, {
                         headers: { Authorization: `Bearer ${token}` },
                     });
@@ -155,7 +155,12 @@ const BatchMonitor: React.FC = () => {
                                                 <td className="px-6 py-4">{start.toLocaleString()}</td>
                                                 <td className="px-6 py-4">{end ? end.toLocaleString() : '-'}</td>
                                                 <td className="px-6 py-4 font-mono">{duration}</td>
-                                                <td className="px-6 py-4">{getStatusBadge(record.Status)}</td>
+                                                <td className="px-6 py-4">
+                                                    {getStatusBadge(record.Status)}
+                                                    {record.ItemsTotal > 0 && (
+                                                        <span className="ml-2 text-xs text-slate-500">{record.ItemsProcessed}/{record.ItemsTotal}</span>
+                                                    )}
+                                                </td>
                                                 <td className="px-6 py-4 text-slate-500 truncate max-w-xs" title={record.ErrorLog}>
                                                     {record.ErrorLog || '-'}
                                                 </td>
@@ -4,6 +4,15 @@ import { useAuth } from '../../context/AuthContext';
 import FeeConfig from './FeeConfig';
 import RuleConfig from './RuleConfig';
 
+interface GLAccount {
+    code: string;
+    name: string;
+    type: string;
+    parent: string | null;
+    is_header: boolean;
+    is_postable: boolean;
+}
+
 interface Product {
     id: string;
     name: string;
@@ -22,21 +31,12 @@ const ProductConfig: React.FC = () => {
     const [productsList, setProductsList] = useState<Product[]>([]);
     const [loading, setLoading] = useState(false);
 
-    // Mock Data for COA (Keep existing mock for now)
-    const [coa, setCoa] = useState([
-        { code: '1000', name: 'Assets', type: 'ASSET', parent: null },
-        { code: '1001', name: 'Vault Cash', type: 'ASSET', parent: '1000' },
-        { code: '2000', name: 'Liabilities', type: 'LIABILITY', parent: null },
-        { code: '2001', name: 'Customer Savings Control', type: 'LIABILITY', parent: '2000' },
-        { code: '4000', name: 'Income', type: 'INCOME', parent: null },
-        { code: '4001', name: 'Fee Income', type: 'INCOME', parent: '4000' },
-        { code: '5000', name: 'Expenses', type: 'EXPENSE', parent: null },
-        { code: '5001', name: 'Interest Paid Expense', type: 'EXPENSE', parent: '5000' },
-    ]);
+    // Chart of accounts
+    const [coa, setCoa] = useState<GLAccount[]>([]);
 
     // Mock Data for Event Mappings
     const [showAddModal, setShowAddModal] = useState(false);
-    const [newGL, setNewGL] = useState({ code: '', name: '', type: 'ASSET', parent: '' });
+    const [newGL, setNewGL] = useState({ code: '', name: '', type: 'ASSET', parent: '', is_header: false });
     const [editingGL, setEditingGL] = useState<any>(null);
     const [mappings] = useState([
         { event: 'Account Creation', debitGL: '', creditGL: '' },
@@ -66,8 +66,23 @@ const ProductConfig: React.FC = () => {
 
     useEffect(() => {
         fetchProducts();
+        fetchCOA();
     }, [token]);
 
+    const fetchCOA = async () => {
+        try {
+            const response = await fetch('http://localhost:8080/gl-accounts', {
+                headers: { Authorization: `Bearer ${token}` },
+            });
+            if (response.ok) {
+                const data = await response.json();
+                setCoa((data || []).map((g: any) => ({ ...g, parent: g.parent_code || null })));
+            }
+        } catch (error) {
+            console.error("Failed to fetch chart of accounts", error);
+        }
+    };
+
     const fetchProducts = async () => {
         setLoading(true);
         try {
@@ -88,19 +103,55 @@ const ProductConfig: React.FC = () => {
         }
     };
 
-    const handleSaveGL = (e: React.FormEvent) => {
+    const handleSaveGL = async (e: React.FormEvent) => {
         e.preventDefault();
-        const parent = newGL.parent === '' ? null : newGL.parent;
-        if (editingGL) {
-            setCoa(coa.map(c => c.code === editingGL.code ? { ...newGL, parent } : c));
+        try {
+            const response = editingGL
+                ? await fetch(`http://localhost:8080/gl-accounts?code=${encodeURIComponent(editingGL.code)}`, {
+                    method: 'PUT',
+                    headers: { 'Content-Type': 'application/json', Authorization: `Bearer ${token}` },
+                    body: JSON.stringify({ name: newGL.name, parent_code: newGL.parent, is_postable: editingGL.is_postable }),
+                })
+                : await fetch('http://localhost:8080/gl-accounts', {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json', Authorization: `Bearer ${token}` },
+                    body: JSON.stringify({
+                        code: newGL.code,
+                        name: newGL.name,
+                        type: newGL.type,
+                        parent_code: newGL.parent,
+                        is_header: newGL.is_header,
+                        is_postable: !newGL.is_header,
+                    }),
+                });
+            if (!response.ok) {
+                alert(`Failed to save GL account: ${await response.text()}`);
+                return;
+            }
             setEditingGL(null);
-            alert("GL Account Updated (Mock)");
-        } else {
-            setCoa([...coa, { ...newGL, parent }]);
-            alert("GL Account Added (Mock)");
+            setShowAddModal(false);
+            setNewGL({ code: '', name: '', type: 'ASSET', parent: '', is_header: false });
+            fetchCOA();
+        } catch (error) {
+            console.error("Failed to save GL account", error);
+        }
+    };
+
+    const handleDeleteGL = async (code: string) => {
+        if (!window.confirm("Delete this GL account?")) return;
+        try {
+            const response = await fetch(`http://localhost:8080/gl-accounts?code=${encodeURIComponent(code)}`, {
+                method: 'DELETE',
+                headers: { Authorization: `Bearer ${token}` },
+            });
+            if (!response.ok) {
+                alert(`Failed to delete GL account: ${await response.text()}`);
+                return;
+            }
+            fetchCOA();
+        } catch (error) {
+            console.error("Failed to delete GL account", error);
         }
-        setShowAddModal(false);
-        setNewGL({ code: '', name: '', type: 'ASSET', parent: '' });
     };
 
     const handleCreateProduct = () => {
@@ -324,7 +375,7 @@ const ProductConfig: React.FC = () => {
                                             <button
                                                 onClick={() => {
                                                     setEditingGL(null);
-                                                    setNewGL({ code: '', name: '', type: 'ASSET', parent: '' });
+                                                    setNewGL({ code: '', name: '', type: 'ASSET', parent: '', is_header: false });
                                                     setShowAddModal(true);
                                                 }}
                                                 className="px-3 py-2 border border-transparent text-sm leading-4 font-medium rounded-md text-white bg-indigo-600 hover:bg-indigo-700"
@@ -357,14 +408,10 @@ const ProductConfig: React.FC = () => {
                                                             <td className="px-6 py-4 whitespace-nowrap text-sm font-medium space-x-2">
                                                                 <button onClick={() => {
                                                                     setEditingGL(account);
-                                                                    setNewGL({ code: account.code, name: account.name, type: account.type, parent: account.parent || '' });
+                                                                    setNewGL({ code: account.code, name: account.name, type: account.type, parent: account.parent || '', is_header: account.is_header });
                                                                     setShowAddModal(true);
                                                                 }} className="text-indigo-600 hover:text-indigo-900">Edit</button>
-                                                                <button onClick={() => {
-                                                                    if (window.confirm("Delete this GL account?")) {
-                                                                        setCoa(coa.filter(item => item.code !== account.code));
-                                                                    }
-                                                                }} className="text-red-600 hover:text-red-900">Delete</button>
+                                                                <button onClick={() => handleDeleteGL(account.code)} className="text-red-600 hover:text-red-900">Delete</button>
                                                             </td>
                                                         </tr>
                                                     ))}
@@ -567,6 +614,7 @@ const ProductConfig: React.FC = () => {
                                                     className="mt-1 block w-full border border-gray-300 rounded-md shadow-sm py-2 px-3 focus:outline-none focus:ring-indigo-500 focus:border-indigo-500 sm:text-sm"
                                                     value={newGL.type}
                                                     onChange={(e) => setNewGL({ ...newGL, type: e.target.value })}
+                                                    disabled={!!editingGL}
                                                 >
                                                     <option value="ASSET">Asset</option>
                                                     <option value="LIABILITY">Liability</option>
@@ -584,6 +632,17 @@ const ProductConfig: React.FC = () => {
                                                     onChange={(e) => setNewGL({ ...newGL, parent: e.target.value })}
                                                 />
                                             </div>
+                                            <div className="flex items-center">
+                                                <input
+                                                    id="gl-header"
+                                                    type="checkbox"
+                                                    className="h-4 w-4 text-indigo-600 border-gray-300 rounded"
+                                                    checked={newGL.is_header}
+                                                    onChange={(e) => setNewGL({ ...newGL, is_header: e.target.checked })}
+                                                    disabled={!!editingGL}
+                                                />
+                                                <label htmlFor="gl-header" className="ml-2 block text-sm text-gray-700">Header account (groups others, not postable)</label>
+                                            </div>
                                         </div>
                                     </div>
                                     <div className="bg-gray-50 px-4 py-3 sm:px-6 sm:flex sm:flex-row-reverse">
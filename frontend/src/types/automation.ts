@@ -7,6 +7,8 @@ export interface BatchRecord {
     EndTime?: string; // ISO string
     Status: JobStatus;
     ErrorLog?: string;
+    ItemsTotal: number; // 0 for jobs that do not report progress
+    ItemsProcessed: number;
 }
 
 export interface WorkflowDefinition {
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strings"
@@ -14,7 +15,22 @@ import (
 // In production, this should be loaded from environment variables.
 var SecretKey = []byte("super-secret-key-for-dev")
 
-// Middleware validates the JWT token in the Authorization header.
+type subjectKey struct{}
+
+// WithSubject returns a copy of ctx carrying the authenticated caller's name.
+func WithSubject(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, subjectKey{}, subject)
+}
+
+// Subject returns the name of the caller authenticated by Middleware, or "" when
+// the request did not go through it.
+func Subject(ctx context.Context) string {
+	subject, _ := ctx.Value(subjectKey{}).(string)
+	return subject
+}
+
+// Middleware validates the JWT token in the Authorization header and passes the
+// token's username claim on as the request's Subject.
 func Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
@@ -46,7 +62,11 @@ func Middleware(next http.Handler) http.Handler {
 		}
 
 		// Token is valid, proceed
-		next.ServeHTTP(w, r)
+		subject := ""
+		if claims, ok := token.Claims.(jwt.MapClaims); ok {
+			subject, _ = claims["username"].(string)
+		}
+		next.ServeHTTP(w, r.WithContext(WithSubject(r.Context(), subject)))
 	})
 }
 
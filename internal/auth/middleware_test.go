@@ -16,6 +16,23 @@ func TestGenerateToken(t *testing.T) {
 	}
 }
 
+func TestMiddlewareSetsSubject(t *testing.T) {
+	token, err := GenerateToken("alice")
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	var got string
+	Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = Subject(r.Context())
+	})).ServeHTTP(httptest.NewRecorder(), req)
+	if got != "alice" {
+		t.Errorf("Expected subject alice, got %q", got)
+	}
+}
+
 func TestMiddleware(t *testing.T) {
 	// Create a valid token
 	token, err := GenerateToken("testuser")
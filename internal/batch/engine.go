@@ -9,7 +9,10 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/nathanmocogni/core-banking-system/internal/idempotency"
 	"github.com/nathanmocogni/core-banking-system/internal/ledger"
+	"github.com/nathanmocogni/core-banking-system/internal/loan"
+	"github.com/nathanmocogni/core-banking-system/internal/standingorder"
 )
 
 type JobStatus string
@@ -21,12 +24,14 @@ const (
 )
 
 type BatchRecord struct {
-	ID        uuid.UUID
-	JobName   string
-	StartTime time.Time
-	EndTime   *time.Time
-	Status    JobStatus
-	ErrorLog  string
+	ID             uuid.UUID
+	JobName        string
+	StartTime      time.Time
+	EndTime        *time.Time
+	Status         JobStatus
+	ErrorLog       string
+	ItemsTotal     int // Set by jobs that report progress
+	ItemsProcessed int
 }
 
 type Job interface {
@@ -63,6 +68,14 @@ func (e *Engine) RunJob(ctx context.Context, name string) (*BatchRecord, error)
 	if !exists {
 		return nil, fmt.Errorf("job %s not found", name)
 	}
+	return e.Submit(ctx, job)
+}
+
+// Submit runs a job that is not registered, such as a file import, and records it
+// in the batch history like a registered one. The returned record is a snapshot
+// taken at start; the job's progress is read from the batch history.
+func (e *Engine) Submit(ctx context.Context, job Job) (*BatchRecord, error) {
+	name := job.Name()
 
 	// Create Batch Record
 	record := &BatchRecord{
@@ -77,21 +90,25 @@ func (e *Engine) RunJob(ctx context.Context, name string) (*BatchRecord, error)
 	}
 
 	// Run Job
+	// The job outlives the caller (e.g. an HTTP request), so it must not inherit its cancellation.
+	// It updates its own copy of the record, as the caller may still be reading the returned one.
+	jobCtx := context.WithValue(context.WithoutCancel(ctx), batchKey{}, &batchRun{engine: e, id: record.ID})
+	run := *record
 	go func() {
-		err := job.Run(ctx)
+		err := job.Run(jobCtx)
 		endTime := time.Now()
-		record.EndTime = &endTime
+		run.EndTime = &endTime
 
 		if err != nil {
-			record.Status = StatusFailed
-			record.ErrorLog = err.Error()
+			run.Status = StatusFailed
+			run.ErrorLog = err.Error()
 			log.Printf("Job %s failed: %v", name, err)
 		} else {
-			record.Status = StatusCompleted
+			run.Status = StatusCompleted
 			log.Printf("Job %s completed successfully", name)
 		}
 
-		if updateErr := e.logEnd(record); updateErr != nil {
+		if updateErr := e.logEnd(&run); updateErr != nil {
 			log.Printf("Failed to update batch record for %s: %v", name, updateErr)
 		}
 	}()
@@ -111,8 +128,39 @@ func (e *Engine) logEnd(record *BatchRecord) error {
 	return err
 }
 
+type batchKey struct{}
+
+type batchRun struct {
+	engine *Engine
+	id     uuid.UUID
+}
+
+// BatchID returns the ID of the batch record of the job running with ctx.
+func BatchID(ctx context.Context) (uuid.UUID, bool) {
+	run, ok := ctx.Value(batchKey{}).(*batchRun)
+	if !ok {
+		return uuid.Nil, false
+	}
+	return run.id, true
+}
+
+// ReportProgress records on the batch record of the job running with ctx that
+// processed of total items are done. It is a no-op outside a batch.
+func ReportProgress(ctx context.Context, processed, total int) {
+	run, ok := ctx.Value(batchKey{}).(*batchRun)
+	if !ok {
+		return
+	}
+	query := `UPDATE batches SET items_processed = $1, items_total = $2 WHERE id = $3`
+	if _, err := run.engine.db.Exec(query, processed, total, run.id); err != nil {
+		log.Printf("Failed to record progress of batch %s: %v", run.id, err)
+	}
+}
+
 func (e *Engine) GetHistory() ([]*BatchRecord, error) {
-	query := `SELECT id, job_name, start_time, end_time, status, error_log FROM batches ORDER BY start_time DESC LIMIT 50`
+	query := `
+		SELECT id, job_name, start_time, end_time, status, error_log, COALESCE(items_total, 0), COALESCE(items_processed, 0)
+		FROM batches ORDER BY start_time DESC LIMIT 50`
 	rows, err := e.db.Query(query)
 	if err != nil {
 		return nil, err
@@ -123,7 +171,7 @@ func (e *Engine) GetHistory() ([]*BatchRecord, error) {
 	for rows.Next() {
 		var r BatchRecord
 		var errLog sql.NullString
-		if err := rows.Scan(&r.ID, &r.JobName, &r.StartTime, &r.EndTime, &r.Status, &errLog); err != nil {
+		if err := rows.Scan(&r.ID, &r.JobName, &r.StartTime, &r.EndTime, &r.Status, &errLog, &r.ItemsTotal, &r.ItemsProcessed); err != nil {
 			return nil, err
 		}
 		r.ErrorLog = errLog.String
@@ -144,10 +192,11 @@ func NewDailyAccrualJob(s *ledger.Service) *DailyAccrualJob {
 
 func (j *DailyAccrualJob) Name() string { return "Daily Accrual" }
 
+// Run accrues interest for today's business date. Accrual is keyed by account and
+// business date, so rerunning the job the same day posts nothing more, and days the
+// job did not run are caught up first.
 func (j *DailyAccrualJob) Run(ctx context.Context) error {
-	// Call the existing CalculateInterest method in ledger service
-	// Note: In a real system, we might want to pass a date or handle idempotency better
-	txs, err := j.service.CalculateInterest()
+	txs, err := j.service.CalculateInterestAsOf(time.Now())
 	if err != nil {
 		return err
 	}
@@ -165,13 +214,30 @@ func NewCapitalizationJob(s *ledger.Service) *CapitalizationJob {
 
 func (j *CapitalizationJob) Name() string { return "Capitalization" }
 
+// Run capitalizes the interest whose capitalization period has ended, then
+// reconciles the accrual sub-ledger with the Interest Payable and Receivable GL
+// accounts. A difference fails the batch so it shows up in the batch history.
 func (j *CapitalizationJob) Run(ctx context.Context) error {
-	// Placeholder logic for Capitalization
-	// 1. Find all accounts with accrued interest (Liability accounts)
-	// 2. Move from Accrued Interest Payable (Liability) to Customer Account (Liability - Credit)
-	// For prototype, we'll just log
-	log.Println("Running Capitalization Job (Mock)...")
-	time.Sleep(2 * time.Second) // Simulate work
+	txs, err := j.service.CapitalizeInterest()
+	if err != nil {
+		return err
+	}
+	recon, err := j.service.ReconcileInterestAccruals()
+	if err != nil {
+		return err
+	}
+	var unreconciled int
+	for _, r := range recon {
+		if r.Difference != 0 {
+			unreconciled++
+			log.Printf("Capitalization Job: %s %s (GL %s) accrued %d, ledger %d, difference %d",
+				r.Side, r.Currency, r.GLCode, r.Accrued, r.LedgerBalance, r.Difference)
+		}
+	}
+	log.Printf("Capitalization Job: Processed %d transactions", len(txs))
+	if unreconciled > 0 {
+		return fmt.Errorf("interest accruals do not reconcile in %d currencies", unreconciled)
+	}
 	return nil
 }
 
@@ -191,3 +257,188 @@ func (j *FeeSweeperJob) Run(ctx context.Context) error {
 	time.Sleep(1 * time.Second)
 	return nil
 }
+
+type HoldExpiryJob struct {
+	service *ledger.Service
+}
+
+func NewHoldExpiryJob(s *ledger.Service) *HoldExpiryJob {
+	return &HoldExpiryJob{service: s}
+}
+
+func (j *HoldExpiryJob) Name() string { return "Hold Expiry" }
+
+func (j *HoldExpiryJob) Run(ctx context.Context) error {
+	// Void expired authorizations first, releasing their holds with them.
+	voided, err := j.service.ExpirePendingTransactions()
+	if err != nil {
+		return err
+	}
+	// Expired holds already stop reducing the available balance; this flips their status.
+	n, err := j.service.ExpireHolds()
+	if err != nil {
+		return err
+	}
+	log.Printf("Hold Expiry Job: Voided %d pending transactions, expired %d holds", voided, n)
+	return nil
+}
+
+type IdempotencyPurgeJob struct {
+	store idempotency.Store
+}
+
+func NewIdempotencyPurgeJob(store idempotency.Store) *IdempotencyPurgeJob {
+	return &IdempotencyPurgeJob{store: store}
+}
+
+func (j *IdempotencyPurgeJob) Name() string { return "Idempotency Key Purge" }
+
+func (j *IdempotencyPurgeJob) Run(ctx context.Context) error {
+	// Expired keys are already ignored on lookup; this only reclaims the space.
+	n, err := j.store.Purge(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	log.Printf("Idempotency Purge Job: Removed %d expired keys", n)
+	return nil
+}
+
+type IntegrityCheckJob struct {
+	service *ledger.Service
+	repair  bool
+}
+
+// NewIntegrityCheckJob creates the ledger verification job. Drifted balances are
+// only rewritten when repair is set; otherwise the job just reports them.
+func NewIntegrityCheckJob(s *ledger.Service, repair bool) *IntegrityCheckJob {
+	return &IntegrityCheckJob{service: s, repair: repair}
+}
+
+func (j *IntegrityCheckJob) Name() string { return "Ledger Integrity Check" }
+
+func (j *IntegrityCheckJob) Run(ctx context.Context) error {
+	report, err := j.service.VerifyIntegrity(j.repair)
+	if err != nil {
+		return err
+	}
+	log.Printf("Integrity Check Job: Checked %d accounts, %d drifted (%d repaired), %d unbalanced transactions, %d orphan entries (report %s)",
+		report.AccountsChecked, len(report.Drifts), report.Repaired, len(report.UnbalancedTransactions), len(report.OrphanEntries), report.ID)
+	// Fail the batch so unresolved discrepancies show up in the batch history
+	if n := report.Unresolved(); n > 0 {
+		return fmt.Errorf("ledger integrity check found %d unresolved discrepancies, see report %s", n, report.ID)
+	}
+	return nil
+}
+
+type StatementJob struct {
+	service *ledger.Service
+}
+
+func NewStatementJob(s *ledger.Service) *StatementJob {
+	return &StatementJob{service: s}
+}
+
+func (j *StatementJob) Name() string { return "Monthly Statements" }
+
+// Run produces statements for the previous calendar month. Rerunning it replaces
+// that month's statements, so a failed run can simply be retried.
+func (j *StatementJob) Run(ctx context.Context) error {
+	first, _ := ledger.MonthBounds(time.Now().UTC())
+	month := first.AddDate(0, -1, 0)
+	n, errs := j.service.GenerateMonthlyStatements(month)
+	for _, err := range errs {
+		log.Printf("Statement Job: %v", err)
+	}
+	log.Printf("Statement Job: Stored %d statements for %s", n, month.Format("2006-01"))
+	if len(errs) > 0 {
+		return fmt.Errorf("%d statements failed", len(errs))
+	}
+	return nil
+}
+
+type StandingOrderJob struct {
+	orders *standingorder.Service
+}
+
+func NewStandingOrderJob(orders *standingorder.Service) *StandingOrderJob {
+	return &StandingOrderJob{orders: orders}
+}
+
+func (j *StandingOrderJob) Name() string { return "Standing Orders" }
+
+// Run executes the standing orders due by now. Declined transfers are recorded on
+// the orders themselves, so they do not fail the job.
+func (j *StandingOrderJob) Run(ctx context.Context) error {
+	result, err := j.orders.ExecuteDue(time.Now())
+	if err != nil {
+		return err
+	}
+	log.Printf("Standing Order Job: %d succeeded, %d retrying, %d failed", result.Succeeded, result.Retrying, result.Failed)
+	return nil
+}
+
+type LoanDelinquencyJob struct {
+	loans *loan.Service
+}
+
+func NewLoanDelinquencyJob(loans *loan.Service) *LoanDelinquencyJob {
+	return &LoanDelinquencyJob{loans: loans}
+}
+
+func (j *LoanDelinquencyJob) Name() string { return "Loan Delinquency" }
+
+// Run brings days past due and delinquency buckets up to today and charges the
+// late fees that have become due.
+func (j *LoanDelinquencyJob) Run(ctx context.Context) error {
+	result, err := j.loans.UpdateDelinquency(time.Now())
+	if result != nil {
+		log.Printf("Loan Delinquency Job: %d loans updated, %d delinquent, %d late fees charged", result.Loans, result.Delinquent, result.FeesCharged)
+	}
+	return err
+}
+
+// importProgressEvery is how many transactions an import posts between progress updates.
+const importProgressEvery = 50
+
+// ImportJob posts the journal lines of an uploaded file. It is submitted per upload
+// rather than registered, and stores its report under its batch ID.
+type ImportJob struct {
+	service  *ledger.Service
+	fileName string
+	format   string
+	lines    []ledger.ImportLine
+	opts     ledger.ImportOptions
+}
+
+func NewImportJob(s *ledger.Service, fileName, format string, lines []ledger.ImportLine, opts ledger.ImportOptions) *ImportJob {
+	return &ImportJob{service: s, fileName: fileName, format: format, lines: lines, opts: opts}
+}
+
+func (j *ImportJob) Name() string {
+	if j.opts.DryRun {
+		return "Transaction Import (dry run)"
+	}
+	return "Transaction Import"
+}
+
+func (j *ImportJob) Run(ctx context.Context) error {
+	report, err := j.service.ImportTransactions(j.lines, j.opts, func(done, total int) {
+		if done%importProgressEvery == 0 || done == total {
+			ReportProgress(ctx, done, total)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if id, ok := BatchID(ctx); ok {
+		if err := j.service.SaveImportReport(id, j.fileName, j.format, report); err != nil {
+			return err
+		}
+	}
+	log.Printf("Import Job: %s: %d transactions, %d posted, %d failed (mode %s, dry run %t)",
+		j.fileName, report.Transactions, report.Posted, report.Failed, report.Mode, report.DryRun)
+	if report.Failed > 0 {
+		return fmt.Errorf("%d of %d transactions failed, see the import report", report.Failed, report.Transactions)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package common
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 500
+
+	// NextCursorHeader carries the cursor of the next page; it is absent on the last page.
+	NextCursorHeader = "X-Next-Cursor"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// PageRequest asks for up to Limit rows after Cursor (empty for the first page).
+type PageRequest struct {
+	Limit  int
+	Cursor string
+}
+
+// PageSize returns the limit clamped to [1, MaxPageLimit], defaulting to DefaultPageLimit.
+func (p PageRequest) PageSize() int {
+	switch {
+	case p.Limit <= 0:
+		return DefaultPageLimit
+	case p.Limit > MaxPageLimit:
+		return MaxPageLimit
+	}
+	return p.Limit
+}
+
+// Cursor is a keyset position: the sort key and ID of the last row of a page.
+// Lists are ordered by (key, id), so the ID breaks ties between equal keys.
+type Cursor struct {
+	Key string    `json:"k"`
+	ID  uuid.UUID `json:"id"`
+}
+
+// EncodeCursor returns the opaque cursor string for a position.
+func EncodeCursor(key string, id uuid.UUID) string {
+	b, _ := json.Marshal(Cursor{Key: key, ID: id})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor parses a cursor string. An empty string yields nil (first page).
+func DecodeCursor(s string) (*Cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil || c.ID == uuid.Nil {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// ParsePageRequest reads ?limit= and ?cursor= from a request.
+func ParsePageRequest(r *http.Request) (PageRequest, error) {
+	q := r.URL.Query()
+	p := PageRequest{Cursor: q.Get("cursor")}
+	if l := q.Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n <= 0 {
+			return p, errors.New("invalid limit")
+		}
+		p.Limit = n
+	}
+	if _, err := DecodeCursor(p.Cursor); err != nil {
+		return p, err
+	}
+	return p, nil
+}
+
+// SetNextCursor sets the next-page header when there is a next page.
+func SetNextCursor(w http.ResponseWriter, next string) {
+	if next != "" {
+		w.Header().Set(NextCursorHeader, next)
+	}
+}
@@ -0,0 +1,36 @@
+package common
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	id := uuid.New()
+	c, err := DecodeCursor(EncodeCursor("2025-01-31T10:00:00.123456Z", id))
+	if err != nil {
+		t.Fatalf("DecodeCursor failed: %v", err)
+	}
+	if c.Key != "2025-01-31T10:00:00.123456Z" || c.ID != id {
+		t.Errorf("Unexpected cursor %+v", c)
+	}
+
+	if c, err := DecodeCursor(""); c != nil || err != nil {
+		t.Errorf("Expected no cursor for an empty string, got %+v, %v", c, err)
+	}
+	for _, bad := range []string{"not base64!", "e30"} { // "e30" is {}
+		if _, err := DecodeCursor(bad); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("DecodeCursor(%q): expected ErrInvalidCursor, got %v", bad, err)
+		}
+	}
+}
+
+func TestPageSize(t *testing.T) {
+	for limit, want := range map[int]int{0: DefaultPageLimit, -1: DefaultPageLimit, 10: 10, MaxPageLimit + 1: MaxPageLimit} {
+		if got := (PageRequest{Limit: limit}).PageSize(); got != want {
+			t.Errorf("PageSize(%d) = %d, want %d", limit, got, want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package database
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/nathanmocogni/core-banking-system/db"
+)
+
+func TestLoadMigrations_Ordering(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0010_later.up.sql":     {Data: []byte("SELECT 10;")},
+		"0010_later.down.sql":   {Data: []byte("SELECT -10;")},
+		"0002_second.up.sql":    {Data: []byte("SELECT 2;")},
+		"0001_first.up.sql":     {Data: []byte("SELECT 1;")},
+		"0001_first.down.sql":   {Data: []byte("SELECT -1;")},
+		"README.md":             {Data: []byte("ignored")},
+		"0003_bad-name.up.sql":  {Data: []byte("ignored, does not match the naming scheme")},
+		"0004_notes.sql.backup": {Data: []byte("ignored")},
+	}
+
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		t.Fatalf("LoadMigrations failed: %v", err)
+	}
+
+	want := []int64{1, 2, 10}
+	if len(migrations) != len(want) {
+		t.Fatalf("Expected %d migrations, got %d", len(want), len(migrations))
+	}
+	for i, v := range want {
+		if migrations[i].Version != v {
+			t.Errorf("Migration %d: expected version %d, got %d", i, v, migrations[i].Version)
+		}
+	}
+	if migrations[0].Down != "SELECT -1;" {
+		t.Errorf("Expected down script to be loaded, got %q", migrations[0].Down)
+	}
+	if migrations[1].Down != "" {
+		t.Errorf("Expected no down script for version 2, got %q", migrations[1].Down)
+	}
+}
+
+func TestLoadMigrations_MissingUp(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0001_only_down.down.sql": {Data: []byte("SELECT 1;")},
+	}
+	if _, err := LoadMigrations(fsys); err == nil {
+		t.Error("Expected error for migration without up script")
+	}
+}
+
+func TestLoadMigrations_DuplicateVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0001_one.up.sql":   {Data: []byte("SELECT 1;")},
+		"0001_other.up.sql": {Data: []byte("SELECT 1;")},
+	}
+	if _, err := LoadMigrations(fsys); err == nil {
+		t.Error("Expected error for two migrations sharing a version")
+	}
+}
+
+// TestEmbeddedMigrations guards the shipped migrations: they must load and be reversible.
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := LoadMigrations(db.Migrations)
+	if err != nil {
+		t.Fatalf("Failed to load embedded migrations: %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("Expected embedded migrations")
+	}
+	for i, m := range migrations {
+		if m.Down == "" {
+			t.Errorf("Migration %d_%s has no down script", m.Version, m.Name)
+		}
+		if i > 0 && m.Version != migrations[i-1].Version+1 {
+			t.Errorf("Migration versions are not contiguous: %d follows %d", m.Version, migrations[i-1].Version)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OutboxStatus string
+
+const (
+	OutboxStatusPending OutboxStatus = "PENDING"
+	OutboxStatusSent    OutboxStatus = "SENT"
+	OutboxStatusFailed  OutboxStatus = "FAILED" // Gave up after MaxAttempts
+)
+
+// Publisher is the part of Producer the outbox relay depends on.
+type Publisher interface {
+	Publish(ctx context.Context, key string, payload interface{}) error
+}
+
+// OutboxEvent is a domain event staged for delivery.
+type OutboxEvent struct {
+	ID            uuid.UUID
+	AggregateType string
+	AggregateID   string // Used as the message key so events for one aggregate stay ordered
+	EventType     string
+	Payload       interface{}
+}
+
+// Enqueue writes an event to the outbox as part of tx.
+// The event only becomes visible to the relay if tx commits, so a committed
+// change always has its event and a rolled back change never does.
+func Enqueue(tx *sql.Tx, evt OutboxEvent) error {
+	if evt.ID == uuid.Nil {
+		evt.ID = uuid.New()
+	}
+
+	payload, err := json.Marshal(evt.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal outbox payload: %w", err)
+	}
+
+	query := `
+		INSERT INTO outbox_events (id, aggregate_type, aggregate_id, event_type, payload)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	_, err = tx.Exec(query, evt.ID, evt.AggregateType, evt.AggregateID, evt.EventType, payload)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue outbox event: %w", err)
+	}
+	return nil
+}
+
+// OutboxRelay polls the outbox and publishes pending events.
+// Delivery is at-least-once: an event is marked SENT only after the publisher
+// accepts it, so a crash between the two results in a redelivery. Consumers
+// should de-duplicate on the event_id carried in the payload.
+type OutboxRelay struct {
+	db        *sql.DB
+	publisher Publisher
+
+	PollInterval   time.Duration
+	BatchSize      int
+	MaxAttempts    int
+	BaseBackoff    time.Duration
+	PublishTimeout time.Duration
+}
+
+func NewOutboxRelay(db *sql.DB, publisher Publisher) *OutboxRelay {
+	return &OutboxRelay{
+		db:             db,
+		publisher:      publisher,
+		PollInterval:   1 * time.Second,
+		BatchSize:      100,
+		MaxAttempts:    10,
+		BaseBackoff:    2 * time.Second,
+		PublishTimeout: 10 * time.Second,
+	}
+}
+
+// Start runs the relay until ctx is cancelled.
+func (r *OutboxRelay) Start(ctx context.Context) {
+	ticker := time.NewTicker(r.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain everything that is due before waiting again.
+		for {
+			n, err := r.RelayBatch(ctx)
+			if err != nil {
+				log.Printf("outbox relay error: %v", err)
+				break
+			}
+			if n < r.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+type outboxRow struct {
+	id          uuid.UUID
+	aggregateID string
+	payload     []byte
+	attempts    int
+}
+
+// RelayBatch publishes up to BatchSize due events and returns how many it picked up.
+// Rows are claimed with SKIP LOCKED so several relays can run side by side.
+func (r *OutboxRelay) RelayBatch(ctx context.Context) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin outbox transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT id, aggregate_id, payload, attempts
+		FROM outbox_events
+		WHERE status = $1 AND next_attempt_at <= NOW()
+		ORDER BY seq
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`
+	rows, err := tx.QueryContext(ctx, query, OutboxStatusPending, r.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch outbox events: %w", err)
+	}
+
+	var batch []outboxRow
+	for rows.Next() {
+		var row outboxRow
+		if err := rows.Scan(&row.id, &row.aggregateID, &row.payload, &row.attempts); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan outbox event: %w", err)
+		}
+		batch = append(batch, row)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read outbox events: %w", err)
+	}
+
+	for _, row := range batch {
+		pubCtx, cancel := context.WithTimeout(ctx, r.PublishTimeout)
+		pubErr := r.publisher.Publish(pubCtx, row.aggregateID, json.RawMessage(row.payload))
+		cancel()
+
+		if pubErr == nil {
+			_, err = tx.ExecContext(ctx, `UPDATE outbox_events SET status = $1, attempts = attempts + 1, sent_at = NOW(), last_error = NULL WHERE id = $2`,
+				OutboxStatusSent, row.id)
+		} else {
+			attempts := row.attempts + 1
+			status := OutboxStatusPending
+			if attempts >= r.MaxAttempts {
+				status = OutboxStatusFailed
+				log.Printf("outbox event %s failed permanently after %d attempts: %v", row.id, attempts, pubErr)
+			}
+			nextAttempt := time.Now().Add(Backoff(attempts, r.BaseBackoff))
+			_, err = tx.ExecContext(ctx, `UPDATE outbox_events SET status = $1, attempts = $2, last_error = $3, next_attempt_at = $4 WHERE id = $5`,
+				status, attempts, pubErr.Error(), nextAttempt, row.id)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to update outbox event: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit outbox batch: %w", err)
+	}
+	return len(batch), nil
+}
+
+// Backoff returns the delay before the next delivery attempt.
+// It doubles with every attempt and is capped at one hour.
+func Backoff(attempts int, base time.Duration) time.Duration {
+	const maxBackoff = time.Hour
+	if attempts < 1 {
+		return base
+	}
+	d := base
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return d
+}
@@ -0,0 +1,168 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+)
+
+func connectDB(t *testing.T) *sql.DB {
+	host := os.Getenv("DB_HOST")
+	if host == "" {
+		host = "localhost"
+	}
+	port := os.Getenv("DB_PORT")
+	if port == "" {
+		port = "5433"
+	}
+	user := os.Getenv("DB_USER")
+	if user == "" {
+		user = "user"
+	}
+	password := os.Getenv("DB_PASSWORD")
+	if password == "" {
+		password = "password"
+	}
+	dbname := os.Getenv("DB_NAME")
+	if dbname == "" {
+		dbname = "ledger"
+	}
+
+	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable", host, port, user, password, dbname)
+	db, err := sql.Open("postgres", connStr)
+	if err != nil {
+		t.Skipf("Skipping test: could not connect to database: %v", err)
+	}
+	if err := db.Ping(); err != nil {
+		t.Skipf("Skipping test: database not reachable: %v", err)
+	}
+	return db
+}
+
+// flakyPublisher rejects the first failures attempts for each key and records what it accepts.
+type flakyPublisher struct {
+	failures  int
+	calls     map[string]int
+	published map[string]json.RawMessage
+}
+
+func newFlakyPublisher(failures int) *flakyPublisher {
+	return &flakyPublisher{
+		failures:  failures,
+		calls:     make(map[string]int),
+		published: make(map[string]json.RawMessage),
+	}
+}
+
+func (p *flakyPublisher) Publish(ctx context.Context, key string, payload interface{}) error {
+	p.calls[key]++
+	if p.calls[key] <= p.failures {
+		return fmt.Errorf("broker unavailable")
+	}
+	p.published[key] = payload.(json.RawMessage)
+	return nil
+}
+
+func TestBackoff(t *testing.T) {
+	base := 2 * time.Second
+	cases := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, 2 * time.Second},
+		{1, 2 * time.Second},
+		{2, 4 * time.Second},
+		{3, 8 * time.Second},
+		{20, time.Hour},
+	}
+	for _, c := range cases {
+		if got := Backoff(c.attempts, base); got != c.want {
+			t.Errorf("Backoff(%d) = %v, want %v", c.attempts, got, c.want)
+		}
+	}
+}
+
+func TestOutboxRelay_RetriesUntilSent(t *testing.T) {
+	db := connectDB(t)
+	defer db.Close()
+
+	key := uuid.New().String()
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("Failed to begin: %v", err)
+	}
+	evtID := uuid.New()
+	err = Enqueue(tx, OutboxEvent{
+		ID:            evtID,
+		AggregateType: "TEST",
+		AggregateID:   key,
+		EventType:     "TestEvent",
+		Payload:       map[string]string{"message": "hello"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to enqueue: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+
+	pub := newFlakyPublisher(1)
+	relay := NewOutboxRelay(db, pub)
+	relay.BaseBackoff = 0 // Make the retry due immediately
+
+	// Other tests may have left pending events behind, so keep relaying until ours is through.
+	ctx := context.Background()
+	for pub.published[key] == nil {
+		n, err := relay.RelayBatch(ctx)
+		if err != nil {
+			t.Fatalf("RelayBatch failed: %v", err)
+		}
+		if n == 0 {
+			break
+		}
+	}
+
+	if pub.published[key] == nil {
+		t.Fatal("Expected event to be published after retry")
+	}
+
+	var status string
+	var attempts int
+	if err := db.QueryRow("SELECT status, attempts FROM outbox_events WHERE id = $1", evtID).Scan(&status, &attempts); err != nil {
+		t.Fatalf("Failed to read outbox row: %v", err)
+	}
+	if status != string(OutboxStatusSent) {
+		t.Errorf("Expected status SENT, got %s", status)
+	}
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", attempts)
+	}
+}
+
+func TestEnqueue_RolledBack(t *testing.T) {
+	db := connectDB(t)
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("Failed to begin: %v", err)
+	}
+	evtID := uuid.New()
+	if err := Enqueue(tx, OutboxEvent{ID: evtID, AggregateType: "TEST", AggregateID: "x", EventType: "TestEvent", Payload: map[string]string{}}); err != nil {
+		t.Fatalf("Failed to enqueue: %v", err)
+	}
+	tx.Rollback()
+
+	var count int
+	db.QueryRow("SELECT COUNT(*) FROM outbox_events WHERE id = $1", evtID).Scan(&count)
+	if count != 0 {
+		t.Errorf("Expected rolled back event to be absent, found %d", count)
+	}
+}
@@ -0,0 +1,155 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/nathanmocogni/core-banking-system/internal/auth"
+)
+
+// HeaderKey is the request header carrying the client-chosen idempotency key.
+const HeaderKey = "Idempotency-Key"
+
+// HeaderReplayed is set on responses served from the cache.
+const HeaderReplayed = "Idempotent-Replayed"
+
+const (
+	maxKeyLength   = 255
+	maxRequestBody = 1 << 20 // 1MB
+)
+
+// Middleware makes handlers safe to retry.
+// When a POST, PUT, PATCH or DELETE request carries an Idempotency-Key header, the
+// first response for that key is stored and every retry with the same key and the
+// same request (method, path, query and body) gets the stored response back without
+// running the handler again. Keys belong to the caller authenticated by
+// auth.Middleware, so callers cannot see or block each other's requests. Reusing a
+// key for a different request is rejected with 409 Conflict, as is a retry that
+// arrives while the first request is still running.
+// 5xx responses are not cached, so the client can retry them with the same key. Any
+// other response whose storing fails keeps the key in progress until it expires,
+// because the handler has already taken effect.
+// Records expire after retention; requests without the header, and reads (GET, HEAD,
+// OPTIONS), pass straight through.
+func Middleware(store Store, retention time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(HeaderKey)
+			if key == "" || isSafeMethod(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxKeyLength {
+				http.Error(w, "Idempotency-Key is too long", http.StatusBadRequest)
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBody))
+			if err != nil {
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			hash := RequestHash(r, body)
+			subject := auth.Subject(r.Context())
+
+			existing, err := store.Reserve(r.Context(), subject, key, hash, time.Now().Add(retention))
+			if err != nil {
+				log.Printf("idempotency: %v", err)
+				http.Error(w, "Failed to check idempotency key", http.StatusInternalServerError)
+				return
+			}
+
+			if existing != nil {
+				switch {
+				case existing.RequestHash != hash:
+					http.Error(w, "Idempotency-Key was already used for a different request", http.StatusConflict)
+				case existing.Status != StatusCompleted:
+					http.Error(w, "A request with this Idempotency-Key is still being processed", http.StatusConflict)
+				default:
+					if existing.ResponseContentType != "" {
+						w.Header().Set("Content-Type", existing.ResponseContentType)
+					}
+					w.Header().Set(HeaderReplayed, "true")
+					w.WriteHeader(existing.ResponseStatus)
+					w.Write(existing.ResponseBody)
+				}
+				return
+			}
+
+			// The outcome is recorded even if the client disconnects meanwhile.
+			storeCtx := context.WithoutCancel(r.Context())
+			rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+			release := true
+			defer func() {
+				// Free the key if the handler panicked or failed with a 5xx so the client can retry.
+				if release {
+					if err := store.Release(storeCtx, subject, key); err != nil {
+						log.Printf("idempotency: %v", err)
+					}
+				}
+			}()
+
+			next.ServeHTTP(rec, r)
+
+			if rec.status >= http.StatusInternalServerError {
+				return
+			}
+			// The handler took effect, so a retry must never run it again: if the
+			// response cannot be stored the key stays in progress (409) until it expires.
+			release = false
+			if err := store.Complete(storeCtx, subject, key, rec.status, rec.Header().Get("Content-Type"), rec.body.Bytes()); err != nil {
+				log.Printf("idempotency: %v", err)
+			}
+		})
+	}
+}
+
+// isSafeMethod reports whether a method only reads, so there is nothing to make idempotent.
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// RequestHash fingerprints a request so a reused key can be matched to its original request.
+func RequestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method)
+	h.Write([]byte{0})
+	io.WriteString(h, r.URL.Path)
+	h.Write([]byte{0})
+	io.WriteString(h, r.URL.RawQuery)
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recorder passes the response through to the client while keeping a copy of it.
+type recorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if r.wroteHeader {
+		return
+	}
+	r.status = status
+	r.wroteHeader = true
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
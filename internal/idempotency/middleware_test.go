@@ -0,0 +1,264 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nathanmocogni/core-banking-system/internal/auth"
+)
+
+// memoryStore is an in-memory Store for exercising the middleware without a database.
+type memoryStore struct {
+	mu      sync.Mutex
+	records map[[2]string]*Record // By subject and key
+	// completeErr, when set, makes Complete fail without storing the response
+	completeErr error
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{records: make(map[[2]string]*Record)}
+}
+
+func (s *memoryStore) Reserve(ctx context.Context, subject, key, requestHash string, expiresAt time.Time) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rec, ok := s.records[[2]string{subject, key}]; ok && rec.ExpiresAt.After(time.Now()) {
+		copied := *rec
+		return &copied, nil
+	}
+	s.records[[2]string{subject, key}] = &Record{Subject: subject, Key: key, RequestHash: requestHash, Status: StatusInProgress, ExpiresAt: expiresAt}
+	return nil, nil
+}
+
+func (s *memoryStore) Complete(ctx context.Context, subject, key string, status int, contentType string, body []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.completeErr != nil {
+		return s.completeErr
+	}
+	rec := s.records[[2]string{subject, key}]
+	rec.Status = StatusCompleted
+	rec.ResponseStatus = status
+	rec.ResponseContentType = contentType
+	rec.ResponseBody = append([]byte(nil), body...)
+	return nil
+}
+
+func (s *memoryStore) Release(ctx context.Context, subject, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rec, ok := s.records[[2]string{subject, key}]; ok && rec.Status == StatusInProgress {
+		delete(s.records, [2]string{subject, key})
+	}
+	return nil
+}
+
+func (s *memoryStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func newRequest(key, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/payments/deposit", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(HeaderKey, key)
+	}
+	return req
+}
+
+func TestMiddleware_ReplaysSameRequest(t *testing.T) {
+	calls := 0
+	handler := Middleware(newMemoryStore(), time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"tx-1"}`))
+	}))
+
+	first := httptest.NewRecorder()
+	handler.ServeHTTP(first, newRequest("key-1", `{"amount":100}`))
+	second := httptest.NewRecorder()
+	handler.ServeHTTP(second, newRequest("key-1", `{"amount":100}`))
+
+	if calls != 1 {
+		t.Errorf("Expected handler to run once, ran %d times", calls)
+	}
+	if second.Code != http.StatusCreated {
+		t.Errorf("Expected replayed status 201, got %d", second.Code)
+	}
+	if second.Body.String() != first.Body.String() {
+		t.Errorf("Expected replayed body %q, got %q", first.Body.String(), second.Body.String())
+	}
+	if second.Header().Get(HeaderReplayed) != "true" {
+		t.Error("Expected replay header on cached response")
+	}
+	if second.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("Expected replayed content type, got %q", second.Header().Get("Content-Type"))
+	}
+}
+
+func TestMiddleware_RejectsDifferentBody(t *testing.T) {
+	handler := Middleware(newMemoryStore(), time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), newRequest("key-2", `{"amount":100}`))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, newRequest("key-2", `{"amount":999}`))
+
+	if rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for reused key with different body, got %d", rec.Code)
+	}
+}
+
+func TestMiddleware_ServerErrorsAreNotCached(t *testing.T) {
+	calls := 0
+	handler := Middleware(newMemoryStore(), time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			http.Error(w, "gateway down", http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), newRequest("key-3", `{}`))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, newRequest("key-3", `{}`))
+
+	if calls != 2 {
+		t.Errorf("Expected retry after 5xx to run the handler again, ran %d times", calls)
+	}
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 on retry, got %d", rec.Code)
+	}
+}
+
+func TestMiddleware_FailedCompleteKeepsKeyInProgress(t *testing.T) {
+	store := newMemoryStore()
+	store.completeErr = errors.New("connection reset")
+	calls := 0
+	handler := Middleware(store, time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), newRequest("key-8", `{"amount":5}`))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, newRequest("key-8", `{"amount":5}`))
+
+	if calls != 1 {
+		t.Errorf("Expected the handler to run once, ran %d times", calls)
+	}
+	if rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 while the key is still in progress, got %d", rec.Code)
+	}
+}
+
+func TestMiddleware_StoresOutcomeAfterDisconnect(t *testing.T) {
+	store := newMemoryStore()
+	handler := Middleware(store, time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	handler.ServeHTTP(httptest.NewRecorder(), newRequest("key-9", `{}`).WithContext(ctx))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, newRequest("key-9", `{}`))
+
+	if rec.Code != http.StatusCreated || rec.Header().Get(HeaderReplayed) != "true" {
+		t.Errorf("Expected the stored 201 to be replayed, got %d (replayed=%q)", rec.Code, rec.Header().Get(HeaderReplayed))
+	}
+}
+
+func TestMiddleware_HandlerSeesBody(t *testing.T) {
+	var got string
+	handler := Middleware(newMemoryStore(), time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		got = string(b)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), newRequest("key-4", `{"amount":5}`))
+	if got != `{"amount":5}` {
+		t.Errorf("Expected handler to receive original body, got %q", got)
+	}
+}
+
+func TestMiddleware_NoKeyPassesThrough(t *testing.T) {
+	calls := 0
+	handler := Middleware(newMemoryStore(), time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), newRequest("", `{}`))
+	handler.ServeHTTP(httptest.NewRecorder(), newRequest("", `{}`))
+	if calls != 2 {
+		t.Errorf("Expected requests without a key to always run, ran %d times", calls)
+	}
+}
+
+func TestMiddleware_ExpiredKeyIsReused(t *testing.T) {
+	store := newMemoryStore()
+	calls := 0
+	handler := Middleware(store, -time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), newRequest("key-5", `{"a":1}`))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, newRequest("key-5", `{"a":2}`))
+
+	if calls != 2 || rec.Code != http.StatusOK {
+		t.Errorf("Expected expired key to be treated as new (calls=%d, status=%d)", calls, rec.Code)
+	}
+}
+
+func TestMiddleware_KeysAreScopedToSubject(t *testing.T) {
+	calls := 0
+	handler := Middleware(newMemoryStore(), time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		io.WriteString(w, auth.Subject(r.Context()))
+	}))
+
+	for _, subject := range []string{"alice", "bob"} {
+		req := newRequest("key-6", `{"amount":5}`)
+		req = req.WithContext(auth.WithSubject(req.Context(), subject))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Body.String() != subject || rec.Header().Get(HeaderReplayed) != "" {
+			t.Errorf("Expected %s's request to run, got %q (replayed=%q)", subject, rec.Body.String(), rec.Header().Get(HeaderReplayed))
+		}
+	}
+	if calls != 2 {
+		t.Errorf("Expected the handler to run once per subject, ran %d times", calls)
+	}
+}
+
+func TestMiddleware_ReadsPassThrough(t *testing.T) {
+	calls := 0
+	handler := Middleware(newMemoryStore(), time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/transactions?account_id=1", nil)
+		req.Header.Set(HeaderKey, "key-7")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if calls != 2 {
+		t.Errorf("Expected GET requests to always run, ran %d times", calls)
+	}
+}
@@ -0,0 +1,117 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type RecordStatus string
+
+const (
+	StatusInProgress RecordStatus = "IN_PROGRESS"
+	StatusCompleted  RecordStatus = "COMPLETED"
+)
+
+// Record is the persisted state of one idempotency key. Keys are scoped to the
+// authenticated caller (Subject), so two callers may use the same key.
+type Record struct {
+	Subject             string
+	Key                 string
+	RequestHash         string
+	Status              RecordStatus
+	ResponseStatus      int
+	ResponseContentType string
+	ResponseBody        []byte
+	CreatedAt           time.Time
+	ExpiresAt           time.Time
+}
+
+// Store persists idempotency records.
+type Store interface {
+	// Reserve claims subject's key for a new request. If a live record already holds
+	// the key it is returned and nothing is written; otherwise it returns nil.
+	Reserve(ctx context.Context, subject, key, requestHash string, expiresAt time.Time) (*Record, error)
+	// Complete stores the response for a reserved key.
+	Complete(ctx context.Context, subject, key string, status int, contentType string, body []byte) error
+	// Release drops a reservation so the request can be retried with the same key.
+	Release(ctx context.Context, subject, key string) error
+	// Purge deletes records that expired before the given time.
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+type PostgresStore struct {
+	DB *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{DB: db}
+}
+
+func (s *PostgresStore) Reserve(ctx context.Context, subject, key, requestHash string, expiresAt time.Time) (*Record, error) {
+	// An expired record is treated as absent and taken over by the new request.
+	query := `
+		INSERT INTO idempotency_keys (subject, key, request_hash, status, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (subject, key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash, status = EXCLUDED.status, expires_at = EXCLUDED.expires_at,
+		    response_status = NULL, response_content_type = NULL, response_body = NULL,
+		    created_at = CURRENT_TIMESTAMP, completed_at = NULL
+		WHERE idempotency_keys.expires_at <= CURRENT_TIMESTAMP
+		RETURNING key
+	`
+	var reserved string
+	err := s.DB.QueryRowContext(ctx, query, subject, key, requestHash, StatusInProgress, expiresAt).Scan(&reserved)
+	if err == nil {
+		return nil, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+
+	// The key is held by a live record.
+	rec := &Record{Subject: subject, Key: key}
+	var respStatus sql.NullInt64
+	var contentType sql.NullString
+	err = s.DB.QueryRowContext(ctx, `
+		SELECT request_hash, status, response_status, response_content_type, response_body, created_at, expires_at
+		FROM idempotency_keys
+		WHERE subject = $1 AND key = $2
+	`, subject, key).Scan(&rec.RequestHash, &rec.Status, &respStatus, &contentType, &rec.ResponseBody, &rec.CreatedAt, &rec.ExpiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load idempotency key: %w", err)
+	}
+	rec.ResponseStatus = int(respStatus.Int64)
+	rec.ResponseContentType = contentType.String
+	return rec, nil
+}
+
+func (s *PostgresStore) Complete(ctx context.Context, subject, key string, status int, contentType string, body []byte) error {
+	query := `
+		UPDATE idempotency_keys
+		SET status = $1, response_status = $2, response_content_type = $3, response_body = $4, completed_at = CURRENT_TIMESTAMP
+		WHERE subject = $5 AND key = $6
+	`
+	_, err := s.DB.ExecContext(ctx, query, StatusCompleted, status, contentType, body, subject, key)
+	if err != nil {
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) Release(ctx context.Context, subject, key string) error {
+	_, err := s.DB.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE subject = $1 AND key = $2 AND status = $3`, subject, key, StatusInProgress)
+	if err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.DB.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge idempotency keys: %w", err)
+	}
+	return res.RowsAffected()
+}
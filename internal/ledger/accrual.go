@@ -0,0 +1,395 @@
+package ledger
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CapitalizationFrequency is how often a product pays accrued interest into (or
+// charges it to) the account. Interest is capitalized at the end of each period.
+type CapitalizationFrequency string
+
+const (
+	CapitalizeDaily     CapitalizationFrequency = "DAILY"
+	CapitalizeMonthly   CapitalizationFrequency = "MONTHLY"
+	CapitalizeQuarterly CapitalizationFrequency = "QUARTERLY"
+	CapitalizeAnnually  CapitalizationFrequency = "ANNUALLY"
+)
+
+// AccrualSide is the accrual account interest builds up in until it is capitalized.
+type AccrualSide string
+
+const (
+	AccrualPayable    AccrualSide = "PAYABLE"    // Credit interest the bank owes the customer
+	AccrualReceivable AccrualSide = "RECEIVABLE" // Debit interest the customer owes the bank
+)
+
+// AccrualKind is the kind of an interest accrual sub-ledger record.
+type AccrualKind string
+
+const (
+	AccrualKindAccrual        AccrualKind = "ACCRUAL"
+	AccrualKindCapitalization AccrualKind = "CAPITALIZATION"
+)
+
+// accrualSides maps each side to its GL account and the events that accrue into
+// and capitalize out of it.
+var accrualSides = map[AccrualSide]struct {
+	glCode     string
+	glType     AccountType
+	accrue     EventType
+	capitalize EventType
+}{
+	AccrualPayable:    {GLCodeInterestPayable, Liability, EventInterestAccrual, EventInterestCapitalization},
+	AccrualReceivable: {GLCodeInterestReceivable, Asset, EventDebitInterestAccrual, EventDebitInterestCapitalization},
+}
+
+// AccruedInterest is an account's interest accrued but not yet capitalized.
+type AccruedInterest struct {
+	AccountID       uuid.UUID  `json:"account_id"`
+	Payable         int64      `json:"payable"`    // Owed to the customer
+	Receivable      int64      `json:"receivable"` // Owed by the customer
+	LastCapitalized *time.Time `json:"last_capitalized,omitempty"`
+}
+
+// AccrualReconciliation compares the outstanding interest of the accrual sub-ledger
+// with the balance of the accrual GL account, per side and currency.
+type AccrualReconciliation struct {
+	Side          AccrualSide `json:"side"`
+	GLCode        string      `json:"gl_code"`
+	Currency      string      `json:"currency"`
+	Accrued       int64       `json:"accrued"`        // Accruals less capitalizations
+	LedgerBalance int64       `json:"ledger_balance"` // In the GL account's normal direction
+	Difference    int64       `json:"difference"`
+}
+
+// capitalizationDue returns the end of the latest capitalization period that ends
+// on or before date.
+func capitalizationDue(freq CapitalizationFrequency, date time.Time) time.Time {
+	date = DateOf(date)
+	var months int
+	switch freq {
+	case CapitalizeMonthly:
+		months = 1
+	case CapitalizeQuarterly:
+		months = 3
+	case CapitalizeAnnually:
+		months = 12
+	default:
+		return date
+	}
+	y, m, _ := date.Date()
+	start := (int(m)-1)/months*months + 1
+	periodStart := time.Date(y, time.Month(start), 1, 0, 0, 0, 0, time.UTC)
+	if end := periodStart.AddDate(0, months, -1); end.Equal(date) {
+		return date
+	}
+	return periodStart.AddDate(0, 0, -1)
+}
+
+func recordAccrual(tx *sql.Tx, accountID, transactionID uuid.UUID, kind AccrualKind, side AccrualSide, amount int64, valueDate time.Time) error {
+	_, err := tx.Exec(`
+		INSERT INTO interest_accruals (account_id, transaction_id, kind, side, amount, value_date)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, accountID, transactionID, kind, side, amount, valueDate.Format("2006-01-02"))
+	if err != nil {
+		return fmt.Errorf("failed to record interest %s: %w", kind, err)
+	}
+	return nil
+}
+
+// accrualPosters holds an account's accrual event resolved for each side. Which side
+// a day accrues into is only known inside its transaction, so both are resolved
+// beforehand; a side that failed to resolve only fails the days that need it.
+type accrualPosters struct {
+	posters map[AccrualSide]*eventPoster
+	errs    map[AccrualSide]error
+}
+
+func (s *Service) resolveAccrualPosters(accountID uuid.UUID) accrualPosters {
+	p := accrualPosters{posters: make(map[AccrualSide]*eventPoster), errs: make(map[AccrualSide]error)}
+	for side, cfg := range accrualSides {
+		p.posters[side], p.errs[side] = s.resolveEventPoster(cfg.accrue, accountID)
+	}
+	return p
+}
+
+func (p accrualPosters) side(side AccrualSide) (*eventPoster, error) {
+	return p.posters[side], p.errs[side]
+}
+
+// interestAccount is an account with an interest-bearing product, as accrual needs it.
+type interestAccount struct {
+	id             uuid.UUID
+	accType        AccountType
+	accruedThrough *time.Time
+	rateBPS        int64
+	overdraftLimit int64 // Arranged overdraft limit of deposit accounts
+	terms          InterestTerms
+}
+
+// accrueInterestDay accrues one business day of interest on an account. A credit
+// position (stored balance below zero) earns credit interest into Interest Payable
+// through the INTEREST_ACCRUAL template; a debit position, an overdrawn deposit or a
+// loan, is charged debit interest into Interest Receivable through
+// DEBIT_INTEREST_ACCRUAL. The day's interest plus the fraction carried from earlier
+// days is rounded under the product's rounding mode; whole minor units are posted
+// and recorded in the accrual sub-ledger, and the rest is carried to the next day.
+// A day already accrued, for instance by a concurrent run, is skipped. The postings
+// are built from posters resolved beforehand, so the transaction only uses tx.
+func (s *Service) accrueInterestDay(a interestAccount, posters accrualPosters, day time.Time) (*Transaction, error) {
+	var posted *Transaction
+	err := s.inTx(func(tx *sql.Tx) error {
+		posted = nil
+		st, err := lockInterestState(tx, a.id)
+		if err != nil {
+			return err
+		}
+		if st.accruedThrough != nil && !DateOf(*st.accruedThrough).Before(day) {
+			return nil
+		}
+		balance, err := balanceAsOf(tx, a.id, day)
+		if err != nil {
+			return err
+		}
+
+		from := day.AddDate(0, 0, -1)
+		var side AccrualSide
+		var amount int64
+		switch {
+		case balance < 0:
+			side = AccrualPayable
+			amount, st.carry = accrueInterest(a.rateBPS, a.terms, -balance, from, day, st.carry)
+		case balance > 0:
+			// Only deposits have an arranged overdraft limit to charge a penalty above
+			arranged := int64(-1)
+			if a.accType == Liability {
+				arranged = a.overdraftLimit
+			}
+			side = AccrualReceivable
+			amount, st.debitCarry = carryForward(debitPeriodInterest(a.terms, balance, arranged, from, day), st.debitCarry, a.terms.Rounding)
+		}
+
+		// Default: Debit Bank Interest Expense (GL 5100), Credit Interest Payable (GL 2200),
+		// or Debit Interest Receivable (GL 1500), Credit Interest Income (GL 4200)
+		if amount > 0 {
+			poster, err := posters.side(side)
+			if err != nil {
+				return err
+			}
+			req, err := poster.request(amount, fmt.Sprintf("INT-%s-%s", a.id, day.Format("20060102")), day)
+			if err != nil {
+				return err
+			}
+			if posted, err = s.postRequestInTx(tx, *req); err != nil {
+				return err
+			}
+			if err := recordAccrual(tx, a.id, posted.ID, AccrualKindAccrual, side, amount, day); err != nil {
+				return err
+			}
+		}
+		return saveInterestState(tx, a.id, st, day)
+	})
+	return posted, err
+}
+
+// CapitalizeInterest capitalizes the interest due today. See CapitalizeInterestAsOf.
+func (s *Service) CapitalizeInterest() ([]*Transaction, error) {
+	return s.CapitalizeInterestAsOf(time.Now())
+}
+
+// CapitalizeInterestAsOf moves accrued interest out of Interest Payable into the
+// customer account (and charges Interest Receivable to it) for every account whose
+// product's capitalization period has ended by date. Only interest accrued up to
+// the end of the period is capitalized, with the period end as value date, so a
+// run that was missed is caught up by the next one. Each period is capitalized
+// once, under the reference CAP-<account>-<side>-<period end>; interest accrued into
+// a period after it was capitalized is carried into the next one.
+func (s *Service) CapitalizeInterestAsOf(date time.Time) ([]*Transaction, error) {
+	date = DateOf(date)
+
+	rows, err := s.db.Query(`
+		SELECT r.account_id, r.side, COALESCE(p.capitalization_frequency, 'DAILY')
+		FROM interest_accruals r
+		JOIN accounts a ON a.id = r.account_id
+		LEFT JOIN products p ON p.id = a.product_id
+		GROUP BY r.account_id, r.side, p.capitalization_frequency
+		HAVING SUM(CASE WHEN r.kind = 'ACCRUAL' THEN r.amount ELSE -r.amount END) > 0
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch accrued interest: %w", err)
+	}
+	defer rows.Close()
+
+	type candidate struct {
+		accountID uuid.UUID
+		side      AccrualSide
+		freq      CapitalizationFrequency
+	}
+	var candidates []candidate
+	for rows.Next() {
+		var c candidate
+		if err := rows.Scan(&c.accountID, &c.side, &c.freq); err != nil {
+			return nil, fmt.Errorf("failed to scan accrued interest: %w", err)
+		}
+		candidates = append(candidates, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch accrued interest: %w", err)
+	}
+	rows.Close()
+
+	var transactions []*Transaction
+	for _, c := range candidates {
+		due := capitalizationDue(c.freq, date)
+		poster, err := s.resolveEventPoster(accrualSides[c.side].capitalize, c.accountID)
+		if err != nil {
+			fmt.Printf("Failed to capitalize interest for account %s: %v\n", c.accountID, err)
+			continue
+		}
+		var posted *Transaction
+		err = s.inTx(func(tx *sql.Tx) error {
+			posted = nil
+			// Accruals update the same row, so they cannot interleave with this
+			if _, err := tx.Exec(`SELECT 1 FROM account_interest WHERE account_id = $1 FOR UPDATE`, c.accountID); err != nil {
+				return fmt.Errorf("failed to lock accrued interest: %w", err)
+			}
+			var amount int64
+			var capitalized bool
+			err := tx.QueryRow(`
+				SELECT COALESCE(SUM(CASE WHEN kind = 'CAPITALIZATION' THEN -amount WHEN value_date <= $3::DATE THEN amount ELSE 0 END), 0),
+				       COALESCE(BOOL_OR(kind = 'CAPITALIZATION' AND value_date = $3::DATE), FALSE)
+				FROM interest_accruals
+				WHERE account_id = $1 AND side = $2
+			`, c.accountID, c.side, due.Format("2006-01-02")).Scan(&amount, &capitalized)
+			if err != nil {
+				return fmt.Errorf("failed to load accrued interest: %w", err)
+			}
+			if amount <= 0 || capitalized {
+				return nil
+			}
+
+			req, err := poster.request(amount, fmt.Sprintf("CAP-%s-%s-%s", c.accountID, c.side, due.Format("2006-01-02")), due)
+			if err != nil {
+				return err
+			}
+			// Interest owed is charged even if it takes the account past its overdraft limit
+			req.noFundsCheck = true
+			if posted, err = s.postRequestInTx(tx, *req); err != nil {
+				return err
+			}
+			return recordAccrual(tx, c.accountID, posted.ID, AccrualKindCapitalization, c.side, amount, due)
+		})
+		if err != nil {
+			// Log error but continue processing other accounts
+			fmt.Printf("Failed to capitalize interest for account %s: %v\n", c.accountID, err)
+			continue
+		}
+		if posted != nil {
+			transactions = append(transactions, posted)
+		}
+	}
+	return transactions, nil
+}
+
+// GetAccruedInterest returns the interest accrued on an account and not yet capitalized.
+func (s *Service) GetAccruedInterest(accountID uuid.UUID) (*AccruedInterest, error) {
+	acc, err := s.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+	if acc == nil {
+		return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, accountID)
+	}
+
+	accrued := &AccruedInterest{AccountID: accountID}
+	err = s.db.QueryRow(`
+		SELECT COALESCE(SUM(CASE WHEN side = 'PAYABLE' THEN signed END), 0),
+		       COALESCE(SUM(CASE WHEN side = 'RECEIVABLE' THEN signed END), 0),
+		       MAX(value_date) FILTER (WHERE kind = 'CAPITALIZATION')
+		FROM (
+			SELECT side, kind, value_date, CASE WHEN kind = 'ACCRUAL' THEN amount ELSE -amount END AS signed
+			FROM interest_accruals WHERE account_id = $1
+		) r
+	`, accountID).Scan(&accrued.Payable, &accrued.Receivable, &accrued.LastCapitalized)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load accrued interest: %w", err)
+	}
+	return accrued, nil
+}
+
+// ReconcileInterestAccruals compares, per accrual side and currency, the interest
+// the sub-ledger says is outstanding with the balance of the Interest Payable or
+// Receivable GL account. Any difference means a posting to the GL account that the
+// sub-ledger did not record, or the other way round.
+func (s *Service) ReconcileInterestAccruals() ([]AccrualReconciliation, error) {
+	var result []AccrualReconciliation
+	for _, side := range []AccrualSide{AccrualPayable, AccrualReceivable} {
+		cfg := accrualSides[side]
+		byCurrency := make(map[string]*AccrualReconciliation)
+		row := func(currency string) *AccrualReconciliation {
+			r, ok := byCurrency[currency]
+			if !ok {
+				r = &AccrualReconciliation{Side: side, GLCode: cfg.glCode, Currency: currency}
+				byCurrency[currency] = r
+			}
+			return r
+		}
+
+		rows, err := s.db.Query(`
+			SELECT a.currency, SUM(CASE WHEN r.kind = 'ACCRUAL' THEN r.amount ELSE -r.amount END)
+			FROM interest_accruals r JOIN accounts a ON a.id = r.account_id
+			WHERE r.side = $1
+			GROUP BY a.currency
+		`, side)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sum accrued interest: %w", err)
+		}
+		for rows.Next() {
+			var currency string
+			var amount int64
+			if err := rows.Scan(&currency, &amount); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan accrued interest: %w", err)
+			}
+			row(currency).Accrued = amount
+		}
+		rows.Close()
+
+		rows, err = s.db.Query(`
+			SELECT a.currency, SUM(a.balance)
+			FROM accounts a JOIN gl_accounts g ON g.id = a.gl_account_id
+			WHERE g.code = $1
+			GROUP BY a.currency
+		`, cfg.glCode)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sum accrual account balances: %w", err)
+		}
+		for rows.Next() {
+			var currency string
+			var balance int64
+			if err := rows.Scan(&currency, &balance); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan accrual account balance: %w", err)
+			}
+			row(currency).LedgerBalance = NaturalBalance(cfg.glType, balance)
+		}
+		rows.Close()
+
+		currencies := make([]string, 0, len(byCurrency))
+		for c := range byCurrency {
+			currencies = append(currencies, c)
+		}
+		sort.Strings(currencies)
+		for _, c := range currencies {
+			r := byCurrency[c]
+			r.Difference = r.LedgerBalance - r.Accrued
+			result = append(result, *r)
+		}
+	}
+	return result, nil
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/nathanmocogni/core-banking-system/internal/common"
 )
 
 type Client struct {
@@ -24,7 +25,7 @@ type Client struct {
 type ClientRepository interface {
 	CreateClient(ctx context.Context, client *Client) error
 	GetClient(ctx context.Context, id uuid.UUID) (*Client, error)
-	ListClients(ctx context.Context) ([]Client, error)
+	ListClients(ctx context.Context, page common.PageRequest) ([]Client, string, error)
 }
 
 type PostgresClientRepository struct {
@@ -65,15 +66,30 @@ func (r *PostgresClientRepository) GetClient(ctx context.Context, id uuid.UUID)
 	return &client, nil
 }
 
-func (r *PostgresClientRepository) ListClients(ctx context.Context) ([]Client, error) {
+// ListClients returns a page of clients ordered by name, and the cursor of the next
+// page ("" on the last page).
+func (r *PostgresClientRepository) ListClients(ctx context.Context, page common.PageRequest) ([]Client, string, error) {
+	cursor, err := common.DecodeCursor(page.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	limit := page.PageSize()
+	var after sql.NullString
+	var afterID uuid.UUID
+	if cursor != nil {
+		after, afterID = sql.NullString{String: cursor.Key, Valid: true}, cursor.ID
+	}
+
 	query := `
 		SELECT id, external_id, name, type, status, risk_rating, tax_domicile, classification, created_at, updated_at
 		FROM clients
-		ORDER BY name
+		WHERE $1::VARCHAR IS NULL OR (name, id) > ($1::VARCHAR, $2::UUID)
+		ORDER BY name, id
+		LIMIT $3
 	`
-	rows, err := r.DB.QueryContext(ctx, query)
+	rows, err := r.DB.QueryContext(ctx, query, after, afterID, limit+1)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	defer rows.Close()
 
@@ -85,11 +101,20 @@ func (r *PostgresClientRepository) ListClients(ctx context.Context) ([]Client, e
 			&c.RiskRating, &c.TaxDomicile, &c.Classification,
 			&c.CreatedAt, &c.UpdatedAt,
 		); err != nil {
-			return nil, err
+			return nil, "", err
 		}
 		clients = append(clients, c)
 	}
-	return clients, nil
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	var next string
+	if len(clients) > limit {
+		clients = clients[:limit]
+		next = common.EncodeCursor(clients[limit-1].Name, clients[limit-1].ID)
+	}
+	return clients, next, nil
 }
 
 type ClientService struct {
@@ -0,0 +1,39 @@
+package ledger
+
+import "errors"
+
+// Sentinel errors returned by the ledger service. Handlers map them to HTTP status codes with errors.Is.
+var (
+	ErrDuplicateReference   = errors.New("a transaction with this reference already exists")
+	ErrTransactionNotFound  = errors.New("transaction not found")
+	ErrAlreadyReversed      = errors.New("transaction has already been reversed")
+	ErrReversalOfReversal   = errors.New("a reversal cannot itself be reversed; post a new transaction instead")
+	ErrAccountNotFound      = errors.New("account not found")
+	ErrInsufficientFunds    = errors.New("insufficient available funds")
+	ErrHoldNotFound         = errors.New("hold not found")
+	ErrHoldNotActive        = errors.New("hold is no longer active")
+	ErrUnknownCurrency      = errors.New("unknown or inactive currency")
+	ErrCurrencyMismatch     = errors.New("entry currency does not match account currency")
+	ErrFXRateNotFound       = errors.New("no FX rate for currency pair")
+	ErrDateOutOfPolicy      = errors.New("date is outside the back/forward-dating policy")
+	ErrPeriodNotFound       = errors.New("accounting period not found")
+	ErrPeriodOverlap        = errors.New("accounting period overlaps an existing period")
+	ErrPeriodClosed         = errors.New("accounting period is closed for posting")
+	ErrInvalidPeriodStatus  = errors.New("invalid accounting period status change")
+	ErrNoRetainedEarnings   = errors.New("no retained earnings account configured for currency")
+	ErrAccountNotPostable   = errors.New("account status does not allow this posting")
+	ErrInvalidAccountState  = errors.New("invalid account status change")
+	ErrAccountNotEmpty      = errors.New("account must have a zero balance and no active holds to close")
+	ErrGLAccountNotFound    = errors.New("GL account not found")
+	ErrInvalidGLAccount     = errors.New("invalid GL account")
+	ErrGLAccountInUse       = errors.New("GL account has children or mapped accounts")
+	ErrTemplateNotFound     = errors.New("posting template not found")
+	ErrInvalidTemplate      = errors.New("invalid posting template")
+	ErrNoPostingTemplate    = errors.New("no posting template for event")
+	ErrStatementNotFound    = errors.New("statement not found")
+	ErrInvalidImport        = errors.New("invalid import file")
+	ErrImportNotFound       = errors.New("import not found")
+	ErrInvalidMetadata      = errors.New("invalid transaction metadata")
+	ErrInvalidTxStatus      = errors.New("transaction status does not allow this operation")
+	ErrInvalidInterestTerms = errors.New("invalid interest terms")
+)
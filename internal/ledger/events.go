@@ -0,0 +1,36 @@
+package ledger
+
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/nathanmocogni/core-banking-system/internal/events"
+)
+
+// Event types published by the ledger.
+const (
+	EventTransactionPosted   = "TransactionPosted"
+	EventTransactionReversed = "TransactionReversed"
+	// EventTransactionAuthorized and EventTransactionVoided track pending transactions;
+	// posting one publishes EventTransactionPosted.
+	EventTransactionAuthorized = "TransactionAuthorized"
+	EventTransactionVoided     = "TransactionVoided"
+)
+
+const aggregateTransaction = "TRANSACTION"
+
+// publishEvent stages a ledger event in the outbox as part of tx.
+// The payload always carries "event" and "event_id" so consumers can route and de-duplicate.
+func (s *Service) publishEvent(tx *sql.Tx, eventType string, transactionID uuid.UUID, payload map[string]interface{}) error {
+	eventID := uuid.New()
+	payload["event"] = eventType
+	payload["event_id"] = eventID
+
+	return events.Enqueue(tx, events.OutboxEvent{
+		ID:            eventID,
+		AggregateType: aggregateTransaction,
+		AggregateID:   transactionID.String(),
+		EventType:     eventType,
+		Payload:       payload,
+	})
+}
@@ -0,0 +1,229 @@
+package ledger
+
+import (
+	"database/sql"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// currencyDecimals returns the number of minor-unit digits of an active currency.
+func currencyDecimals(q rowQuerier, code string) (int, error) {
+	var decimals int
+	err := q.QueryRow(`SELECT decimals FROM currencies WHERE code = $1 AND COALESCE(is_active, TRUE)`, code).Scan(&decimals)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownCurrency, code)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to load currency: %w", err)
+	}
+	return decimals, nil
+}
+
+// resolveEntryCurrencies fills in each entry's currency from its account, rejects
+// entries that name a different currency, and checks that debits equal credits
+// within every currency.
+func resolveEntryCurrencies(tx *sql.Tx, entries []Entry) error {
+	ids := make([]string, 0, len(entries))
+	for _, e := range entries {
+		ids = append(ids, e.AccountID.String())
+	}
+
+	rows, err := tx.Query(`SELECT id, currency FROM accounts WHERE id = ANY($1)`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to load account currencies: %w", err)
+	}
+	currencies := make(map[uuid.UUID]string)
+	for rows.Next() {
+		var id uuid.UUID
+		var currency string
+		if err := rows.Scan(&id, &currency); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan account currency: %w", err)
+		}
+		currencies[id] = currency
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to load account currencies: %w", err)
+	}
+
+	net := make(map[string]int64)
+	for i := range entries {
+		currency, ok := currencies[entries[i].AccountID]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrAccountNotFound, entries[i].AccountID)
+		}
+		if entries[i].Currency != "" && entries[i].Currency != currency {
+			return fmt.Errorf("%w: entry in %s against %s account %s", ErrCurrencyMismatch, entries[i].Currency, currency, entries[i].AccountID)
+		}
+		entries[i].Currency = currency
+		if entries[i].Direction == Debit {
+			net[currency] += entries[i].Amount
+		} else {
+			net[currency] -= entries[i].Amount
+		}
+	}
+
+	// Report currencies in a stable order
+	codes := make([]string, 0, len(net))
+	for c := range net {
+		codes = append(codes, c)
+	}
+	sort.Strings(codes)
+	for _, c := range codes {
+		if net[c] != 0 {
+			return fmt.Errorf("transaction is not balanced in %s: debits - credits = %d", c, net[c])
+		}
+	}
+	return nil
+}
+
+// SetFXRate records a rate for 1 unit of base in quote. rate is a decimal string
+// (e.g. "1.0825") so no precision is lost on the way to NUMERIC.
+func (s *Service) SetFXRate(base, quote, rate string) (*FXRate, error) {
+	if base == quote {
+		return nil, fmt.Errorf("base and quote currency must differ")
+	}
+	r, ok := new(big.Rat).SetString(rate)
+	if !ok || r.Sign() <= 0 {
+		return nil, fmt.Errorf("rate must be a positive decimal")
+	}
+	for _, c := range []string{base, quote} {
+		if _, err := currencyDecimals(s.db, c); err != nil {
+			return nil, err
+		}
+	}
+
+	fx := &FXRate{Base: base, Quote: quote}
+	err := s.db.QueryRow(`
+		INSERT INTO fx_rates (base_currency, quote_currency, rate)
+		VALUES ($1, $2, $3)
+		RETURNING id, rate::TEXT, as_of
+	`, base, quote, rate).Scan(&fx.ID, &fx.Rate, &fx.AsOf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store FX rate: %w", err)
+	}
+	return fx, nil
+}
+
+// GetFXRate returns the latest rate for base/quote. If only the inverse pair is
+// quoted, its reciprocal is returned.
+func (s *Service) GetFXRate(base, quote string) (*FXRate, error) {
+	query := `
+		SELECT id, rate::TEXT, as_of FROM fx_rates
+		WHERE base_currency = $1 AND quote_currency = $2
+		ORDER BY as_of DESC
+		LIMIT 1
+	`
+	fx := &FXRate{Base: base, Quote: quote}
+	err := s.db.QueryRow(query, base, quote).Scan(&fx.ID, &fx.Rate, &fx.AsOf)
+	if err == nil {
+		return fx, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to load FX rate: %w", err)
+	}
+
+	// Fall back to the inverse quote
+	var inverse string
+	err = s.db.QueryRow(query, quote, base).Scan(&fx.ID, &inverse, &fx.AsOf)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s/%s", ErrFXRateNotFound, base, quote)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load FX rate: %w", err)
+	}
+	r, ok := new(big.Rat).SetString(inverse)
+	if !ok || r.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid stored FX rate %q", inverse)
+	}
+	fx.Rate = new(big.Rat).Inv(r).FloatString(12)
+	return fx, nil
+}
+
+// ConvertAmount converts amount (minor units of from) into minor units of to at the
+// latest rate, rounding half away from zero.
+func (s *Service) ConvertAmount(amount int64, from, to string) (int64, *FXRate, error) {
+	if from == to {
+		return amount, nil, nil
+	}
+	fromDecimals, err := currencyDecimals(s.db, from)
+	if err != nil {
+		return 0, nil, err
+	}
+	toDecimals, err := currencyDecimals(s.db, to)
+	if err != nil {
+		return 0, nil, err
+	}
+	fx, err := s.GetFXRate(from, to)
+	if err != nil {
+		return 0, nil, err
+	}
+	converted, err := convertMinorUnits(amount, fromDecimals, toDecimals, fx.Rate)
+	if err != nil {
+		return 0, nil, err
+	}
+	return converted, fx, nil
+}
+
+// convertMinorUnits applies a major-unit rate to a minor-unit amount, adjusting for
+// the difference in decimals (e.g. USD cents to whole JPY).
+func convertMinorUnits(amount int64, fromDecimals, toDecimals int, rate string) (int64, error) {
+	r, ok := new(big.Rat).SetString(rate)
+	if !ok || r.Sign() <= 0 {
+		return 0, fmt.Errorf("invalid FX rate %q", rate)
+	}
+	v := new(big.Rat).Mul(new(big.Rat).SetInt64(amount), r)
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(toDecimals-fromDecimals))), nil)
+	if toDecimals >= fromDecimals {
+		v.Mul(v, new(big.Rat).SetInt(scale))
+	} else {
+		v.Quo(v, new(big.Rat).SetInt(scale))
+	}
+
+	// Round half away from zero
+	num, den := new(big.Int).Abs(v.Num()), v.Denom()
+	q, m := new(big.Int).QuoRem(num, den, new(big.Int))
+	if new(big.Int).Mul(m, big.NewInt(2)).Cmp(den) >= 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	if v.Sign() < 0 {
+		q.Neg(q)
+	}
+	if !q.IsInt64() {
+		return 0, fmt.Errorf("converted amount overflows")
+	}
+	return q.Int64(), nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// PostTransfer moves amount (minor units of the source account's currency) from one
+// account to another through the TRANSFER posting template. When the accounts hold
+// different currencies the movement is posted as two balanced legs through the FX
+// Position account (GL 1300) of each currency, which carries the bank's side of the
+// exchange. With the default template:
+//
+//	Debit  from         amount     (source currency)
+//	Credit to           converted  (target currency)
+//	Credit FX Position  amount     (source currency)
+//	Debit  FX Position  converted  (target currency)
+func (s *Service) PostTransfer(reference, description string, fromAccountID, toAccountID uuid.UUID, amount int64) (*Transaction, error) {
+	return s.PostEvent(PostingEvent{
+		Type:           EventTransfer,
+		AccountID:      fromAccountID,
+		CounterpartyID: toAccountID,
+		Amount:         amount,
+		Reference:      reference,
+		Description:    description,
+	})
+}
@@ -0,0 +1,425 @@
+package ledger
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Codes of the GL accounts seeded with the default chart. System accounts are
+// found through these rather than by name.
+const (
+	GLCodePaymentSettlement  = "1200"
+	GLCodeFXPosition         = "1300"
+	GLCodeCustomerLoans      = "1400"
+	GLCodeInterestReceivable = "1500"
+	GLCodeCustomerDeposits   = "2100"
+	GLCodeInterestPayable    = "2200"
+	GLCodeRetainedEarnings   = "3100"
+	GLCodeFeeIncome          = "4100"
+	GLCodeInterestIncome     = "4200"
+	GLCodeInterestExpense    = "5100"
+)
+
+// customerControlGL is the control GL account customer (client-owned) accounts are
+// mapped to when they are opened.
+var customerControlGL = map[AccountType]string{
+	Liability: GLCodeCustomerDeposits,
+	Asset:     GLCodeCustomerLoans,
+}
+
+const glColumns = `g.id, g.code, g.name, g.type, g.parent_id, COALESCE(p.code, ''), g.is_header, g.is_postable, g.created_at, g.updated_at`
+
+func scanGLAccount(row rowScanner) (*GLAccount, error) {
+	var g GLAccount
+	if err := row.Scan(&g.ID, &g.Code, &g.Name, &g.Type, &g.ParentID, &g.ParentCode, &g.IsHeader, &g.IsPostable, &g.CreatedAt, &g.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// GetGLAccount returns the GL account with code, or ErrGLAccountNotFound.
+func (s *Service) GetGLAccount(code string) (*GLAccount, error) {
+	return getGLAccount(s.db, code)
+}
+
+func getGLAccount(q rowQuerier, code string) (*GLAccount, error) {
+	g, err := scanGLAccount(q.QueryRow(`
+		SELECT `+glColumns+`
+		FROM gl_accounts g LEFT JOIN gl_accounts p ON p.id = g.parent_id
+		WHERE g.code = $1
+	`, code))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrGLAccountNotFound, code)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load GL account: %w", err)
+	}
+	return g, nil
+}
+
+// ListGLAccounts returns the whole chart of accounts ordered by code.
+func (s *Service) ListGLAccounts() ([]GLAccount, error) {
+	rows, err := s.db.Query(`
+		SELECT ` + glColumns + `
+		FROM gl_accounts g LEFT JOIN gl_accounts p ON p.id = g.parent_id
+		ORDER BY g.code
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list GL accounts: %w", err)
+	}
+	defer rows.Close()
+
+	chart := []GLAccount{}
+	for rows.Next() {
+		g, err := scanGLAccount(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan GL account: %w", err)
+		}
+		chart = append(chart, *g)
+	}
+	return chart, rows.Err()
+}
+
+// resolveParent checks that parentCode names a header account of the same type.
+func resolveParent(q rowQuerier, parentCode string, accType AccountType) (*uuid.UUID, error) {
+	if parentCode == "" {
+		return nil, nil
+	}
+	parent, err := getGLAccount(q, parentCode)
+	if err != nil {
+		return nil, err
+	}
+	if !parent.IsHeader {
+		return nil, fmt.Errorf("%w: parent %s is not a header account", ErrInvalidGLAccount, parentCode)
+	}
+	if parent.Type != accType {
+		return nil, fmt.Errorf("%w: parent %s is %s, not %s", ErrInvalidGLAccount, parentCode, parent.Type, accType)
+	}
+	return &parent.ID, nil
+}
+
+// CreateGLAccount adds an account to the chart. Header accounts are never postable.
+func (s *Service) CreateGLAccount(code, name string, accType AccountType, parentCode string, header, postable bool) (*GLAccount, error) {
+	if code == "" || name == "" {
+		return nil, fmt.Errorf("%w: code and name are required", ErrInvalidGLAccount)
+	}
+	switch accType {
+	case Asset, Liability, Equity, Income, Expense:
+	default:
+		return nil, fmt.Errorf("%w: unknown type %q", ErrInvalidGLAccount, accType)
+	}
+	parentID, err := resolveParent(s.db, parentCode, accType)
+	if err != nil {
+		return nil, err
+	}
+	if header {
+		postable = false
+	}
+
+	g := &GLAccount{Code: code, Name: name, Type: accType, ParentID: parentID, ParentCode: parentCode, IsHeader: header, IsPostable: postable}
+	err = s.db.QueryRow(`
+		INSERT INTO gl_accounts (code, name, type, parent_id, is_header, is_postable)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`, code, name, accType, parentID, header, postable).Scan(&g.ID, &g.CreatedAt, &g.UpdatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return nil, fmt.Errorf("%w: code %s already exists", ErrInvalidGLAccount, code)
+		}
+		return nil, fmt.Errorf("failed to create GL account: %w", err)
+	}
+	return g, nil
+}
+
+// GLAccountUpdate holds the mutable fields of a GL account. Code, type and the
+// header flag are fixed once created.
+type GLAccountUpdate struct {
+	Name       string `json:"name"`
+	ParentCode string `json:"parent_code"`
+	IsPostable bool   `json:"is_postable"`
+}
+
+// UpdateGLAccount renames, re-parents or (un)blocks a GL account. Moving an account
+// under one of its own descendants is rejected.
+func (s *Service) UpdateGLAccount(code string, u GLAccountUpdate) (*GLAccount, error) {
+	if u.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidGLAccount)
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	g, err := getGLAccount(tx, code)
+	if err != nil {
+		return nil, err
+	}
+	if g.IsHeader && u.IsPostable {
+		return nil, fmt.Errorf("%w: header account %s cannot be postable", ErrInvalidGLAccount, code)
+	}
+	parentID, err := resolveParent(tx, u.ParentCode, g.Type)
+	if err != nil {
+		return nil, err
+	}
+	if parentID != nil {
+		var cycle bool
+		err := tx.QueryRow(`
+			WITH RECURSIVE ancestors AS (
+				SELECT id, parent_id FROM gl_accounts WHERE id = $1
+				UNION ALL
+				SELECT g.id, g.parent_id FROM gl_accounts g JOIN ancestors a ON g.id = a.parent_id
+			)
+			SELECT EXISTS (SELECT 1 FROM ancestors WHERE id = $2)
+		`, *parentID, g.ID).Scan(&cycle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check GL hierarchy: %w", err)
+		}
+		if cycle {
+			return nil, fmt.Errorf("%w: %s cannot be moved under itself", ErrInvalidGLAccount, code)
+		}
+	}
+
+	_, err = tx.Exec(`
+		UPDATE gl_accounts SET name = $1, parent_id = $2, is_postable = $3, updated_at = NOW()
+		WHERE id = $4
+	`, u.Name, parentID, u.IsPostable, g.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update GL account: %w", err)
+	}
+	g, err = getGLAccount(tx, code)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return g, nil
+}
+
+// DeleteGLAccount removes a GL account with no children and nothing mapped to it.
+func (s *Service) DeleteGLAccount(code string) error {
+	g, err := s.GetGLAccount(code)
+	if err != nil {
+		return err
+	}
+	var inUse bool
+	err = s.db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM gl_accounts WHERE parent_id = $1)
+		    OR EXISTS (SELECT 1 FROM accounts WHERE gl_account_id = $1)
+		    OR EXISTS (SELECT 1 FROM products WHERE control_gl_account_id = $1)
+	`, g.ID).Scan(&inUse)
+	if err != nil {
+		return fmt.Errorf("failed to check GL account usage: %w", err)
+	}
+	if inUse {
+		return fmt.Errorf("%w: %s", ErrGLAccountInUse, code)
+	}
+	if _, err := s.db.Exec(`DELETE FROM gl_accounts WHERE id = $1`, g.ID); err != nil {
+		return fmt.Errorf("failed to delete GL account: %w", err)
+	}
+	return nil
+}
+
+// mappableGL loads a GL account that ledger accounts of accType may be mapped to.
+func mappableGL(q rowQuerier, code string, accType AccountType) (*GLAccount, error) {
+	g, err := getGLAccount(q, code)
+	if err != nil {
+		return nil, err
+	}
+	if g.IsHeader {
+		return nil, fmt.Errorf("%w: %s is a header account", ErrInvalidGLAccount, code)
+	}
+	if g.Type != accType {
+		return nil, fmt.Errorf("%w: %s is %s, account is %s", ErrInvalidGLAccount, code, g.Type, accType)
+	}
+	return g, nil
+}
+
+// SetAccountGL maps a ledger account to a GL account of the same type.
+func (s *Service) SetAccountGL(accountID uuid.UUID, code string) error {
+	account, err := s.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+	if account == nil {
+		return fmt.Errorf("%w: %s", ErrAccountNotFound, accountID)
+	}
+	g, err := mappableGL(s.db, code, account.Type)
+	if err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(`UPDATE accounts SET gl_account_id = $1 WHERE id = $2`, g.ID, accountID); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" && pqErr.Constraint == "idx_accounts_system_gl" {
+			return fmt.Errorf("%w: %s is already backed by a %s system account", ErrInvalidGLAccount, code, account.Currency)
+		}
+		return fmt.Errorf("failed to map account to GL: %w", err)
+	}
+	return nil
+}
+
+// SetProductControlGL sets the GL account that accounts assigned to the product are
+// mapped to. An empty code clears it.
+func (s *Service) SetProductControlGL(productID uuid.UUID, code string) error {
+	var glID *uuid.UUID
+	if code != "" {
+		g, err := s.GetGLAccount(code)
+		if err != nil {
+			return err
+		}
+		if g.IsHeader {
+			return fmt.Errorf("%w: %s is a header account", ErrInvalidGLAccount, code)
+		}
+		glID = &g.ID
+	}
+	res, err := s.db.Exec(`UPDATE products SET control_gl_account_id = $1 WHERE id = $2`, glID, productID)
+	if err != nil {
+		return fmt.Errorf("failed to set product control GL: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("product not found")
+	}
+	return nil
+}
+
+// GetOrCreateGLSystemAccount returns the system ledger account backing the GL
+// account code in currency, creating it (named after the GL account) on first use.
+// Concurrent first uses get the same account: the insert yields to the unique index
+// on (gl_account_id, currency) and the winner's row is read back.
+func (s *Service) GetOrCreateGLSystemAccount(code, currency string) (uuid.UUID, error) {
+	g, err := s.GetGLAccount(code)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	find := func() (uuid.UUID, error) {
+		var id uuid.UUID
+		err := s.db.QueryRow(`
+			SELECT id FROM accounts
+			WHERE gl_account_id = $1 AND currency = $2 AND account_category = 'SYSTEM'
+		`, g.ID, currency).Scan(&id)
+		return id, err
+	}
+	id, err := find()
+	if err == nil {
+		return id, nil
+	}
+	if err != sql.ErrNoRows {
+		return uuid.Nil, fmt.Errorf("failed to find system account: %w", err)
+	}
+
+	if g.IsHeader {
+		return uuid.Nil, fmt.Errorf("%w: %s is a header account", ErrInvalidGLAccount, code)
+	}
+	if _, err := currencyDecimals(s.db, currency); err != nil {
+		return uuid.Nil, err
+	}
+	_, err = s.db.Exec(`
+		INSERT INTO accounts (name, type, currency, account_category, ownership_type, status, gl_account_id)
+		VALUES ($1, $2, $3, 'SYSTEM', 'SYSTEM', $4, $5)
+		ON CONFLICT (gl_account_id, currency) WHERE account_category = 'SYSTEM' DO NOTHING
+	`, g.Name, g.Type, currency, AccountActive, g.ID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to create system account: %w", err)
+	}
+	if id, err = find(); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to find system account: %w", err)
+	}
+	return id, nil
+}
+
+// GLBalance is a GL account's activity, including everything below it in the tree.
+// Amounts are signed as stored (debit +, credit -) except Debits/Credits.
+type GLBalance struct {
+	GLAccountID    uuid.UUID    `json:"gl_account_id"`
+	Code           string       `json:"code"`
+	Name           string       `json:"name"`
+	Type           AccountType  `json:"type"`
+	ParentCode     string       `json:"parent_code,omitempty"`
+	IsHeader       bool         `json:"is_header"`
+	Level          int          `json:"level"` // 1 for top-level accounts
+	OpeningBalance int64        `json:"opening_balance"`
+	Debits         int64        `json:"debits"`
+	Credits        int64        `json:"credits"`
+	ClosingBalance int64        `json:"closing_balance"`
+	Balance        int64        `json:"balance"` // Closing balance in the normal direction
+	Children       []*GLBalance `json:"children,omitempty"`
+}
+
+func (b *GLBalance) add(opening, debits, credits int64) {
+	b.OpeningBalance += opening
+	b.Debits += debits
+	b.Credits += credits
+}
+
+// BuildGLRollup places each account's activity on its GL account and rolls it up
+// to every ancestor. Activity of accounts without a GL mapping is returned separately.
+func BuildGLRollup(chart []GLAccount, activity []AccountActivity) (roots []*GLBalance, unmapped *GLBalance) {
+	nodes := make(map[uuid.UUID]*GLBalance, len(chart))
+	for _, g := range chart {
+		nodes[g.ID] = &GLBalance{GLAccountID: g.ID, Code: g.Code, Name: g.Name, Type: g.Type, ParentCode: g.ParentCode, IsHeader: g.IsHeader}
+	}
+	parents := make(map[uuid.UUID]uuid.UUID, len(chart))
+	for _, g := range chart {
+		if g.ParentID != nil && nodes[*g.ParentID] != nil {
+			parents[g.ID] = *g.ParentID
+			nodes[*g.ParentID].Children = append(nodes[*g.ParentID].Children, nodes[g.ID])
+		} else {
+			roots = append(roots, nodes[g.ID])
+		}
+	}
+
+	unmapped = &GLBalance{Code: "UNMAPPED", Name: "Accounts without a GL mapping"}
+	for _, a := range activity {
+		if a.GLAccountID == nil || nodes[*a.GLAccountID] == nil {
+			unmapped.add(a.OpeningBalance, a.Debits, a.Credits)
+			continue
+		}
+		// Walk up the tree; the visited set guards against a corrupt (cyclic) chart
+		visited := make(map[uuid.UUID]bool)
+		for id, ok := *a.GLAccountID, true; ok && !visited[id]; id, ok = parents[id] {
+			visited[id] = true
+			nodes[id].add(a.OpeningBalance, a.Debits, a.Credits)
+		}
+	}
+
+	var finish func(nodes []*GLBalance, level int)
+	finish = func(nodes []*GLBalance, level int) {
+		sort.Slice(nodes, func(i, j int) bool { return nodes[i].Code < nodes[j].Code })
+		for _, n := range nodes {
+			n.Level = level
+			n.ClosingBalance = n.OpeningBalance + n.Debits - n.Credits
+			n.Balance = NaturalBalance(n.Type, n.ClosingBalance)
+			finish(n.Children, level+1)
+		}
+	}
+	finish(roots, 1)
+	unmapped.ClosingBalance = unmapped.OpeningBalance + unmapped.Debits - unmapped.Credits
+	unmapped.Balance = unmapped.ClosingBalance
+	return roots, unmapped
+}
+
+// FlattenGL lists the tree depth-first. With level > 0 it keeps only the accounts
+// at that depth, plus shallower accounts that have nothing below them, so the
+// lines still add up to the whole ledger.
+func FlattenGL(roots []*GLBalance, level int) []*GLBalance {
+	var lines []*GLBalance
+	var walk func(nodes []*GLBalance)
+	walk = func(nodes []*GLBalance) {
+		for _, n := range nodes {
+			if level <= 0 || n.Level == level || (n.Level < level && len(n.Children) == 0) {
+				line := *n
+				line.Children = nil
+				lines = append(lines, &line)
+			}
+			if level <= 0 || n.Level < level {
+				walk(n.Children)
+			}
+		}
+	}
+	walk(roots)
+	return lines
+}
@@ -0,0 +1,175 @@
+package ledger
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/nathanmocogni/core-banking-system/internal/common"
+)
+
+// TransactionFilter narrows an account's transaction history. Amount and direction
+// apply to the account's own entries; zero values are ignored. Without an account
+// the search spans all transactions and amount and direction apply to any entry.
+type TransactionFilter struct {
+	AccountID       uuid.UUID
+	FromDate        time.Time // Value date, inclusive
+	ToDate          time.Time // Value date, inclusive
+	MinAmount       int64
+	MaxAmount       int64
+	Direction       EntryDirection
+	ReferencePrefix string
+	CounterpartyID  uuid.UUID // Only transactions that also post to this account
+	// Metadata matches transactions whose metadata has every key with the given
+	// string value; an empty value only requires the key.
+	Metadata map[string]string
+}
+
+// GetTransactions returns a page of the posted transactions an account was involved
+// in, newest first, with all their entries, and the cursor of the next page ("" on the
+// last page).
+func (s *Service) GetTransactions(filter TransactionFilter, page common.PageRequest) ([]*Transaction, string, error) {
+	cursor, err := common.DecodeCursor(page.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	limit := page.PageSize()
+
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	// Conditions on the account's own entries
+	entryConds := []string{"e.transaction_id = t.id"}
+	if filter.AccountID != uuid.Nil {
+		entryConds = append(entryConds, "e.account_id = "+arg(filter.AccountID))
+	}
+	if filter.Direction != "" {
+		entryConds = append(entryConds, "e.direction = "+arg(filter.Direction))
+	}
+	if filter.MinAmount > 0 {
+		entryConds = append(entryConds, "e.amount >= "+arg(filter.MinAmount))
+	}
+	if filter.MaxAmount > 0 {
+		entryConds = append(entryConds, "e.amount <= "+arg(filter.MaxAmount))
+	}
+	// Pending and voided transactions have no entries; see ListPendingTransactions
+	conds := []string{"t.status = 'POSTED'"}
+	if len(entryConds) > 1 {
+		conds = append(conds, "EXISTS (SELECT 1 FROM entries e WHERE "+strings.Join(entryConds, " AND ")+")")
+	}
+
+	if !filter.FromDate.IsZero() {
+		conds = append(conds, "t.value_date >= "+arg(filter.FromDate.Format("2006-01-02"))+"::DATE")
+	}
+	if !filter.ToDate.IsZero() {
+		conds = append(conds, "t.value_date <= "+arg(filter.ToDate.Format("2006-01-02"))+"::DATE")
+	}
+	if filter.ReferencePrefix != "" {
+		// Escape LIKE wildcards so the prefix matches literally
+		prefix := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(filter.ReferencePrefix)
+		conds = append(conds, "t.reference LIKE "+arg(prefix+"%"))
+	}
+	if filter.CounterpartyID != uuid.Nil {
+		conds = append(conds, "EXISTS (SELECT 1 FROM entries c WHERE c.transaction_id = t.id AND c.account_id = "+arg(filter.CounterpartyID)+")")
+	}
+	if len(filter.Metadata) > 0 {
+		contains := make(map[string]string)
+		for k, v := range filter.Metadata {
+			if v == "" {
+				conds = append(conds, "t.metadata ? "+arg(k))
+			} else {
+				contains[k] = v
+			}
+		}
+		if len(contains) > 0 {
+			doc, _ := json.Marshal(contains)
+			conds = append(conds, "t.metadata @> "+arg(string(doc))+"::JSONB")
+		}
+	}
+	if cursor != nil {
+		postedAt, err := time.Parse(time.RFC3339Nano, cursor.Key)
+		if err != nil {
+			return nil, "", common.ErrInvalidCursor
+		}
+		conds = append(conds, "(t.posted_at, t.id) < ("+arg(postedAt)+"::TIMESTAMPTZ, "+arg(cursor.ID)+"::UUID)")
+	}
+
+	query := `
+		SELECT t.id, t.reference, t.description, t.status, t.posted_at, t.value_date, t.effective_date,
+		       t.reversal_of_id, t.reversed_by_id, COALESCE(t.reversal_reason, ''), t.metadata
+		FROM transactions t
+		WHERE ` + strings.Join(conds, " AND ") + `
+		ORDER BY t.posted_at DESC, t.id DESC
+		LIMIT ` + arg(limit+1)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch transactions: %w", err)
+	}
+	var transactions []*Transaction
+	for rows.Next() {
+		var t Transaction
+		if err := rows.Scan(&t.ID, &t.Reference, &t.Description, &t.Status, &t.PostedAt, &t.ValueDate, &t.EffectiveDate, &t.ReversalOf, &t.ReversedBy, &t.ReversalReason, &t.Metadata); err != nil {
+			rows.Close()
+			return nil, "", fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		transactions = append(transactions, &t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("failed to fetch transactions: %w", err)
+	}
+
+	var next string
+	if len(transactions) > limit {
+		transactions = transactions[:limit]
+		last := transactions[limit-1]
+		next = common.EncodeCursor(last.PostedAt.Format(time.RFC3339Nano), last.ID)
+	}
+
+	if err := s.loadEntries(transactions); err != nil {
+		return nil, "", err
+	}
+	return transactions, next, nil
+}
+
+// loadEntries fetches the entries of all transactions in one query.
+func (s *Service) loadEntries(transactions []*Transaction) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+	ids := make([]string, len(transactions))
+	byID := make(map[uuid.UUID]*Transaction, len(transactions))
+	for i, t := range transactions {
+		ids[i] = t.ID.String()
+		byID[t.ID] = t
+	}
+
+	rows, err := s.db.Query(`
+		SELECT id, transaction_id, account_id, direction, amount, currency, COALESCE(narrative, ''), counterparty, created_at
+		FROM entries
+		WHERE transaction_id = ANY($1)
+		ORDER BY transaction_id, created_at, id
+	`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to fetch entries: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e Entry
+		if err := rows.Scan(&e.ID, &e.TransactionID, &e.AccountID, &e.Direction, &e.Amount, &e.Currency, &e.Narrative, &e.Counterparty, &e.CreatedAt); err != nil {
+			return fmt.Errorf("failed to scan entry: %w", err)
+		}
+		if t := byID[e.TransactionID]; t != nil {
+			t.Entries = append(t.Entries, e)
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,384 @@
+package ledger
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// categorySystem marks internal accounts (settlement, interest expense, ...).
+// They are not subject to funds checks.
+const categorySystem = "SYSTEM"
+
+// NormalBalance returns the direction that increases an account of the given type.
+func NormalBalance(t AccountType) EntryDirection {
+	switch t {
+	case Liability, Equity, Income:
+		return Credit
+	default:
+		return Debit
+	}
+}
+
+// NaturalBalance converts a stored signed balance (debit +, credit -) into the
+// account's normal direction, so a funded customer deposit (Liability) is positive.
+func NaturalBalance(t AccountType, balance int64) int64 {
+	if NormalBalance(t) == Credit {
+		return -balance
+	}
+	return balance
+}
+
+type rowQuerier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// loadBalance reads the funds view of an account. With lock set the account row is
+// locked as postings lock it (see lockAccounts), so holds and postings against it
+// serialize.
+func loadBalance(q rowQuerier, accountID uuid.UUID, lock bool) (*AccountBalance, string, error) {
+	query := `
+		SELECT a.type, a.currency, a.balance, COALESCE(a.account_category, ''),
+		       COALESCE(a.overdraft_limit, p.overdraft_limit, 0),
+		       COALESCE((
+		           SELECT SUM(h.amount) FROM holds h
+		           WHERE h.account_id = a.id AND h.status = 'ACTIVE'
+		             AND (h.expires_at IS NULL OR h.expires_at > CURRENT_TIMESTAMP)
+		       ), 0)
+		FROM accounts a
+		LEFT JOIN products p ON p.id = a.product_id
+		WHERE a.id = $1
+	`
+	if lock {
+		query += ` FOR NO KEY UPDATE OF a`
+	}
+
+	b := &AccountBalance{AccountID: accountID}
+	var category string
+	err := q.QueryRow(query, accountID).Scan(&b.Type, &b.Currency, &b.LedgerBalance, &category, &b.OverdraftLimit, &b.HeldAmount)
+	if err == sql.ErrNoRows {
+		return nil, "", ErrAccountNotFound
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load account balance: %w", err)
+	}
+	b.Balance = NaturalBalance(b.Type, b.LedgerBalance)
+	b.AvailableBalance = b.Balance - b.HeldAmount
+	return b, category, nil
+}
+
+// GetAvailableBalance returns the ledger balance, active holds, overdraft limit and
+// available balance of an account.
+func (s *Service) GetAvailableBalance(accountID uuid.UUID) (*AccountBalance, error) {
+	b, _, err := loadBalance(s.db, accountID, false)
+	return b, err
+}
+
+// checkFunds rejects postings that leave an account below its overdraft limit.
+// netChange is the signed change the posting made to the stored balance. Only
+// changes against the account's normal direction are checked, so an account that
+// is already overdrawn can always be paid into.
+func checkFunds(tx *sql.Tx, accountID uuid.UUID, netChange int64) error {
+	if netChange == 0 {
+		return nil
+	}
+	b, category, err := loadBalance(tx, accountID, false)
+	if err != nil {
+		return err
+	}
+	if category == categorySystem || NaturalBalance(b.Type, netChange) >= 0 {
+		return nil
+	}
+	if b.AvailableBalance+b.OverdraftLimit < 0 {
+		return fmt.Errorf("%w: account %s would have available balance %d, overdraft limit %d", ErrInsufficientFunds, accountID, b.AvailableBalance, b.OverdraftLimit)
+	}
+	return nil
+}
+
+// CreateHold reserves amount on an account. The hold reduces the available balance
+// until it is captured, released or expires; it fails if the account cannot cover it.
+func (s *Service) CreateHold(accountID uuid.UUID, amount int64, reference, description string, expiresAt *time.Time) (*Hold, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("hold amount must be positive")
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("hold expiry must be in the future")
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	hold, err := placeHold(tx, &Hold{
+		AccountID:   accountID,
+		Amount:      amount,
+		Reference:   reference,
+		Description: description,
+		ExpiresAt:   expiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit hold: %w", err)
+	}
+	return hold, nil
+}
+
+// placeHold inserts an active hold within tx after checking that the account is
+// ACTIVE and can cover it. hold.TransactionID links it to a pending transaction.
+func placeHold(tx *sql.Tx, hold *Hold) (*Hold, error) {
+	// 1. Lock the account so concurrent holds and postings see each other
+	b, category, err := loadBalance(tx, hold.AccountID, true)
+	if err != nil {
+		return nil, err
+	}
+	if category != categorySystem && b.AvailableBalance-hold.Amount+b.OverdraftLimit < 0 {
+		return nil, fmt.Errorf("%w: account %s available %d, overdraft limit %d", ErrInsufficientFunds, hold.AccountID, b.AvailableBalance, b.OverdraftLimit)
+	}
+	var status AccountStatus
+	if err := tx.QueryRow(`SELECT status FROM accounts WHERE id = $1`, hold.AccountID).Scan(&status); err != nil {
+		return nil, fmt.Errorf("failed to load account status: %w", err)
+	}
+	if status != AccountActive {
+		return nil, fmt.Errorf("%w: holds need an ACTIVE account, %s is %s", ErrAccountNotPostable, hold.AccountID, status)
+	}
+
+	// 2. Insert Hold
+	hold.Status = HoldStatusActive
+	query := `
+		INSERT INTO holds (account_id, amount, reference, description, status, expires_at, transaction_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at
+	`
+	err = tx.QueryRow(query, hold.AccountID, hold.Amount, hold.Reference, hold.Description, hold.Status, hold.ExpiresAt, hold.TransactionID).
+		Scan(&hold.ID, &hold.CreatedAt, &hold.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create hold: %w", err)
+	}
+	return hold, nil
+}
+
+// CaptureHold turns a hold into a posting. amount may be less than the hold (0
+// captures it in full); any remainder is given back. The held account moves against
+// its normal balance and counterpartyID takes the other side.
+func (s *Service) CaptureHold(holdID uuid.UUID, amount int64, counterpartyID uuid.UUID, description string) (*Transaction, error) {
+	if amount < 0 {
+		return nil, fmt.Errorf("capture amount must not be negative")
+	}
+
+	var result *Transaction
+	err := s.inTx(func(tx *sql.Tx) error {
+		var err error
+		result, err = s.captureHoldInTx(tx, holdID, amount, counterpartyID, description)
+		return err
+	})
+	return result, err
+}
+
+// captureHoldInTx marks the hold captured and posts the capture within tx.
+func (s *Service) captureHoldInTx(tx *sql.Tx, holdID uuid.UUID, amount int64, counterpartyID uuid.UUID, description string) (*Transaction, error) {
+	// 1. Lock Hold
+	hold, err := lockActiveHold(tx, holdID)
+	if err != nil {
+		return nil, err
+	}
+	if amount == 0 {
+		amount = hold.Amount
+	}
+	if amount > hold.Amount {
+		return nil, fmt.Errorf("capture amount %d exceeds hold amount %d", amount, hold.Amount)
+	}
+
+	// 2. Mark Captured first, so the hold no longer counts against the funds check below
+	_, err = tx.Exec(`UPDATE holds SET status = $1, captured_amount = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $3`,
+		HoldStatusCaptured, amount, holdID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to capture hold: %w", err)
+	}
+
+	// 3. Post Transaction
+	var accType AccountType
+	if err := tx.QueryRow(`SELECT type FROM accounts WHERE id = $1`, hold.AccountID).Scan(&accType); err != nil {
+		return nil, fmt.Errorf("failed to load held account: %w", err)
+	}
+	heldSide, counterSide := Credit, Debit
+	if NormalBalance(accType) == Credit {
+		heldSide, counterSide = Debit, Credit
+	}
+	if description == "" {
+		description = hold.Description
+	}
+	entries := []Entry{
+		{AccountID: hold.AccountID, Direction: heldSide, Amount: amount},
+		{AccountID: counterpartyID, Direction: counterSide, Amount: amount},
+	}
+	if err := validateEntries(entries); err != nil {
+		return nil, err
+	}
+	transaction, err := s.postInTx(tx, &Transaction{
+		Reference:   "CAP-" + holdID.String(),
+		Description: description,
+		Entries:     entries,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(`UPDATE holds SET transaction_id = $1 WHERE id = $2`, transaction.ID, holdID); err != nil {
+		return nil, fmt.Errorf("failed to link hold to transaction: %w", err)
+	}
+
+	return transaction, nil
+}
+
+// ReleaseHold cancels an active hold and gives the funds back to the available balance.
+func (s *Service) ReleaseHold(holdID uuid.UUID) (*Hold, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	hold, err := lockActiveHold(tx, holdID)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.QueryRow(`UPDATE holds SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2 RETURNING updated_at`,
+		HoldStatusReleased, holdID).Scan(&hold.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to release hold: %w", err)
+	}
+	hold.Status = HoldStatusReleased
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit release: %w", err)
+	}
+	return hold, nil
+}
+
+// ExpireHolds marks active holds past their expiry as EXPIRED and returns how many changed.
+// Expired holds already stop counting against the available balance; this makes it visible.
+func (s *Service) ExpireHolds() (int64, error) {
+	res, err := s.db.Exec(`
+		UPDATE holds SET status = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE status = $2 AND expires_at <= CURRENT_TIMESTAMP
+	`, HoldStatusExpired, HoldStatusActive)
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire holds: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// ListHolds returns the holds on an account, newest first. activeOnly limits the
+// result to holds that still reduce the available balance.
+func (s *Service) ListHolds(accountID uuid.UUID, activeOnly bool) ([]*Hold, error) {
+	query := `
+		SELECT id, account_id, amount, reference, description, status, expires_at, captured_amount, transaction_id, created_at, updated_at
+		FROM holds
+		WHERE account_id = $1
+	`
+	if activeOnly {
+		query += ` AND status = 'ACTIVE' AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)`
+	}
+	query += ` ORDER BY created_at DESC`
+
+	rows, err := s.db.Query(query, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list holds: %w", err)
+	}
+	defer rows.Close()
+
+	var holds []*Hold
+	for rows.Next() {
+		h, err := scanHold(rows)
+		if err != nil {
+			return nil, err
+		}
+		holds = append(holds, h)
+	}
+	return holds, rows.Err()
+}
+
+// SetAccountOverdraftLimit sets the account-level overdraft limit. nil clears it,
+// so the account falls back to its product's limit.
+func (s *Service) SetAccountOverdraftLimit(accountID uuid.UUID, limit *int64) error {
+	if limit != nil && *limit < 0 {
+		return fmt.Errorf("overdraft limit must not be negative")
+	}
+	res, err := s.db.Exec(`UPDATE accounts SET overdraft_limit = $1 WHERE id = $2`, limit, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to set overdraft limit: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrAccountNotFound
+	}
+	return nil
+}
+
+// SetProductOverdraftLimit sets the overdraft limit inherited by accounts on a product.
+func (s *Service) SetProductOverdraftLimit(productID uuid.UUID, limit int64) error {
+	if limit < 0 {
+		return fmt.Errorf("overdraft limit must not be negative")
+	}
+	res, err := s.db.Exec(`UPDATE products SET overdraft_limit = $1 WHERE id = $2`, limit, productID)
+	if err != nil {
+		return fmt.Errorf("failed to set overdraft limit: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("product not found")
+	}
+	return nil
+}
+
+// lockActiveHold locks a hold row and checks that it can still be captured or released
+// on its own.
+func lockActiveHold(tx *sql.Tx, holdID uuid.UUID) (*Hold, error) {
+	row := tx.QueryRow(`
+		SELECT id, account_id, amount, reference, description, status, expires_at, captured_amount, transaction_id, created_at, updated_at
+		FROM holds
+		WHERE id = $1
+		FOR UPDATE
+	`, holdID)
+	hold, err := scanHold(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrHoldNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if hold.Status != HoldStatusActive || (hold.ExpiresAt != nil && !hold.ExpiresAt.After(time.Now())) {
+		return nil, fmt.Errorf("%w: hold %s is %s", ErrHoldNotActive, holdID, hold.Status)
+	}
+	// An active hold with a transaction belongs to a pending transaction, which
+	// settles it when it is posted or voided
+	if hold.TransactionID != nil {
+		return nil, fmt.Errorf("%w: hold %s belongs to pending transaction %s", ErrHoldNotActive, holdID, hold.TransactionID)
+	}
+	return hold, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanHold(row rowScanner) (*Hold, error) {
+	var h Hold
+	var reference, description sql.NullString
+	err := row.Scan(&h.ID, &h.AccountID, &h.Amount, &reference, &description, &h.Status, &h.ExpiresAt,
+		&h.CapturedAmount, &h.TransactionID, &h.CreatedAt, &h.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan hold: %w", err)
+	}
+	h.Reference = reference.String
+	h.Description = description.String
+	return &h, nil
+}
@@ -0,0 +1,413 @@
+package ledger
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ImportMode decides what happens to the rest of an import when a transaction fails.
+type ImportMode string
+
+const (
+	ImportAtomic     ImportMode = "ATOMIC"      // Post everything or nothing
+	ImportBestEffort ImportMode = "BEST_EFFORT" // Post every transaction that is valid
+)
+
+// Import result statuses of a transaction.
+const (
+	ImportPosted  = "POSTED"
+	ImportValid   = "VALID" // Dry run: would have been posted
+	ImportFailed  = "FAILED"
+	ImportSkipped = "SKIPPED" // Atomic import aborted by another transaction's failure
+)
+
+// ImportLine is one journal line of an import file. Lines sharing a reference form
+// one transaction; its description and dates are taken from its first line.
+type ImportLine struct {
+	Line          int            `json:"line"` // 1-based data line (CSV header excluded) or array index + 1
+	Reference     string         `json:"reference"`
+	Description   string         `json:"description"`
+	AccountID     uuid.UUID      `json:"account_id"`
+	Direction     EntryDirection `json:"direction"`
+	Amount        int64          `json:"amount"`
+	ValueDate     time.Time      `json:"value_date"`
+	EffectiveDate time.Time      `json:"effective_date"`
+	Err           string         `json:"-"` // Field error found while parsing
+}
+
+// ImportOptions control how an import is posted.
+type ImportOptions struct {
+	Mode   ImportMode
+	DryRun bool // Validate and post in a transaction that is always rolled back
+}
+
+// ImportError reports a problem with one line, or with a whole transaction (Line 0).
+type ImportError struct {
+	Line      int    `json:"line"`
+	Reference string `json:"reference"`
+	Message   string `json:"message"`
+}
+
+// ImportResult is the outcome of one transaction of an import.
+type ImportResult struct {
+	Reference     string     `json:"reference"`
+	Lines         []int      `json:"lines"`
+	Status        string     `json:"status"`
+	TransactionID *uuid.UUID `json:"transaction_id,omitempty"`
+	Error         string     `json:"error,omitempty"`
+}
+
+// ImportReport is the outcome of an import.
+type ImportReport struct {
+	Mode         ImportMode     `json:"mode"`
+	DryRun       bool           `json:"dry_run"`
+	Lines        int            `json:"lines"`
+	Transactions int            `json:"transactions"`
+	Posted       int            `json:"posted"` // Dry run: would have been posted
+	Failed       int            `json:"failed"`
+	Results      []ImportResult `json:"results"`
+	Errors       []ImportError  `json:"errors"`
+}
+
+// importGroup is one transaction of an import and its result.
+type importGroup struct {
+	req     PostingRequest
+	result  *ImportResult
+	postErr error // Set when posting, rather than validation, failed
+}
+
+var requiredImportColumns = []string{"reference", "description", "account_id", "direction", "amount"}
+
+// ParseImportCSV reads journal lines from CSV with a header row naming the columns
+// reference, description, account_id, direction, amount (minor units) and the
+// optional value_date and effective_date (YYYY-MM-DD). Field errors are kept on
+// their line; only an unreadable file is an error.
+func ParseImportCSV(r io.Reader) ([]ImportLine, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: empty file", ErrInvalidImport)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+	col := make(map[string]int, len(header))
+	for i, h := range header {
+		col[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	for _, c := range requiredImportColumns {
+		if _, ok := col[c]; !ok {
+			return nil, fmt.Errorf("%w: missing column %q", ErrInvalidImport, c)
+		}
+	}
+
+	var lines []ImportLine
+	for n := 1; ; n++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+		}
+		field := func(name string) string {
+			if i, ok := col[name]; ok && i < len(rec) {
+				return strings.TrimSpace(rec[i])
+			}
+			return ""
+		}
+		lines = append(lines, parseImportLine(n, field))
+	}
+	return lines, nil
+}
+
+// ParseImportJSON reads journal lines from a JSON array of objects with the same
+// fields as the CSV format. The amount may be a number or a string.
+func ParseImportJSON(r io.Reader) ([]ImportLine, error) {
+	var raw []map[string]any
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+	lines := make([]ImportLine, 0, len(raw))
+	for i, obj := range raw {
+		field := func(name string) string {
+			switch v := obj[name].(type) {
+			case string:
+				return strings.TrimSpace(v)
+			case float64:
+				return strconv.FormatFloat(v, 'f', -1, 64)
+			}
+			return ""
+		}
+		lines = append(lines, parseImportLine(i+1, field))
+	}
+	return lines, nil
+}
+
+func parseImportLine(n int, field func(string) string) ImportLine {
+	l := ImportLine{
+		Line:        n,
+		Reference:   field("reference"),
+		Description: field("description"),
+		Direction:   EntryDirection(strings.ToUpper(field("direction"))),
+	}
+	var problems []string
+	if l.Reference == "" {
+		problems = append(problems, "reference is required")
+	}
+	var err error
+	if l.AccountID, err = uuid.Parse(field("account_id")); err != nil {
+		problems = append(problems, "invalid account_id")
+	}
+	if l.Direction != Debit && l.Direction != Credit {
+		problems = append(problems, "direction must be DEBIT or CREDIT")
+	}
+	if l.Amount, err = strconv.ParseInt(field("amount"), 10, 64); err != nil || l.Amount <= 0 {
+		problems = append(problems, "amount must be a positive integer in minor units")
+	}
+	for _, d := range []struct {
+		name string
+		dst  *time.Time
+	}{{"value_date", &l.ValueDate}, {"effective_date", &l.EffectiveDate}} {
+		if v := field(d.name); v != "" {
+			if *d.dst, err = time.Parse("2006-01-02", v); err != nil {
+				problems = append(problems, "invalid "+d.name)
+			}
+		}
+	}
+	l.Err = strings.Join(problems, "; ")
+	return l
+}
+
+// groupImportLines groups lines into transactions by reference, in order of first
+// appearance, and checks each group without touching the database. Groups with
+// errors have a FAILED result; the errors are returned per line.
+func groupImportLines(lines []ImportLine) ([]*importGroup, []ImportError) {
+	var groups []*importGroup
+	byRef := make(map[string]*importGroup)
+	var errs []ImportError
+	for _, l := range lines {
+		g := byRef[l.Reference]
+		if g == nil {
+			g = &importGroup{
+				req: PostingRequest{
+					Reference:     l.Reference,
+					Description:   l.Description,
+					ValueDate:     l.ValueDate,
+					EffectiveDate: l.EffectiveDate,
+				},
+				result: &ImportResult{Reference: l.Reference},
+			}
+			byRef[l.Reference] = g
+			groups = append(groups, g)
+		}
+		g.result.Lines = append(g.result.Lines, l.Line)
+
+		msg := l.Err
+		if msg == "" && (!l.ValueDate.IsZero() && !l.ValueDate.Equal(g.req.ValueDate) ||
+			!l.EffectiveDate.IsZero() && !l.EffectiveDate.Equal(g.req.EffectiveDate)) {
+			msg = "dates differ from the first line of the transaction"
+		}
+		if msg != "" {
+			errs = append(errs, ImportError{Line: l.Line, Reference: l.Reference, Message: msg})
+			g.result.Status, g.result.Error = ImportFailed, "invalid lines"
+			continue
+		}
+		g.req.Entries = append(g.req.Entries, Entry{AccountID: l.AccountID, Direction: l.Direction, Amount: l.Amount})
+	}
+
+	for _, g := range groups {
+		if g.result.Status == ImportFailed || g.req.Reference == "" {
+			continue
+		}
+		var err error
+		switch {
+		case g.req.Description == "":
+			err = errors.New("description is required")
+		case len(g.req.Entries) < 2:
+			err = errors.New("a transaction needs at least two lines")
+		default:
+			err = validateEntries(g.req.Entries)
+		}
+		if err != nil {
+			g.result.Status, g.result.Error = ImportFailed, err.Error()
+			errs = append(errs, ImportError{Reference: g.req.Reference, Message: err.Error()})
+		}
+	}
+	return groups, errs
+}
+
+func (g *importGroup) postFailed(err error) {
+	g.postErr = err
+	g.result.Status, g.result.Error = ImportFailed, err.Error()
+}
+
+// ImportTransactions validates journal lines and posts them as transactions grouped
+// by reference. Atomic imports and dry runs post inside one database transaction,
+// each ledger transaction under its own savepoint so every failure is reported; it
+// is committed only by a live import without failures. Live best-effort imports
+// post each transaction through Post. progress, if set, is called after each
+// transaction.
+func (s *Service) ImportTransactions(lines []ImportLine, opts ImportOptions, progress func(done, total int)) (*ImportReport, error) {
+	if opts.Mode == "" {
+		opts.Mode = ImportAtomic
+	}
+	if opts.Mode != ImportAtomic && opts.Mode != ImportBestEffort {
+		return nil, fmt.Errorf("%w: unknown mode %q", ErrInvalidImport, opts.Mode)
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("%w: no lines", ErrInvalidImport)
+	}
+
+	groups, errs := groupImportLines(lines)
+	report := &ImportReport{Mode: opts.Mode, DryRun: opts.DryRun, Lines: len(lines), Transactions: len(groups), Errors: errs}
+	done := 0
+	step := func() {
+		done++
+		if progress != nil {
+			progress(done, len(groups))
+		}
+	}
+
+	if opts.Mode == ImportBestEffort && !opts.DryRun {
+		for _, g := range groups {
+			if g.result.Status != ImportFailed {
+				if t, err := s.Post(g.req); err != nil {
+					g.postFailed(err)
+				} else {
+					g.result.Status, g.result.TransactionID = ImportPosted, &t.ID
+				}
+			}
+			step()
+		}
+	} else if err := s.importInTx(groups, opts, step); err != nil {
+		return nil, err
+	}
+
+	for _, g := range groups {
+		switch g.result.Status {
+		case ImportFailed:
+			report.Failed++
+		case ImportPosted, ImportValid:
+			report.Posted++
+		}
+		if g.postErr != nil {
+			report.Errors = append(report.Errors, ImportError{Reference: g.req.Reference, Message: g.postErr.Error()})
+		}
+		report.Results = append(report.Results, *g.result)
+	}
+	return report, nil
+}
+
+// importInTx posts the valid groups in one database transaction and commits it only
+// for a live import where nothing failed. The accounts of all groups are locked
+// before the first is posted and stay locked until the import ends.
+func (s *Service) importInTx(groups []*importGroup, opts ImportOptions, step func()) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var accountIDs []uuid.UUID
+	for _, g := range groups {
+		if g.result.Status == ImportFailed {
+			continue
+		}
+		for _, e := range g.req.Entries {
+			accountIDs = append(accountIDs, e.AccountID)
+		}
+	}
+	if err := lockAllAccounts(tx, accountIDs); err != nil {
+		return err
+	}
+
+	failed := false
+	for _, g := range groups {
+		if g.result.Status == ImportFailed {
+			failed = true
+			step()
+			continue
+		}
+		if _, err := tx.Exec(`SAVEPOINT import_txn`); err != nil {
+			return fmt.Errorf("failed to create savepoint: %w", err)
+		}
+		t, err := s.postRequestInTx(tx, g.req)
+		if err != nil {
+			if _, rbErr := tx.Exec(`ROLLBACK TO SAVEPOINT import_txn`); rbErr != nil {
+				return fmt.Errorf("failed to roll back to savepoint: %w", rbErr)
+			}
+			g.postFailed(err)
+			failed = true
+		} else {
+			if _, err := tx.Exec(`RELEASE SAVEPOINT import_txn`); err != nil {
+				return fmt.Errorf("failed to release savepoint: %w", err)
+			}
+			g.result.Status = ImportValid
+			if !opts.DryRun {
+				g.result.Status, g.result.TransactionID = ImportPosted, &t.ID
+			}
+		}
+		step()
+	}
+
+	if opts.DryRun {
+		return nil
+	}
+	if failed {
+		// Atomic: nothing is posted when anything failed
+		for _, g := range groups {
+			if g.result.Status != ImportFailed {
+				g.result.Status, g.result.TransactionID = ImportSkipped, nil
+			}
+		}
+		return nil
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit import: %w", err)
+	}
+	return nil
+}
+
+// SaveImportReport stores the report of an import run as batch batchID.
+func (s *Service) SaveImportReport(batchID uuid.UUID, fileName, format string, report *ImportReport) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal import report: %w", err)
+	}
+	_, err = s.db.Exec(`
+		INSERT INTO transaction_imports (batch_id, file_name, format, mode, dry_run, transactions, posted, failed, report)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, batchID, fileName, format, report.Mode, report.DryRun, report.Transactions, report.Posted, report.Failed, body)
+	if err != nil {
+		return fmt.Errorf("failed to store import report: %w", err)
+	}
+	return nil
+}
+
+// GetImportReport returns the stored report of the import run as batch batchID.
+func (s *Service) GetImportReport(batchID uuid.UUID) (*ImportReport, error) {
+	var body []byte
+	err := s.db.QueryRow(`SELECT report FROM transaction_imports WHERE batch_id = $1`, batchID).Scan(&body)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrImportNotFound, batchID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load import report: %w", err)
+	}
+	var r ImportReport
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, fmt.Errorf("failed to decode import report: %w", err)
+	}
+	return &r, nil
+}
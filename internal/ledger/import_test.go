@@ -0,0 +1,164 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestParseImportCSV(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+	file := fmt.Sprintf(`reference,description,account_id,direction,amount,value_date
+REF-1,Payroll,%s,debit,1000,2025-01-15
+REF-1,Payroll,%s,CREDIT,1000,
+REF-2,Bad,not-a-uuid,SIDEWAYS,-5,15/01/2025
+`, a, b)
+	lines, err := ParseImportCSV(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("ParseImportCSV failed: %v", err)
+	}
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %d", len(lines))
+	}
+	if l := lines[0]; l.Err != "" || l.AccountID != a || l.Direction != Debit || l.Amount != 1000 ||
+		!l.ValueDate.Equal(time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected first line %+v", l)
+	}
+	for _, want := range []string{"account_id", "direction", "amount", "value_date"} {
+		if !strings.Contains(lines[2].Err, want) {
+			t.Errorf("Expected line 3 error to mention %s, got %q", want, lines[2].Err)
+		}
+	}
+
+	if _, err := ParseImportCSV(strings.NewReader("reference,amount\nX,1\n")); !errors.Is(err, ErrInvalidImport) {
+		t.Errorf("Expected ErrInvalidImport for missing columns, got %v", err)
+	}
+}
+
+func TestParseImportJSON(t *testing.T) {
+	file := fmt.Sprintf(`[{"reference":"J-1","description":"Fee","account_id":"%s","direction":"DEBIT","amount":250}]`, uuid.New())
+	lines, err := ParseImportJSON(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("ParseImportJSON failed: %v", err)
+	}
+	if len(lines) != 1 || lines[0].Err != "" || lines[0].Amount != 250 {
+		t.Errorf("Unexpected lines %+v", lines)
+	}
+	if _, err := ParseImportJSON(strings.NewReader(`{"not":"an array"}`)); !errors.Is(err, ErrInvalidImport) {
+		t.Errorf("Expected ErrInvalidImport, got %v", err)
+	}
+}
+
+func TestGroupImportLines(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+	line := func(n int, ref string, dir EntryDirection, amount int64) ImportLine {
+		return ImportLine{Line: n, Reference: ref, Description: "Import", AccountID: a, Direction: dir, Amount: amount}
+	}
+	lines := []ImportLine{
+		line(1, "OK", Debit, 100),
+		line(2, "UNBALANCED", Debit, 100),
+		line(3, "OK", Credit, 100),
+		line(4, "UNBALANCED", Credit, 90),
+		line(5, "SINGLE", Debit, 10),
+		{Line: 6, Reference: "BAD", Description: "Import", AccountID: b, Err: "invalid account_id"},
+		line(7, "BAD", Credit, 5),
+	}
+	groups, errs := groupImportLines(lines)
+	if len(groups) != 4 {
+		t.Fatalf("Expected 4 transactions, got %d", len(groups))
+	}
+	if groups[0].req.Reference != "OK" || len(groups[0].req.Entries) != 2 || groups[0].result.Status != "" {
+		t.Errorf("Expected OK to be a valid two-line transaction, got %+v", groups[0].result)
+	}
+	if got := groups[0].result.Lines; len(got) != 2 || got[0] != 1 || got[1] != 3 {
+		t.Errorf("Expected OK from lines 1 and 3, got %v", got)
+	}
+	for _, g := range groups[1:] {
+		if g.result.Status != ImportFailed {
+			t.Errorf("Expected %s to fail, got %q", g.req.Reference, g.result.Status)
+		}
+	}
+	// One line error and two transaction errors
+	if len(errs) != 3 {
+		t.Fatalf("Expected 3 errors, got %+v", errs)
+	}
+	if errs[0].Line != 6 || errs[0].Reference != "BAD" {
+		t.Errorf("Expected the line error first, got %+v", errs[0])
+	}
+}
+
+func TestImportTransactions(t *testing.T) {
+	db, err := connectDB()
+	if err != nil {
+		t.Skip("Skipping test: could not connect to database")
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		t.Skip("Skipping test: database not reachable")
+	}
+
+	service := NewService(db)
+
+	cash, err := service.GetOrCreateSystemAccount("Cash In", Asset, "USD")
+	if err != nil {
+		t.Fatalf("Failed to get cash account: %v", err)
+	}
+	acc, err := service.CreateAccount("Import Account", Liability, "USD", "CASH", "INDIVIDUAL", nil)
+	if err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+	prefix := fmt.Sprintf("IMP-%d-", time.Now().UnixNano())
+	file := fmt.Sprintf(`reference,description,account_id,direction,amount
+%[1]s1,Import,%[2]s,DEBIT,500
+%[1]s1,Import,%[3]s,CREDIT,500
+%[1]s2,Import,%[2]s,DEBIT,300
+%[1]s2,Import,%[4]s,CREDIT,300
+`, prefix, cash, acc.ID, uuid.New()) // The second transaction posts to a missing account
+	lines, err := ParseImportCSV(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("ParseImportCSV failed: %v", err)
+	}
+
+	balance := func() int64 {
+		a, err := service.GetAccount(acc.ID)
+		if err != nil {
+			t.Fatalf("GetAccount failed: %v", err)
+		}
+		return a.Balance
+	}
+
+	for _, opts := range []ImportOptions{
+		{Mode: ImportAtomic},
+		{Mode: ImportBestEffort, DryRun: true},
+	} {
+		report, err := service.ImportTransactions(lines, opts, nil)
+		if err != nil {
+			t.Fatalf("ImportTransactions(%+v) failed: %v", opts, err)
+		}
+		if report.Failed != 1 || len(report.Errors) != 1 {
+			t.Errorf("%+v: expected one failure, got %+v", opts, report)
+		}
+		if b := balance(); b != 0 {
+			t.Errorf("%+v: expected nothing posted, balance is %d", opts, b)
+		}
+	}
+
+	var done int
+	report, err := service.ImportTransactions(lines, ImportOptions{Mode: ImportBestEffort}, func(d, total int) { done = d })
+	if err != nil {
+		t.Fatalf("Best-effort import failed: %v", err)
+	}
+	if report.Posted != 1 || report.Results[0].Status != ImportPosted || report.Results[0].TransactionID == nil {
+		t.Errorf("Expected the first transaction posted, got %+v", report.Results)
+	}
+	if done != 2 {
+		t.Errorf("Expected progress for 2 transactions, got %d", done)
+	}
+	if b := balance(); b != -500 {
+		t.Errorf("Expected balance -500 after best-effort import, got %d", b)
+	}
+}
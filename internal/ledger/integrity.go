@@ -0,0 +1,326 @@
+package ledger
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SystemActorID is recorded as the actor of changes made by the system itself
+// (batch jobs, repairs) rather than by a user.
+var SystemActorID = uuid.Nil
+
+// Orphan entry reasons. Foreign keys normally prevent the first two; they show up
+// after restores or manual edits that bypassed constraints.
+const (
+	OrphanMissingTransaction = "MISSING_TRANSACTION"
+	OrphanMissingAccount     = "MISSING_ACCOUNT"
+	OrphanCurrencyMismatch   = "CURRENCY_MISMATCH"
+)
+
+// BalanceDrift is an account whose cached balance differs from the sum of its entries.
+type BalanceDrift struct {
+	AccountID       uuid.UUID `json:"account_id"`
+	Name            string    `json:"name"`
+	Currency        string    `json:"currency"`
+	CachedBalance   int64     `json:"cached_balance"`
+	ComputedBalance int64     `json:"computed_balance"`
+	Drift           int64     `json:"drift"` // Cached minus computed
+	Repaired        bool      `json:"repaired"`
+}
+
+// UnbalancedTransaction is a transaction whose debits and credits differ in a currency.
+type UnbalancedTransaction struct {
+	TransactionID uuid.UUID `json:"transaction_id"`
+	Reference     string    `json:"reference"`
+	Currency      string    `json:"currency"`
+	Debits        int64     `json:"debits"`
+	Credits       int64     `json:"credits"`
+}
+
+// OrphanEntry is an entry that cannot be attributed to a valid transaction and account.
+type OrphanEntry struct {
+	EntryID       uuid.UUID `json:"entry_id"`
+	TransactionID uuid.UUID `json:"transaction_id"`
+	AccountID     uuid.UUID `json:"account_id"`
+	Reason        string    `json:"reason"`
+}
+
+// IntegrityReport is the outcome of one ledger verification run.
+type IntegrityReport struct {
+	ID                     uuid.UUID               `json:"id"`
+	StartedAt              time.Time               `json:"started_at"`
+	FinishedAt             time.Time               `json:"finished_at"`
+	Repair                 bool                    `json:"repair"`
+	AccountsChecked        int                     `json:"accounts_checked"`
+	Drifts                 []BalanceDrift          `json:"drifts"`
+	UnbalancedTransactions []UnbalancedTransaction `json:"unbalanced_transactions"`
+	OrphanEntries          []OrphanEntry           `json:"orphan_entries"`
+	Repaired               int                     `json:"repaired"`
+	OK                     bool                    `json:"ok"` // No discrepancies were found
+}
+
+// Unresolved counts the discrepancies still present after the run. Only balance
+// drift is repaired automatically; the rest needs a correcting posting or manual fix.
+func (r *IntegrityReport) Unresolved() int {
+	return len(r.Drifts) - r.Repaired + len(r.UnbalancedTransactions) + len(r.OrphanEntries)
+}
+
+// VerifyIntegrity recomputes every account balance from its entries and looks for
+// unbalanced transactions and orphan entries, all within one consistent snapshot.
+// With repair set, drifted balances are reset to the sum of their entries and each
+// change is written to audit_logs. The report is stored in integrity_reports.
+func (s *Service) VerifyIntegrity(repair bool) (*IntegrityReport, error) {
+	report := &IntegrityReport{
+		ID:                     uuid.New(),
+		StartedAt:              time.Now(),
+		Repair:                 repair,
+		Drifts:                 []BalanceDrift{},
+		UnbalancedTransactions: []UnbalancedTransaction{},
+		OrphanEntries:          []OrphanEntry{},
+	}
+
+	if err := s.scanIntegrity(report); err != nil {
+		return nil, err
+	}
+	report.OK = len(report.Drifts) == 0 && len(report.UnbalancedTransactions) == 0 && len(report.OrphanEntries) == 0
+
+	if repair {
+		for i := range report.Drifts {
+			repaired, err := s.repairBalance(report.ID, &report.Drifts[i])
+			if err != nil {
+				return nil, err
+			}
+			if repaired {
+				report.Repaired++
+			}
+		}
+	}
+
+	report.FinishedAt = time.Now()
+	if err := s.saveIntegrityReport(report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (s *Service) scanIntegrity(report *IntegrityReport) error {
+	tx, err := s.db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM accounts`).Scan(&report.AccountsChecked); err != nil {
+		return fmt.Errorf("failed to count accounts: %w", err)
+	}
+
+	// Balance drift
+	rows, err := tx.Query(`
+		SELECT a.id, a.name, a.currency, a.balance, COALESCE(e.computed, 0)
+		FROM accounts a
+		LEFT JOIN (
+			SELECT account_id, SUM(CASE WHEN direction = 'DEBIT' THEN amount ELSE -amount END) AS computed
+			FROM entries
+			GROUP BY account_id
+		) e ON e.account_id = a.id
+		WHERE a.balance <> COALESCE(e.computed, 0)
+		ORDER BY a.id
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to recompute balances: %w", err)
+	}
+	for rows.Next() {
+		var d BalanceDrift
+		if err := rows.Scan(&d.AccountID, &d.Name, &d.Currency, &d.CachedBalance, &d.ComputedBalance); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan balance drift: %w", err)
+		}
+		d.Drift = d.CachedBalance - d.ComputedBalance
+		report.Drifts = append(report.Drifts, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to recompute balances: %w", err)
+	}
+
+	// Unbalanced transactions, per currency
+	rows, err = tx.Query(`
+		SELECT t.id, t.reference, e.currency,
+		       COALESCE(SUM(CASE WHEN e.direction = 'DEBIT' THEN e.amount END), 0),
+		       COALESCE(SUM(CASE WHEN e.direction = 'CREDIT' THEN e.amount END), 0)
+		FROM transactions t
+		JOIN entries e ON e.transaction_id = t.id
+		GROUP BY t.id, t.reference, e.currency
+		HAVING SUM(CASE WHEN e.direction = 'DEBIT' THEN e.amount ELSE -e.amount END) <> 0
+		ORDER BY t.id, e.currency
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to check transaction balances: %w", err)
+	}
+	for rows.Next() {
+		var u UnbalancedTransaction
+		if err := rows.Scan(&u.TransactionID, &u.Reference, &u.Currency, &u.Debits, &u.Credits); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan unbalanced transaction: %w", err)
+		}
+		report.UnbalancedTransactions = append(report.UnbalancedTransactions, u)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to check transaction balances: %w", err)
+	}
+
+	// Orphan entries
+	rows, err = tx.Query(`
+		SELECT e.id, e.transaction_id, e.account_id,
+		       CASE WHEN t.id IS NULL THEN $1::TEXT
+		            WHEN a.id IS NULL THEN $2::TEXT
+		            ELSE $3::TEXT END
+		FROM entries e
+		LEFT JOIN transactions t ON t.id = e.transaction_id
+		LEFT JOIN accounts a ON a.id = e.account_id
+		WHERE t.id IS NULL OR a.id IS NULL OR e.currency <> a.currency
+		ORDER BY e.id
+	`, OrphanMissingTransaction, OrphanMissingAccount, OrphanCurrencyMismatch)
+	if err != nil {
+		return fmt.Errorf("failed to find orphan entries: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var o OrphanEntry
+		if err := rows.Scan(&o.EntryID, &o.TransactionID, &o.AccountID, &o.Reason); err != nil {
+			return fmt.Errorf("failed to scan orphan entry: %w", err)
+		}
+		report.OrphanEntries = append(report.OrphanEntries, o)
+	}
+	return rows.Err()
+}
+
+// repairBalance resets a drifted balance to the sum of its entries. The drift is
+// re-checked under the account lock, since postings may have landed since the scan.
+func (s *Service) repairBalance(reportID uuid.UUID, d *BalanceDrift) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var cached int64
+	if err := tx.QueryRow(`SELECT balance FROM accounts WHERE id = $1 FOR UPDATE`, d.AccountID).Scan(&cached); err != nil {
+		return false, fmt.Errorf("failed to lock account %s: %w", d.AccountID, err)
+	}
+	var computed int64
+	err = tx.QueryRow(`
+		SELECT COALESCE(SUM(CASE WHEN direction = 'DEBIT' THEN amount ELSE -amount END), 0)
+		FROM entries WHERE account_id = $1
+	`, d.AccountID).Scan(&computed)
+	if err != nil {
+		return false, fmt.Errorf("failed to recompute balance of %s: %w", d.AccountID, err)
+	}
+	if cached == computed {
+		return false, nil
+	}
+
+	if _, err := tx.Exec(`UPDATE accounts SET balance = $1 WHERE id = $2`, computed, d.AccountID); err != nil {
+		return false, fmt.Errorf("failed to repair balance of %s: %w", d.AccountID, err)
+	}
+
+	changes, err := json.Marshal(map[string]any{
+		"balance":             map[string]int64{"old": cached, "new": computed},
+		"integrity_report_id": reportID,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal audit changes: %w", err)
+	}
+	_, err = tx.Exec(`
+		INSERT INTO audit_logs (entity_name, entity_id, action, actor_id, changes)
+		VALUES ('ACCOUNT', $1, 'BALANCE_REPAIR', $2, $3)
+	`, d.AccountID, SystemActorID, changes)
+	if err != nil {
+		return false, fmt.Errorf("failed to write audit log: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	d.CachedBalance, d.ComputedBalance, d.Drift, d.Repaired = cached, computed, cached-computed, true
+	return true, nil
+}
+
+func (s *Service) saveIntegrityReport(r *IntegrityReport) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal integrity report: %w", err)
+	}
+	_, err = s.db.Exec(`
+		INSERT INTO integrity_reports (id, started_at, finished_at, repair, accounts_checked,
+		                               drift_count, unbalanced_count, orphan_count, repaired_count, report)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, r.ID, r.StartedAt, r.FinishedAt, r.Repair, r.AccountsChecked,
+		len(r.Drifts), len(r.UnbalancedTransactions), len(r.OrphanEntries), r.Repaired, body)
+	if err != nil {
+		return fmt.Errorf("failed to store integrity report: %w", err)
+	}
+	return nil
+}
+
+// GetIntegrityReport returns a stored report, or nil if it does not exist.
+func (s *Service) GetIntegrityReport(id uuid.UUID) (*IntegrityReport, error) {
+	var body []byte
+	err := s.db.QueryRow(`SELECT report FROM integrity_reports WHERE id = $1`, id).Scan(&body)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load integrity report: %w", err)
+	}
+	var r IntegrityReport
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, fmt.Errorf("failed to decode integrity report: %w", err)
+	}
+	return &r, nil
+}
+
+// IntegrityRun summarises a stored report without its discrepancy lists.
+type IntegrityRun struct {
+	ID              uuid.UUID `json:"id"`
+	StartedAt       time.Time `json:"started_at"`
+	FinishedAt      time.Time `json:"finished_at"`
+	Repair          bool      `json:"repair"`
+	AccountsChecked int       `json:"accounts_checked"`
+	DriftCount      int       `json:"drift_count"`
+	UnbalancedCount int       `json:"unbalanced_count"`
+	OrphanCount     int       `json:"orphan_count"`
+	RepairedCount   int       `json:"repaired_count"`
+}
+
+// ListIntegrityRuns returns the most recent verification runs, newest first.
+func (s *Service) ListIntegrityRuns(limit int) ([]IntegrityRun, error) {
+	rows, err := s.db.Query(`
+		SELECT id, started_at, finished_at, repair, accounts_checked,
+		       drift_count, unbalanced_count, orphan_count, repaired_count
+		FROM integrity_reports
+		ORDER BY started_at DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list integrity runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []IntegrityRun{}
+	for rows.Next() {
+		var r IntegrityRun
+		if err := rows.Scan(&r.ID, &r.StartedAt, &r.FinishedAt, &r.Repair, &r.AccountsChecked,
+			&r.DriftCount, &r.UnbalancedCount, &r.OrphanCount, &r.RepairedCount); err != nil {
+			return nil, fmt.Errorf("failed to scan integrity run: %w", err)
+		}
+		runs = append(runs, r)
+	}
+	return runs, rows.Err()
+}
@@ -0,0 +1,346 @@
+package ledger
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DayCountConvention decides how many days a period counts for and how many days
+// a year has when turning an annual rate into interest for the period.
+type DayCountConvention string
+
+const (
+	DayCountAct365 DayCountConvention = "ACT/365" // Actual days over a 365-day year
+	DayCountAct360 DayCountConvention = "ACT/360" // Actual days over a 360-day year
+	DayCount30360  DayCountConvention = "30/360"  // 30-day months over a 360-day year
+)
+
+// TierMode decides how balance tiers apply.
+type TierMode string
+
+const (
+	TierBanded TierMode = "BANDED" // Each slice of the balance earns its own tier's rate
+	TierWhole  TierMode = "WHOLE"  // The whole balance earns the rate of the highest tier it reaches
+)
+
+// RoundingMode decides how accrued interest is rounded to minor units. The
+// difference is carried forward to the next accrual, so rounding only moves
+// interest between days and none is lost.
+type RoundingMode string
+
+const (
+	RoundDown     RoundingMode = "DOWN" // Towards zero
+	RoundUp       RoundingMode = "UP"   // Away from zero
+	RoundHalfUp   RoundingMode = "HALF_UP"
+	RoundHalfEven RoundingMode = "HALF_EVEN" // Banker's rounding
+)
+
+// InterestTier is a balance band starting at MinBalance (minor units) that earns RateBPS.
+type InterestTier struct {
+	MinBalance int64 `json:"min_balance"`
+	RateBPS    int64 `json:"rate_bps"`
+}
+
+// InterestTiers are stored as a JSONB array; no tiers are stored as NULL.
+type InterestTiers []InterestTier
+
+// Value implements driver.Valuer.
+func (t InterestTiers) Value() (driver.Value, error) {
+	if len(t) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(t)
+}
+
+// Scan implements sql.Scanner.
+func (t *InterestTiers) Scan(src any) error {
+	*t = nil
+	b, ok := src.([]byte)
+	if src == nil || ok && len(b) == 0 {
+		return nil
+	}
+	if !ok {
+		return fmt.Errorf("cannot scan %T into InterestTiers", src)
+	}
+	return json.Unmarshal(b, t)
+}
+
+// InterestTerms are a product's interest conventions. Credit positions earn the
+// product's InterestRateBPS, or the tiers when set; debit positions (overdrawn
+// deposits, loans) are charged DebitRateBPS, and the part of an overdraft beyond the
+// arranged limit PenaltyRateBPS when set.
+type InterestTerms struct {
+	DayCount   DayCountConvention `json:"day_count_convention"`
+	TierMode   TierMode           `json:"tier_mode"`
+	Tiers      InterestTiers      `json:"interest_tiers,omitempty"`
+	MinBalance int64              `json:"min_interest_balance"` // Balances below it earn nothing
+	Rounding   RoundingMode       `json:"interest_rounding"`
+
+	Capitalization CapitalizationFrequency `json:"capitalization_frequency"` // When accrued interest is paid to the account
+
+	DebitRateBPS   int64 `json:"debit_interest_rate_bps"`
+	PenaltyRateBPS int64 `json:"overdraft_penalty_rate_bps"` // Above the arranged overdraft limit
+}
+
+// DefaultInterestTerms are the terms of a new product.
+var DefaultInterestTerms = InterestTerms{DayCount: DayCountAct365, TierMode: TierBanded, Rounding: RoundDown, Capitalization: CapitalizeMonthly}
+
+func validateInterestTerms(t InterestTerms) error {
+	switch t.DayCount {
+	case DayCountAct365, DayCountAct360, DayCount30360:
+	default:
+		return fmt.Errorf("%w: unknown day count convention %q", ErrInvalidInterestTerms, t.DayCount)
+	}
+	if t.TierMode != TierBanded && t.TierMode != TierWhole {
+		return fmt.Errorf("%w: tier mode must be BANDED or WHOLE", ErrInvalidInterestTerms)
+	}
+	switch t.Rounding {
+	case RoundDown, RoundUp, RoundHalfUp, RoundHalfEven:
+	default:
+		return fmt.Errorf("%w: unknown rounding mode %q", ErrInvalidInterestTerms, t.Rounding)
+	}
+	switch t.Capitalization {
+	case CapitalizeDaily, CapitalizeMonthly, CapitalizeQuarterly, CapitalizeAnnually:
+	default:
+		return fmt.Errorf("%w: unknown capitalization frequency %q", ErrInvalidInterestTerms, t.Capitalization)
+	}
+	if t.MinBalance < 0 {
+		return fmt.Errorf("%w: minimum balance must not be negative", ErrInvalidInterestTerms)
+	}
+	if t.DebitRateBPS < 0 || t.PenaltyRateBPS < 0 {
+		return fmt.Errorf("%w: debit and penalty rates must not be negative", ErrInvalidInterestTerms)
+	}
+	for i, tier := range t.Tiers {
+		if tier.MinBalance < 0 || tier.RateBPS < 0 {
+			return fmt.Errorf("%w: tier balances and rates must not be negative", ErrInvalidInterestTerms)
+		}
+		if i > 0 && tier.MinBalance <= t.Tiers[i-1].MinBalance {
+			return fmt.Errorf("%w: tiers must be in ascending min_balance order", ErrInvalidInterestTerms)
+		}
+	}
+	return nil
+}
+
+// dayCount returns the days between from and to under conv, and the days in its year.
+func dayCount(conv DayCountConvention, from, to time.Time) (days, basis int64) {
+	from, to = DateOf(from), DateOf(to)
+	switch conv {
+	case DayCount30360:
+		// 30/360 US: the 31st counts as the 30th, and so does a closing 31st after an opening 30th
+		y1, m1, d1 := from.Date()
+		y2, m2, d2 := to.Date()
+		if d1 == 31 {
+			d1 = 30
+		}
+		if d2 == 31 && d1 == 30 {
+			d2 = 30
+		}
+		return int64(360*(y2-y1) + 30*(int(m2)-int(m1)) + (d2 - d1)), 360
+	case DayCountAct360:
+		return int64(to.Sub(from).Hours() / 24), 360
+	default:
+		return int64(to.Sub(from).Hours() / 24), 365
+	}
+}
+
+// annualInterest returns balance × rate in minor units × basis points, applying the
+// tiers (or the flat rate without tiers).
+func annualInterest(rateBPS int64, t InterestTerms, balance int64) *big.Int {
+	tiers := t.Tiers
+	if len(tiers) == 0 {
+		tiers = InterestTiers{{MinBalance: 0, RateBPS: rateBPS}}
+	}
+	total := new(big.Int)
+	if t.TierMode == TierWhole {
+		// Highest tier the balance reaches
+		i := sort.Search(len(tiers), func(i int) bool { return tiers[i].MinBalance > balance }) - 1
+		if i >= 0 {
+			total.Mul(big.NewInt(balance), big.NewInt(tiers[i].RateBPS))
+		}
+		return total
+	}
+	for i, tier := range tiers {
+		if balance <= tier.MinBalance {
+			break
+		}
+		upper := balance
+		if i+1 < len(tiers) && tiers[i+1].MinBalance < balance {
+			upper = tiers[i+1].MinBalance
+		}
+		slice := new(big.Int).Mul(big.NewInt(upper-tier.MinBalance), big.NewInt(tier.RateBPS))
+		total.Add(total, slice)
+	}
+	return total
+}
+
+// periodInterest returns the exact interest a balance earns over (from, to] under a
+// product's rate and terms, in minor units.
+func periodInterest(rateBPS int64, t InterestTerms, balance int64, from, to time.Time) *big.Rat {
+	if balance <= 0 || balance < t.MinBalance {
+		return new(big.Rat)
+	}
+	return overPeriod(annualInterest(rateBPS, t, balance), t.DayCount, from, to)
+}
+
+// debitPeriodInterest returns the exact interest charged on a debit position of
+// balance over (from, to]. With a penalty rate, the part beyond the arranged
+// overdraft limit is charged that instead of the debit rate; arranged is negative
+// when the account has no arranged limit (loans).
+func debitPeriodInterest(t InterestTerms, balance, arranged int64, from, to time.Time) *big.Rat {
+	if balance <= 0 {
+		return new(big.Rat)
+	}
+	within, beyond := balance, int64(0)
+	if t.PenaltyRateBPS > 0 && arranged >= 0 && balance > arranged {
+		within, beyond = arranged, balance-arranged
+	}
+	annual := new(big.Int).Mul(big.NewInt(within), big.NewInt(t.DebitRateBPS))
+	annual.Add(annual, new(big.Int).Mul(big.NewInt(beyond), big.NewInt(t.PenaltyRateBPS)))
+	return overPeriod(annual, t.DayCount, from, to)
+}
+
+// overPeriod turns annual interest in minor units × basis points into the interest
+// for (from, to] under conv.
+func overPeriod(annual *big.Int, conv DayCountConvention, from, to time.Time) *big.Rat {
+	days, basis := dayCount(conv, from, to)
+	if days <= 0 {
+		return new(big.Rat)
+	}
+	num := new(big.Int).Mul(annual, big.NewInt(days))
+	return new(big.Rat).SetFrac(num, big.NewInt(10000*basis))
+}
+
+// roundInterest rounds r to whole minor units under mode.
+func roundInterest(r *big.Rat, mode RoundingMode) int64 {
+	q, m := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if m.Sign() == 0 {
+		return q.Int64()
+	}
+	away := false
+	switch mode {
+	case RoundUp:
+		away = true
+	case RoundHalfUp, RoundHalfEven:
+		// Compare the remainder with half the denominator
+		c := new(big.Int).Mul(new(big.Int).Abs(m), big.NewInt(2)).Cmp(r.Denom())
+		away = c > 0 || c == 0 && (mode == RoundHalfUp || q.Bit(0) == 1)
+	}
+	if away {
+		q.Add(q, big.NewInt(int64(r.Sign())))
+	}
+	return q.Int64()
+}
+
+// accrueInterest returns the credit interest to post for (from, to] with the
+// carried fraction added, and the fraction to carry on.
+func accrueInterest(rateBPS int64, t InterestTerms, balance int64, from, to time.Time, carry *big.Rat) (int64, *big.Rat) {
+	return carryForward(periodInterest(rateBPS, t, balance, from, to), carry, t.Rounding)
+}
+
+// carryForward rounds exact interest plus the carried fraction to whole minor
+// units, and returns them with the fraction left to carry.
+func carryForward(exact, carry *big.Rat, mode RoundingMode) (int64, *big.Rat) {
+	total := new(big.Rat).Add(exact, carry)
+	if total.Sign() <= 0 {
+		return 0, total
+	}
+	amount := roundInterest(total, mode)
+	return amount, total.Sub(total, new(big.Rat).SetInt64(amount))
+}
+
+// carryScale is the number of decimals a carried fraction is stored with.
+const carryScale = 18
+
+// interestState is an account's accrual progress. Credit and debit interest carry
+// their sub-unit fractions separately.
+type interestState struct {
+	carry          *big.Rat
+	debitCarry     *big.Rat
+	accruedThrough *time.Time // Last business date accrued; nil before the first accrual
+}
+
+// lockInterestState locks an account's interest state row, creating it on first use.
+func lockInterestState(tx *sql.Tx, accountID uuid.UUID) (*interestState, error) {
+	if _, err := tx.Exec(`INSERT INTO account_interest (account_id) VALUES ($1) ON CONFLICT (account_id) DO NOTHING`, accountID); err != nil {
+		return nil, fmt.Errorf("failed to create interest state: %w", err)
+	}
+	var carry, debitCarry string
+	st := &interestState{}
+	err := tx.QueryRow(`
+		SELECT carry::TEXT, debit_carry::TEXT, accrued_through FROM account_interest WHERE account_id = $1 FOR UPDATE
+	`, accountID).Scan(&carry, &debitCarry, &st.accruedThrough)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load interest state: %w", err)
+	}
+	var ok bool
+	if st.carry, ok = new(big.Rat).SetString(carry); !ok {
+		return nil, fmt.Errorf("invalid interest carry %q", carry)
+	}
+	if st.debitCarry, ok = new(big.Rat).SetString(debitCarry); !ok {
+		return nil, fmt.Errorf("invalid debit interest carry %q", debitCarry)
+	}
+	return st, nil
+}
+
+func saveInterestState(tx *sql.Tx, accountID uuid.UUID, st *interestState, accruedThrough time.Time) error {
+	_, err := tx.Exec(`
+		UPDATE account_interest
+		SET carry = $2::NUMERIC, debit_carry = $3::NUMERIC, accrued_through = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE account_id = $1
+	`, accountID, st.carry.FloatString(carryScale), st.debitCarry.FloatString(carryScale), accruedThrough.Format("2006-01-02"))
+	if err != nil {
+		return fmt.Errorf("failed to save interest state: %w", err)
+	}
+	return nil
+}
+
+// SetProductInterestTerms sets a product's day-count convention, tiers, minimum
+// balance, rounding, capitalization schedule, debit interest rate and overdraft
+// penalty rate. Like its rate, the terms of an active product that accounts use
+// cannot change; create a new version instead.
+func (s *Service) SetProductInterestTerms(productID uuid.UUID, t InterestTerms) error {
+	if err := validateInterestTerms(t); err != nil {
+		return err
+	}
+
+	var status ProductStatus
+	var inUse bool
+	err := s.db.QueryRow(`
+		SELECT status, EXISTS (SELECT 1 FROM accounts WHERE product_id = $1)
+		FROM products WHERE id = $1
+	`, productID).Scan(&status, &inUse)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("product not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load product: %w", err)
+	}
+	if status == ProductStatusActive && inUse {
+		return fmt.Errorf("cannot change interest terms of an active product in use. Create a new version instead")
+	}
+	return saveInterestTerms(s.db, productID, t)
+}
+
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+func saveInterestTerms(db execer, productID uuid.UUID, t InterestTerms) error {
+	_, err := db.Exec(`
+		UPDATE products
+		SET day_count_convention = $1, tier_mode = $2, interest_tiers = $3, min_interest_balance = $4, interest_rounding = $5,
+		    capitalization_frequency = $6, debit_interest_rate_bps = $7, overdraft_penalty_rate_bps = $8
+		WHERE id = $9
+	`, t.DayCount, t.TierMode, t.Tiers, t.MinBalance, t.Rounding, t.Capitalization, t.DebitRateBPS, t.PenaltyRateBPS, productID)
+	if err != nil {
+		return fmt.Errorf("failed to set interest terms: %w", err)
+	}
+	return nil
+}
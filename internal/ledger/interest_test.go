@@ -0,0 +1,193 @@
+package ledger
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func day(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestDayCount(t *testing.T) {
+	tests := []struct {
+		conv     DayCountConvention
+		from, to time.Time
+		days, yr int64
+	}{
+		{DayCountAct365, day(2025, 1, 1), day(2025, 2, 1), 31, 365},
+		{DayCountAct360, day(2025, 1, 1), day(2025, 2, 1), 31, 360},
+		{DayCountAct365, day(2024, 2, 28), day(2024, 3, 1), 2, 365},
+		{DayCount30360, day(2025, 1, 1), day(2025, 7, 1), 180, 360},
+		{DayCount30360, day(2025, 1, 30), day(2025, 1, 31), 0, 360},
+		{DayCount30360, day(2025, 1, 31), day(2025, 2, 1), 1, 360},
+		{DayCount30360, day(2025, 2, 28), day(2025, 3, 1), 3, 360},
+	}
+	for _, tt := range tests {
+		days, basis := dayCount(tt.conv, tt.from, tt.to)
+		if days != tt.days || basis != tt.yr {
+			t.Errorf("%s %s..%s: got %d/%d, want %d/%d", tt.conv, tt.from.Format("2006-01-02"), tt.to.Format("2006-01-02"), days, basis, tt.days, tt.yr)
+		}
+	}
+}
+
+func TestAnnualInterestTiers(t *testing.T) {
+	tiers := InterestTiers{{MinBalance: 0, RateBPS: 100}, {MinBalance: 1000, RateBPS: 200}, {MinBalance: 5000, RateBPS: 300}}
+	banded := InterestTerms{TierMode: TierBanded, Tiers: tiers}
+	whole := InterestTerms{TierMode: TierWhole, Tiers: tiers}
+	tests := []struct {
+		name    string
+		terms   InterestTerms
+		balance int64
+		want    int64
+	}{
+		{"flat rate without tiers", InterestTerms{TierMode: TierBanded}, 10000, 10000 * 500},
+		{"banded across all tiers", banded, 6000, 1000*100 + 4000*200 + 1000*300},
+		{"banded in first tier", banded, 500, 500 * 100},
+		{"whole balance at top tier", whole, 6000, 6000 * 300},
+		{"whole balance at middle tier", whole, 1000, 1000 * 200},
+		{"below first tier", InterestTerms{TierMode: TierWhole, Tiers: InterestTiers{{MinBalance: 1000, RateBPS: 200}}}, 999, 0},
+	}
+	for _, tt := range tests {
+		if got := annualInterest(500, tt.terms, tt.balance); got.Int64() != tt.want {
+			t.Errorf("%s: got %s, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRoundInterest(t *testing.T) {
+	tests := []struct {
+		num, den int64
+		mode     RoundingMode
+		want     int64
+	}{
+		{5, 2, RoundDown, 2},
+		{5, 2, RoundUp, 3},
+		{5, 2, RoundHalfUp, 3},
+		{5, 2, RoundHalfEven, 2},
+		{7, 2, RoundHalfEven, 4},
+		{13, 5, RoundHalfUp, 3},
+		{12, 5, RoundHalfUp, 2},
+		{12, 5, RoundUp, 3},
+		{4, 1, RoundUp, 4},
+	}
+	for _, tt := range tests {
+		if got := roundInterest(big.NewRat(tt.num, tt.den), tt.mode); got != tt.want {
+			t.Errorf("%d/%d %s: got %d, want %d", tt.num, tt.den, tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestAccrueInterestCarriesSubCentAmounts(t *testing.T) {
+	// 1,000 at 5% earns 0.137 a day: truncating each day would pay nothing
+	for _, mode := range []RoundingMode{RoundDown, RoundUp, RoundHalfUp, RoundHalfEven} {
+		terms := DefaultInterestTerms
+		terms.Rounding = mode
+		carry := new(big.Rat)
+		var total int64
+		date := day(2025, 1, 1)
+		for i := 0; i < 365; i++ {
+			var amount int64
+			amount, carry = accrueInterest(500, terms, 1000, date, date.AddDate(0, 0, 1), carry)
+			total += amount
+			date = date.AddDate(0, 0, 1)
+		}
+		if total != 50 || carry.Sign() != 0 {
+			t.Errorf("%s: expected 50 posted over a year with nothing left over, got %d with carry %s", mode, total, carry.FloatString(4))
+		}
+	}
+}
+
+func TestAccrueInterestMinimumBalance(t *testing.T) {
+	terms := DefaultInterestTerms
+	terms.MinBalance = 20000
+	carry := big.NewRat(1, 2)
+	amount, carry := accrueInterest(500, terms, 10000, day(2025, 1, 1), day(2025, 1, 2), carry)
+	if amount != 0 || carry.Cmp(big.NewRat(1, 2)) != 0 {
+		t.Errorf("Expected no interest below the minimum balance and the carry kept, got %d, %s", amount, carry.FloatString(2))
+	}
+	if amount, _ := accrueInterest(500, terms, -50000, day(2025, 1, 1), day(2025, 1, 2), new(big.Rat)); amount != 0 {
+		t.Errorf("Expected no credit interest on an overdrawn balance, got %d", amount)
+	}
+}
+
+func TestDebitPeriodInterest(t *testing.T) {
+	terms := DefaultInterestTerms
+	terms.DayCount = DayCountAct360
+	terms.DebitRateBPS = 1800
+	from, to := day(2025, 1, 1), day(2025, 1, 2)
+	tests := []struct {
+		name              string
+		penalty           int64
+		balance, arranged int64
+		want              *big.Rat
+	}{
+		{"within the arranged limit", 3600, 36000, 50000, big.NewRat(36000*1800, 10000*360)},
+		{"beyond the limit at the penalty rate", 3600, 72000, 36000, big.NewRat(36000*1800+36000*3600, 10000*360)},
+		{"no penalty rate", 0, 72000, 36000, big.NewRat(72000*1800, 10000*360)},
+		{"loan without an arranged limit", 3600, 72000, -1, big.NewRat(72000*1800, 10000*360)},
+		{"credit position", 3600, -5000, 0, new(big.Rat)},
+	}
+	for _, tt := range tests {
+		terms.PenaltyRateBPS = tt.penalty
+		if got := debitPeriodInterest(terms, tt.balance, tt.arranged, from, to); got.Cmp(tt.want) != 0 {
+			t.Errorf("%s: got %s, want %s", tt.name, got.FloatString(4), tt.want.FloatString(4))
+		}
+	}
+}
+
+func TestValidateInterestTerms(t *testing.T) {
+	if err := validateInterestTerms(DefaultInterestTerms); err != nil {
+		t.Fatalf("Expected the default terms to be valid, got %v", err)
+	}
+	for name, change := range map[string]func(*InterestTerms){
+		"unknown convention":  func(t *InterestTerms) { t.DayCount = "ACT/ACT" },
+		"unknown tier mode":   func(t *InterestTerms) { t.TierMode = "STEPPED" },
+		"unknown rounding":    func(t *InterestTerms) { t.Rounding = "CEILING" },
+		"negative minimum":    func(t *InterestTerms) { t.MinBalance = -1 },
+		"negative rate":       func(t *InterestTerms) { t.Tiers = InterestTiers{{MinBalance: 0, RateBPS: -5}} },
+		"negative debit rate": func(t *InterestTerms) { t.DebitRateBPS = -1 },
+		"unsorted tiers":      func(t *InterestTerms) { t.Tiers = InterestTiers{{MinBalance: 100}, {MinBalance: 100}} },
+	} {
+		terms := DefaultInterestTerms
+		change(&terms)
+		if err := validateInterestTerms(terms); !errors.Is(err, ErrInvalidInterestTerms) {
+			t.Errorf("%s: expected ErrInvalidInterestTerms, got %v", name, err)
+		}
+	}
+}
+
+func TestCapitalizationDue(t *testing.T) {
+	tests := []struct {
+		freq CapitalizationFrequency
+		date time.Time
+		want time.Time
+	}{
+		{CapitalizeDaily, day(2025, 5, 14), day(2025, 5, 14)},
+		{CapitalizeMonthly, day(2025, 5, 14), day(2025, 4, 30)},
+		{CapitalizeMonthly, day(2025, 5, 31), day(2025, 5, 31)},
+		{CapitalizeMonthly, day(2025, 3, 1), day(2025, 2, 28)},
+		{CapitalizeQuarterly, day(2025, 5, 14), day(2025, 3, 31)},
+		{CapitalizeQuarterly, day(2025, 6, 30), day(2025, 6, 30)},
+		{CapitalizeQuarterly, day(2025, 2, 1), day(2024, 12, 31)},
+		{CapitalizeAnnually, day(2025, 12, 30), day(2024, 12, 31)},
+		{CapitalizeAnnually, day(2025, 12, 31), day(2025, 12, 31)},
+	}
+	for _, tt := range tests {
+		if got := capitalizationDue(tt.freq, tt.date); !got.Equal(tt.want) {
+			t.Errorf("%s on %s: got %s, want %s", tt.freq, tt.date.Format("2006-01-02"), got.Format("2006-01-02"), tt.want.Format("2006-01-02"))
+		}
+	}
+}
+
+func TestAccrualTemplatesNeedNoAccountLine(t *testing.T) {
+	lines := []PostingTemplateLine{{Direction: Debit, Role: RoleGL, GLCode: GLCodeInterestExpense}, {Direction: Credit, Role: RoleGL, GLCode: GLCodeInterestPayable}}
+	if err := validateTemplate(&PostingTemplate{EventType: EventInterestAccrual, Description: "Accrual", Lines: lines}); err != nil {
+		t.Errorf("Expected a GL-only accrual template to be valid, got %v", err)
+	}
+	if err := validateTemplate(&PostingTemplate{EventType: EventInterestCapitalization, Description: "Capitalization", Lines: lines}); !errors.Is(err, ErrInvalidTemplate) {
+		t.Errorf("Expected a capitalization template without an ACCOUNT line to be rejected, got %v", err)
+	}
+}
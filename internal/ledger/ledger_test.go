@@ -2,13 +2,17 @@ package ledger
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
 	_ "github.com/lib/pq"
+	"github.com/nathanmocogni/core-banking-system/internal/common"
 )
 
 // TestMain handles setup and teardown for the test suite.
@@ -54,7 +58,7 @@ func TestCreateAccount(t *testing.T) {
 		t.Skip("Skipping test: database not reachable")
 	}
 
-	service := NewService(db, nil)
+	service := NewService(db)
 
 	name := fmt.Sprintf("Test Account %d", time.Now().UnixNano())
 	acc, err := service.CreateAccount(name, Asset, "USD", "CASH", "INDIVIDUAL", nil)
@@ -80,7 +84,7 @@ func TestPostTransaction_Balanced(t *testing.T) {
 		t.Skip("Skipping test: database not reachable")
 	}
 
-	service := NewService(db, nil)
+	service := NewService(db)
 
 	// Setup accounts
 	// Setup accounts
@@ -139,7 +143,7 @@ func TestPostTransaction_Unbalanced(t *testing.T) {
 		t.Skip("Skipping test: database not reachable")
 	}
 
-	service := NewService(db, nil)
+	service := NewService(db)
 
 	acc1, err := service.CreateAccount("Acc 1", Asset, "USD", "CASH", "INDIVIDUAL", nil)
 	if err != nil {
@@ -171,7 +175,7 @@ func TestProductVersioning(t *testing.T) {
 		t.Skip("Skipping test: database not reachable")
 	}
 
-	service := NewService(db, nil)
+	service := NewService(db)
 
 	// 1. Create Product
 	p1, err := service.CreateProduct("Savings Account", 500) // 5%
@@ -243,7 +247,7 @@ func TestFeeManagement(t *testing.T) {
 		t.Skip("Skipping test: database not reachable")
 	}
 
-	service := NewService(db, nil)
+	service := NewService(db)
 
 	// Create GL Account for Fees
 	glAcc, err := service.CreateAccount("Fee Income", Income, "USD", "REVENUE", "SYSTEM", nil)
@@ -277,7 +281,7 @@ func TestCalculateInterest(t *testing.T) {
 		t.Skip("Skipping test: database not reachable")
 	}
 
-	service := NewService(db, nil)
+	service := NewService(db)
 
 	// 1. Setup Product (5% interest)
 	prod, err := service.CreateProduct("Interest Product", 500) // 5% = 500 bps
@@ -301,7 +305,7 @@ func TestCalculateInterest(t *testing.T) {
 
 	// Deposit 10,000
 	// Liability Credit -> Balance -10,000
-	sysAcc, _ := service.GetOrCreateSystemAccount("Cash In", Asset)
+	sysAcc, _ := service.GetOrCreateSystemAccount("Cash In", Asset, "USD")
 	entries := []Entry{
 		{AccountID: sysAcc, Direction: Debit, Amount: 10000},
 		{AccountID: acc.ID, Direction: Credit, Amount: 10000},
@@ -332,3 +336,1474 @@ func TestCalculateInterest(t *testing.T) {
 		}
 	}
 }
+
+func TestInterestAccrualAndCapitalization(t *testing.T) {
+	db, err := connectDB()
+	if err != nil {
+		t.Skip("Skipping test: could not connect to database")
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		t.Skip("Skipping test: database not reachable")
+	}
+
+	service := NewService(db)
+
+	prod, err := service.CreateProduct("Accruing Savings", 500)
+	if err != nil {
+		t.Fatalf("Failed to create product: %v", err)
+	}
+	terms := DefaultInterestTerms
+	terms.Capitalization = CapitalizeDaily
+	if err := service.SetProductInterestTerms(prod.ID, terms); err != nil {
+		t.Fatalf("Failed to set interest terms: %v", err)
+	}
+	if _, err := service.UpdateProduct(prod.ID, "Accruing Savings", 500, ProductStatusActive); err != nil {
+		t.Fatalf("Failed to activate product: %v", err)
+	}
+	acc, err := service.CreateAccount("Accrual User", Liability, "USD", "CASH", "INDIVIDUAL", nil)
+	if err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+	if err := service.AssignProduct(acc.ID, prod.ID); err != nil {
+		t.Fatalf("Failed to assign product: %v", err)
+	}
+	sysAcc, _ := service.GetOrCreateSystemAccount("Cash In", Asset, "USD")
+	if _, err := service.PostTransaction(fmt.Sprintf("DEP-ACR-%d", time.Now().UnixNano()), "Deposit", []Entry{
+		{AccountID: sysAcc, Direction: Debit, Amount: 1000000},
+		{AccountID: acc.ID, Direction: Credit, Amount: 1000000},
+	}); err != nil {
+		t.Fatalf("Failed to deposit: %v", err)
+	}
+
+	// 1,000,000 at 5% earns 136.99 a day: 136 accrues, the rest is carried
+	today := DateOf(time.Now())
+	if _, err := service.CalculateInterestAsOf(today); err != nil {
+		t.Fatalf("Failed to calculate interest: %v", err)
+	}
+	accrued, err := service.GetAccruedInterest(acc.ID)
+	if err != nil {
+		t.Fatalf("GetAccruedInterest failed: %v", err)
+	}
+	if accrued.Payable != 136 {
+		t.Errorf("Expected 136 accrued, got %d", accrued.Payable)
+	}
+	if got, _ := service.GetAccount(acc.ID); got.Balance != -1000000 {
+		t.Errorf("Expected accrual to leave the account alone, got balance %d", got.Balance)
+	}
+
+	capitalized, err := service.CapitalizeInterestAsOf(today)
+	if err != nil {
+		t.Fatalf("Failed to capitalize interest: %v", err)
+	}
+	if got, _ := service.GetAccount(acc.ID); got.Balance != -1000136 {
+		t.Errorf("Expected the accrued interest to be capitalized, got balance %d", got.Balance)
+	}
+	wantRef := fmt.Sprintf("CAP-%s-%s-%s", acc.ID, AccrualPayable, today.Format("2006-01-02"))
+	found := false
+	for _, tx := range capitalized {
+		found = found || tx.Reference == wantRef
+	}
+	if !found {
+		t.Errorf("Expected a capitalization with reference %s", wantRef)
+	}
+	// Running again for the same period posts nothing
+	again, err := service.CapitalizeInterestAsOf(today)
+	if err != nil {
+		t.Fatalf("Failed to capitalize interest again: %v", err)
+	}
+	for _, tx := range again {
+		if tx.Reference == wantRef {
+			t.Errorf("Expected the period to be capitalized once, got %s again", tx.Reference)
+		}
+	}
+	accrued, err = service.GetAccruedInterest(acc.ID)
+	if err != nil {
+		t.Fatalf("GetAccruedInterest failed: %v", err)
+	}
+	if accrued.Payable != 0 || accrued.LastCapitalized == nil {
+		t.Errorf("Expected nothing left accrued after capitalization, got %+v", accrued)
+	}
+	if _, err := service.ReconcileInterestAccruals(); err != nil {
+		t.Errorf("ReconcileInterestAccruals failed: %v", err)
+	}
+}
+
+func TestInterestAccrualIsIdempotentAndCatchesUp(t *testing.T) {
+	db, err := connectDB()
+	if err != nil {
+		t.Skip("Skipping test: could not connect to database")
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		t.Skip("Skipping test: database not reachable")
+	}
+
+	service := NewService(db)
+
+	prod, err := service.CreateProduct("Catch-up Savings", 500)
+	if err != nil {
+		t.Fatalf("Failed to create product: %v", err)
+	}
+	if _, err := service.UpdateProduct(prod.ID, "Catch-up Savings", 500, ProductStatusActive); err != nil {
+		t.Fatalf("Failed to activate product: %v", err)
+	}
+	acc, err := service.CreateAccount("Catch-up User", Liability, "USD", "CASH", "INDIVIDUAL", nil)
+	if err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+	if err := service.AssignProduct(acc.ID, prod.ID); err != nil {
+		t.Fatalf("Failed to assign product: %v", err)
+	}
+	today := DateOf(time.Now())
+	sysAcc, _ := service.GetOrCreateSystemAccount("Cash In", Asset, "USD")
+	if _, err := service.Post(PostingRequest{
+		Reference:   fmt.Sprintf("DEP-CU-%d", time.Now().UnixNano()),
+		Description: "Deposit",
+		ValueDate:   today.AddDate(0, 0, -5),
+		Entries: []Entry{
+			{AccountID: sysAcc, Direction: Debit, Amount: 1000000},
+			{AccountID: acc.ID, Direction: Credit, Amount: 1000000},
+		},
+	}); err != nil {
+		t.Fatalf("Failed to deposit: %v", err)
+	}
+
+	accruals := func(txs []*Transaction) int {
+		var n int
+		for _, tx := range txs {
+			if strings.HasPrefix(tx.Reference, "INT-"+acc.ID.String()) {
+				n++
+			}
+		}
+		return n
+	}
+	run := func(date time.Time, want int) {
+		t.Helper()
+		txs, err := service.CalculateInterestAsOf(date)
+		if err != nil {
+			t.Fatalf("CalculateInterestAsOf failed: %v", err)
+		}
+		if got := accruals(txs); got != want {
+			t.Errorf("CalculateInterestAsOf(%s) accrued %d days, want %d", date.Format("2006-01-02"), got, want)
+		}
+	}
+
+	run(today.AddDate(0, 0, -3), 1)
+	run(today.AddDate(0, 0, -3), 0) // Same business date again
+	run(today, 3)                   // Catches up the two missed days
+	run(today, 0)
+
+	// 136.99 a day over 4 days is 547.95: the carry makes up what truncation would lose
+	accrued, err := service.GetAccruedInterest(acc.ID)
+	if err != nil {
+		t.Fatalf("GetAccruedInterest failed: %v", err)
+	}
+	if accrued.Payable != 547 {
+		t.Errorf("Expected 547 accrued over 4 days, got %d", accrued.Payable)
+	}
+
+	if _, err := service.CalculateInterestAsOf(today.AddDate(0, 0, 1)); !errors.Is(err, ErrDateOutOfPolicy) {
+		t.Errorf("Expected a future business date to be rejected, got %v", err)
+	}
+}
+
+func TestOverdraftDebitInterest(t *testing.T) {
+	db, err := connectDB()
+	if err != nil {
+		t.Skip("Skipping test: could not connect to database")
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		t.Skip("Skipping test: database not reachable")
+	}
+
+	service := NewService(db)
+
+	prod, err := service.CreateProduct("Overdraft Current Account", 500)
+	if err != nil {
+		t.Fatalf("Failed to create product: %v", err)
+	}
+	terms := DefaultInterestTerms
+	terms.DayCount = DayCountAct360
+	terms.Capitalization = CapitalizeDaily
+	terms.DebitRateBPS = 1800
+	terms.PenaltyRateBPS = 3600
+	if err := service.SetProductInterestTerms(prod.ID, terms); err != nil {
+		t.Fatalf("Failed to set interest terms: %v", err)
+	}
+	if _, err := service.UpdateProduct(prod.ID, "Overdraft Current Account", 500, ProductStatusActive); err != nil {
+		t.Fatalf("Failed to activate product: %v", err)
+	}
+	acc, err := service.CreateAccount("Overdrawn User", Liability, "USD", "CASH", "INDIVIDUAL", nil)
+	if err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+	if err := service.AssignProduct(acc.ID, prod.ID); err != nil {
+		t.Fatalf("Failed to assign product: %v", err)
+	}
+
+	// Overdraw by 72,000, then cut the arranged limit to 36,000
+	limit := int64(72000)
+	if err := service.SetAccountOverdraftLimit(acc.ID, &limit); err != nil {
+		t.Fatalf("Failed to set overdraft limit: %v", err)
+	}
+	sysAcc, _ := service.GetOrCreateSystemAccount("Cash In", Asset, "USD")
+	if _, err := service.PostTransaction(fmt.Sprintf("WD-OD-%d", time.Now().UnixNano()), "Withdrawal", []Entry{
+		{AccountID: acc.ID, Direction: Debit, Amount: 72000},
+		{AccountID: sysAcc, Direction: Credit, Amount: 72000},
+	}); err != nil {
+		t.Fatalf("Failed to overdraw: %v", err)
+	}
+	limit = 36000
+	if err := service.SetAccountOverdraftLimit(acc.ID, &limit); err != nil {
+		t.Fatalf("Failed to set overdraft limit: %v", err)
+	}
+
+	// 36,000 at 18% plus 36,000 at the 36% penalty rate over 360 days is 54 a day
+	today := DateOf(time.Now())
+	if _, err := service.CalculateInterestAsOf(today); err != nil {
+		t.Fatalf("Failed to calculate interest: %v", err)
+	}
+	accrued, err := service.GetAccruedInterest(acc.ID)
+	if err != nil {
+		t.Fatalf("GetAccruedInterest failed: %v", err)
+	}
+	if accrued.Receivable != 54 || accrued.Payable != 0 {
+		t.Errorf("Expected 54 debit interest and no credit interest, got %+v", accrued)
+	}
+
+	// Charged to the account even though it is beyond its limit
+	if _, err := service.CapitalizeInterestAsOf(today); err != nil {
+		t.Fatalf("Failed to capitalize interest: %v", err)
+	}
+	if got, _ := service.GetAccount(acc.ID); got.Balance != 72054 {
+		t.Errorf("Expected the debit interest to be charged, got balance %d", got.Balance)
+	}
+}
+
+func TestReverseTransaction(t *testing.T) {
+	db, err := connectDB()
+	if err != nil {
+		t.Skip("Skipping test: could not connect to database")
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		t.Skip("Skipping test: database not reachable")
+	}
+
+	service := NewService(db)
+
+	acc1, err := service.CreateAccount("Rev Acc 1", Asset, "USD", "CASH", "INDIVIDUAL", nil)
+	if err != nil {
+		t.Fatalf("Failed to create acc1: %v", err)
+	}
+	acc2, err := service.CreateAccount("Rev Acc 2", Equity, "USD", "CASH", "INDIVIDUAL", nil)
+	if err != nil {
+		t.Fatalf("Failed to create acc2: %v", err)
+	}
+
+	entries := []Entry{
+		{AccountID: acc1.ID, Direction: Debit, Amount: 250},
+		{AccountID: acc2.ID, Direction: Credit, Amount: 250},
+	}
+	original, err := service.PostTransaction(fmt.Sprintf("REF-%d", time.Now().UnixNano()), "To Reverse", entries)
+	if err != nil {
+		t.Fatalf("Failed to post transaction: %v", err)
+	}
+
+	reversal, err := service.ReverseTransaction(original.ID, "Posted in error")
+	if err != nil {
+		t.Fatalf("Failed to reverse transaction: %v", err)
+	}
+	if reversal.ReversalOf == nil || *reversal.ReversalOf != original.ID {
+		t.Errorf("Expected reversal to link to original %s", original.ID)
+	}
+
+	// Balances are back to zero
+	updatedAcc1, _ := service.GetAccount(acc1.ID)
+	updatedAcc2, _ := service.GetAccount(acc2.ID)
+	if updatedAcc1.Balance != 0 || updatedAcc2.Balance != 0 {
+		t.Errorf("Expected balances 0/0 after reversal, got %d/%d", updatedAcc1.Balance, updatedAcc2.Balance)
+	}
+
+	// Second reversal is rejected
+	if _, err := service.ReverseTransaction(original.ID, "Again"); !errors.Is(err, ErrAlreadyReversed) {
+		t.Errorf("Expected ErrAlreadyReversed, got %v", err)
+	}
+	// Reversing the reversal is rejected
+	if _, err := service.ReverseTransaction(reversal.ID, "Undo undo"); !errors.Is(err, ErrReversalOfReversal) {
+		t.Errorf("Expected ErrReversalOfReversal, got %v", err)
+	}
+
+	// History shows both links
+	history, _, err := service.GetTransactions(TransactionFilter{AccountID: acc1.ID}, common.PageRequest{Limit: 10})
+	if err != nil {
+		t.Fatalf("Failed to get transactions: %v", err)
+	}
+	for _, tx := range history {
+		if tx.ID == original.ID && (tx.ReversedBy == nil || *tx.ReversedBy != reversal.ID) {
+			t.Errorf("Expected original to show reversed_by %s", reversal.ID)
+		}
+	}
+}
+
+func TestNaturalBalance(t *testing.T) {
+	cases := []struct {
+		accType AccountType
+		stored  int64
+		want    int64
+	}{
+		{Asset, 100, 100},
+		{Expense, 100, 100},
+		{Liability, -100, 100},
+		{Equity, -100, 100},
+		{Income, -100, 100},
+		{Liability, 50, -50}, // Overdrawn deposit account
+	}
+	for _, c := range cases {
+		if got := NaturalBalance(c.accType, c.stored); got != c.want {
+			t.Errorf("NaturalBalance(%s, %d) = %d, want %d", c.accType, c.stored, got, c.want)
+		}
+	}
+}
+
+func TestHoldsAndOverdraft(t *testing.T) {
+	db, err := connectDB()
+	if err != nil {
+		t.Skip("Skipping test: could not connect to database")
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		t.Skip("Skipping test: database not reachable")
+	}
+
+	service := NewService(db)
+
+	cash, err := service.GetOrCreateSystemAccount("Cash In", Asset, "USD")
+	if err != nil {
+		t.Fatalf("Failed to get system account: %v", err)
+	}
+	acc, err := service.CreateAccount("Hold User", Liability, "USD", "CASH", "INDIVIDUAL", nil)
+	if err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+
+	// Fund the account with 1000
+	_, err = service.PostTransaction(fmt.Sprintf("DEP-%d", time.Now().UnixNano()), "Deposit", []Entry{
+		{AccountID: cash, Direction: Debit, Amount: 1000},
+		{AccountID: acc.ID, Direction: Credit, Amount: 1000},
+	})
+	if err != nil {
+		t.Fatalf("Failed to fund account: %v", err)
+	}
+
+	// Hold 600 leaves 400 available
+	hold, err := service.CreateHold(acc.ID, 600, "AUTH-1", "Card authorization", nil)
+	if err != nil {
+		t.Fatalf("Failed to create hold: %v", err)
+	}
+	bal, err := service.GetAvailableBalance(acc.ID)
+	if err != nil {
+		t.Fatalf("Failed to get balance: %v", err)
+	}
+	if bal.Balance != 1000 || bal.HeldAmount != 600 || bal.AvailableBalance != 400 {
+		t.Errorf("Expected balance 1000, held 600, available 400; got %+v", bal)
+	}
+
+	// A 500 debit exceeds the available balance
+	_, err = service.PostTransaction(fmt.Sprintf("WD-%d", time.Now().UnixNano()), "Withdrawal", []Entry{
+		{AccountID: acc.ID, Direction: Debit, Amount: 500},
+		{AccountID: cash, Direction: Credit, Amount: 500},
+	})
+	if !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("Expected ErrInsufficientFunds, got %v", err)
+	}
+
+	// ... but fits within a 200 overdraft
+	limit := int64(200)
+	if err := service.SetAccountOverdraftLimit(acc.ID, &limit); err != nil {
+		t.Fatalf("Failed to set overdraft: %v", err)
+	}
+	_, err = service.PostTransaction(fmt.Sprintf("WD-%d", time.Now().UnixNano()), "Withdrawal", []Entry{
+		{AccountID: acc.ID, Direction: Debit, Amount: 500},
+		{AccountID: cash, Direction: Credit, Amount: 500},
+	})
+	if err != nil {
+		t.Fatalf("Expected debit within overdraft to succeed: %v", err)
+	}
+
+	// Capturing part of the hold posts it and frees the rest
+	tx, err := service.CaptureHold(hold.ID, 300, cash, "")
+	if err != nil {
+		t.Fatalf("Failed to capture hold: %v", err)
+	}
+	if len(tx.Entries) != 2 {
+		t.Errorf("Expected 2 entries on capture, got %d", len(tx.Entries))
+	}
+	bal, _ = service.GetAvailableBalance(acc.ID)
+	if bal.Balance != 200 || bal.HeldAmount != 0 {
+		t.Errorf("Expected balance 200 with no holds after capture, got %+v", bal)
+	}
+	if _, err := service.ReleaseHold(hold.ID); !errors.Is(err, ErrHoldNotActive) {
+		t.Errorf("Expected ErrHoldNotActive releasing a captured hold, got %v", err)
+	}
+}
+
+func TestConvertMinorUnits(t *testing.T) {
+	cases := []struct {
+		amount       int64
+		fromDecimals int
+		toDecimals   int
+		rate         string
+		want         int64
+	}{
+		{10000, 2, 2, "1.08", 10800},    // 100.00 EUR -> 108.00 USD
+		{10000, 2, 0, "149.5", 14950},   // 100.00 USD -> 14950 JPY
+		{14950, 0, 2, "0.00669", 10002}, // 14950 JPY -> 100.0155 USD, rounded
+		{1, 2, 2, "0.5", 1},             // Half rounds away from zero
+		{1, 2, 2, "0.4", 0},
+	}
+	for _, c := range cases {
+		got, err := convertMinorUnits(c.amount, c.fromDecimals, c.toDecimals, c.rate)
+		if err != nil {
+			t.Fatalf("convertMinorUnits(%d, %s): %v", c.amount, c.rate, err)
+		}
+		if got != c.want {
+			t.Errorf("convertMinorUnits(%d, %d->%d, %s) = %d, want %d", c.amount, c.fromDecimals, c.toDecimals, c.rate, got, c.want)
+		}
+	}
+	if _, err := convertMinorUnits(100, 2, 2, "-1"); err == nil {
+		t.Error("Expected error for negative rate")
+	}
+}
+
+func TestPostTransaction_CurrencyIntegrity(t *testing.T) {
+	db, err := connectDB()
+	if err != nil {
+		t.Skip("Skipping test: could not connect to database")
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		t.Skip("Skipping test: database not reachable")
+	}
+
+	service := NewService(db)
+
+	usd, err := service.CreateAccount("USD Acc", Asset, "USD", "CASH", "INDIVIDUAL", nil)
+	if err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+	eur, err := service.CreateAccount("EUR Acc", Equity, "EUR", "CASH", "INDIVIDUAL", nil)
+	if err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+
+	// Debits equal credits in total, but not within a currency
+	_, err = service.PostTransaction(fmt.Sprintf("REF-%d", time.Now().UnixNano()), "Mixed", []Entry{
+		{AccountID: usd.ID, Direction: Debit, Amount: 100},
+		{AccountID: eur.ID, Direction: Credit, Amount: 100},
+	})
+	if err == nil {
+		t.Error("Expected a cross-currency posting without FX legs to be rejected")
+	}
+
+	// Entry currency must match the account
+	_, err = service.PostTransaction(fmt.Sprintf("REF-%d", time.Now().UnixNano()), "Mismatch", []Entry{
+		{AccountID: usd.ID, Direction: Debit, Amount: 100, Currency: "EUR"},
+		{AccountID: usd.ID, Direction: Credit, Amount: 100, Currency: "EUR"},
+	})
+	if !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Expected ErrCurrencyMismatch, got %v", err)
+	}
+
+	if _, err := service.CreateAccount("Bad Ccy", Asset, "XXX", "CASH", "INDIVIDUAL", nil); !errors.Is(err, ErrUnknownCurrency) {
+		t.Errorf("Expected ErrUnknownCurrency, got %v", err)
+	}
+}
+
+func TestPostingPolicy(t *testing.T) {
+	today := DateOf(time.Date(2024, 3, 15, 17, 30, 0, 0, time.UTC))
+	p := PostingPolicy{MaxBackdateDays: 5, MaxForwardDays: 2}
+
+	cases := []struct {
+		date time.Time
+		ok   bool
+	}{
+		{time.Time{}, true}, // Defaults to today
+		{today, true},
+		{today.AddDate(0, 0, -5), true},
+		{today.AddDate(0, 0, -6), false},
+		{today.AddDate(0, 0, 2), true},
+		{today.AddDate(0, 0, 3), false},
+	}
+	for _, c := range cases {
+		err := p.check("value date", c.date, today)
+		if c.ok && err != nil {
+			t.Errorf("Expected %s to be allowed: %v", c.date.Format("2006-01-02"), err)
+		}
+		if !c.ok && !errors.Is(err, ErrDateOutOfPolicy) {
+			t.Errorf("Expected ErrDateOutOfPolicy for %s, got %v", c.date.Format("2006-01-02"), err)
+		}
+	}
+}
+
+func TestGetBalanceAsOf(t *testing.T) {
+	db, err := connectDB()
+	if err != nil {
+		t.Skip("Skipping test: could not connect to database")
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		t.Skip("Skipping test: database not reachable")
+	}
+
+	service := NewService(db)
+
+	acc1, err := service.CreateAccount("AsOf Acc 1", Asset, "USD", "CASH", "INDIVIDUAL", nil)
+	if err != nil {
+		t.Fatalf("Failed to create acc1: %v", err)
+	}
+	acc2, err := service.CreateAccount("AsOf Acc 2", Equity, "USD", "CASH", "INDIVIDUAL", nil)
+	if err != nil {
+		t.Fatalf("Failed to create acc2: %v", err)
+	}
+
+	today := DateOf(time.Now())
+	post := func(amount int64, valueDate time.Time) {
+		_, err := service.Post(PostingRequest{
+			Reference:   fmt.Sprintf("REF-%d", time.Now().UnixNano()),
+			Description: "Value dated",
+			Entries: []Entry{
+				{AccountID: acc1.ID, Direction: Debit, Amount: amount},
+				{AccountID: acc2.ID, Direction: Credit, Amount: amount},
+			},
+			ValueDate: valueDate,
+		})
+		if err != nil {
+			t.Fatalf("Failed to post value-dated transaction: %v", err)
+		}
+	}
+	post(100, today.AddDate(0, 0, -3)) // Back-dated
+	post(200, time.Time{})             // Today
+	post(400, today.AddDate(0, 0, 2))  // Forward-dated
+
+	for _, c := range []struct {
+		asOf time.Time
+		want int64
+	}{
+		{today.AddDate(0, 0, -4), 0},
+		{today.AddDate(0, 0, -3), 100},
+		{today, 300},
+		{today.AddDate(0, 0, 2), 700},
+	} {
+		got, err := service.GetBalanceAsOf(acc1.ID, c.asOf)
+		if err != nil {
+			t.Fatalf("GetBalanceAsOf failed: %v", err)
+		}
+		if got != c.want {
+			t.Errorf("Balance as of %s = %d, want %d", c.asOf.Format("2006-01-02"), got, c.want)
+		}
+	}
+
+	// The cached balance includes the forward-dated posting
+	acc, _ := service.GetAccount(acc1.ID)
+	if acc.Balance != 700 {
+		t.Errorf("Expected cached balance 700, got %d", acc.Balance)
+	}
+
+	_, err = service.Post(PostingRequest{
+		Reference: fmt.Sprintf("REF-%d", time.Now().UnixNano()),
+		Entries: []Entry{
+			{AccountID: acc1.ID, Direction: Debit, Amount: 1},
+			{AccountID: acc2.ID, Direction: Credit, Amount: 1},
+		},
+		ValueDate: today.AddDate(-1, 0, 0),
+	})
+	if !errors.Is(err, ErrDateOutOfPolicy) {
+		t.Errorf("Expected ErrDateOutOfPolicy for a year-old value date, got %v", err)
+	}
+}
+
+func TestVerifyIntegrity_RepairsDrift(t *testing.T) {
+	db, err := connectDB()
+	if err != nil {
+		t.Skip("Skipping test: could not connect to database")
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		t.Skip("Skipping test: database not reachable")
+	}
+
+	service := NewService(db)
+
+	acc1, err := service.CreateAccount("Integrity Acc 1", Asset, "USD", "CASH", "INDIVIDUAL", nil)
+	if err != nil {
+		t.Fatalf("Failed to create acc1: %v", err)
+	}
+	acc2, err := service.CreateAccount("Integrity Acc 2", Equity, "USD", "CASH", "INDIVIDUAL", nil)
+	if err != nil {
+		t.Fatalf("Failed to create acc2: %v", err)
+	}
+	_, err = service.PostTransaction(fmt.Sprintf("REF-%d", time.Now().UnixNano()), "Integrity", []Entry{
+		{AccountID: acc1.ID, Direction: Debit, Amount: 250},
+		{AccountID: acc2.ID, Direction: Credit, Amount: 250},
+	})
+	if err != nil {
+		t.Fatalf("Failed to post transaction: %v", err)
+	}
+
+	// Corrupt the cached balance behind the ledger's back
+	if _, err := db.Exec(`UPDATE accounts SET balance = balance + 7 WHERE id = $1`, acc1.ID); err != nil {
+		t.Fatalf("Failed to corrupt balance: %v", err)
+	}
+
+	findDrift := func(r *IntegrityReport) *BalanceDrift {
+		for i := range r.Drifts {
+			if r.Drifts[i].AccountID == acc1.ID {
+				return &r.Drifts[i]
+			}
+		}
+		return nil
+	}
+
+	report, err := service.VerifyIntegrity(false)
+	if err != nil {
+		t.Fatalf("VerifyIntegrity failed: %v", err)
+	}
+	d := findDrift(report)
+	if d == nil {
+		t.Fatalf("Expected drift on %s", acc1.ID)
+	}
+	if d.CachedBalance != 257 || d.ComputedBalance != 250 || d.Drift != 7 || d.Repaired {
+		t.Errorf("Unexpected drift %+v", *d)
+	}
+	if report.OK {
+		t.Error("Expected report not to be OK")
+	}
+	if acc, _ := service.GetAccount(acc1.ID); acc.Balance != 257 {
+		t.Errorf("Verification without repair changed the balance to %d", acc.Balance)
+	}
+
+	report, err = service.VerifyIntegrity(true)
+	if err != nil {
+		t.Fatalf("VerifyIntegrity with repair failed: %v", err)
+	}
+	if d := findDrift(report); d == nil || !d.Repaired {
+		t.Fatalf("Expected drift on %s to be repaired", acc1.ID)
+	}
+	if acc, _ := service.GetAccount(acc1.ID); acc.Balance != 250 {
+		t.Errorf("Expected repaired balance 250, got %d", acc.Balance)
+	}
+
+	var audits int
+	err = db.QueryRow(`SELECT COUNT(*) FROM audit_logs WHERE entity_id = $1 AND action = 'BALANCE_REPAIR'`, acc1.ID).Scan(&audits)
+	if err != nil {
+		t.Fatalf("Failed to count audit logs: %v", err)
+	}
+	if audits != 1 {
+		t.Errorf("Expected 1 repair audit log, got %d", audits)
+	}
+
+	stored, err := service.GetIntegrityReport(report.ID)
+	if err != nil || stored == nil {
+		t.Fatalf("Failed to load stored report: %v", err)
+	}
+	if stored.Repaired != report.Repaired {
+		t.Errorf("Stored report repaired = %d, want %d", stored.Repaired, report.Repaired)
+	}
+}
+
+func TestValidPeriodTransition(t *testing.T) {
+	cases := []struct {
+		from, to PeriodStatus
+		want     bool
+	}{
+		{PeriodOpen, PeriodSoftClosed, true},
+		{PeriodOpen, PeriodHardClosed, false},
+		{PeriodSoftClosed, PeriodOpen, true},
+		{PeriodSoftClosed, PeriodHardClosed, true},
+		{PeriodHardClosed, PeriodOpen, false},
+		{PeriodHardClosed, PeriodSoftClosed, false},
+		{PeriodOpen, PeriodOpen, false},
+		{PeriodOpen, "ARCHIVED", false},
+	}
+	for _, c := range cases {
+		if got := validPeriodTransition(c.from, c.to); got != c.want {
+			t.Errorf("validPeriodTransition(%s, %s) = %v, want %v", c.from, c.to, got, c.want)
+		}
+	}
+}
+
+func TestPeriodCloseAndYearEnd(t *testing.T) {
+	db, err := connectDB()
+	if err != nil {
+		t.Skip("Skipping test: could not connect to database")
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		t.Skip("Skipping test: database not reachable")
+	}
+
+	service := NewService(db)
+	// Book into a far-past year no other test uses
+	service.SetPostingPolicy(PostingPolicy{MaxBackdateDays: 1 << 20, MaxForwardDays: 30})
+	year := 1000 + int(time.Now().UnixNano()%800)
+	start := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(year, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	period, err := service.CreatePeriod(fmt.Sprintf("FY%d-%d", year, time.Now().UnixNano()), start, end)
+	if err != nil {
+		t.Fatalf("Failed to create period: %v", err)
+	}
+	t.Cleanup(func() { db.Exec(`DELETE FROM accounting_periods WHERE id = $1`, period.ID) })
+
+	if _, err := service.CreatePeriod(fmt.Sprintf("Overlap-%d", time.Now().UnixNano()), end, end.AddDate(0, 1, 0)); !errors.Is(err, ErrPeriodOverlap) {
+		t.Errorf("Expected ErrPeriodOverlap, got %v", err)
+	}
+
+	cash, _ := service.CreateAccount("YE Cash", Asset, "CHF", "CASH", "INDIVIDUAL", nil)
+	income, _ := service.CreateAccount("YE Income", Income, "CHF", "CASH", "INDIVIDUAL", nil)
+	expense, _ := service.CreateAccount("YE Expense", Expense, "CHF", "CASH", "INDIVIDUAL", nil)
+	retained, err := service.CreateAccount("YE Retained Earnings", Equity, "CHF", "CASH", "INDIVIDUAL", nil)
+	if err != nil {
+		t.Fatalf("Failed to create accounts: %v", err)
+	}
+	if err := service.SetRetainedEarningsAccount(retained.ID); err != nil {
+		t.Fatalf("Failed to set retained earnings account: %v", err)
+	}
+
+	post := func(debit, credit uuid.UUID, amount int64) error {
+		_, err := service.Post(PostingRequest{
+			Reference:     fmt.Sprintf("REF-%d", time.Now().UnixNano()),
+			Entries:       []Entry{{AccountID: debit, Direction: Debit, Amount: amount}, {AccountID: credit, Direction: Credit, Amount: amount}},
+			ValueDate:     start.AddDate(0, 6, 0),
+			EffectiveDate: start.AddDate(0, 6, 0),
+		})
+		return err
+	}
+	if err := post(cash.ID, income.ID, 1000); err != nil {
+		t.Fatalf("Failed to post income: %v", err)
+	}
+	if err := post(expense.ID, cash.ID, 300); err != nil {
+		t.Fatalf("Failed to post expense: %v", err)
+	}
+
+	// Closing needs a soft-closed period
+	if _, err := service.CloseYear("CHF", end); !errors.Is(err, ErrInvalidPeriodStatus) {
+		t.Errorf("Expected ErrInvalidPeriodStatus closing an open period, got %v", err)
+	}
+	if _, err := service.SetPeriodStatus(period.ID, PeriodSoftClosed); err != nil {
+		t.Fatalf("Failed to soft-close period: %v", err)
+	}
+	if err := post(cash.ID, income.ID, 1); !errors.Is(err, ErrPeriodClosed) {
+		t.Errorf("Expected ErrPeriodClosed posting into a soft-closed period, got %v", err)
+	}
+
+	result, err := service.CloseYear("CHF", end)
+	if err != nil {
+		t.Fatalf("CloseYear failed: %v", err)
+	}
+	if result.Transaction == nil {
+		t.Fatal("Expected a closing transaction")
+	}
+	for _, c := range []struct {
+		id   uuid.UUID
+		want int64
+	}{{income.ID, 0}, {expense.ID, 0}, {retained.ID, -700}, {cash.ID, 700}} {
+		got, err := service.GetBalanceAsOf(c.id, end)
+		if err != nil {
+			t.Fatalf("GetBalanceAsOf failed: %v", err)
+		}
+		if got != c.want {
+			t.Errorf("Balance of %s at year end = %d, want %d", c.id, got, c.want)
+		}
+	}
+
+	// The closed year's income statement still shows its result
+	is, err := service.GetIncomeStatement("CHF", start, end)
+	if err != nil {
+		t.Fatalf("GetIncomeStatement failed: %v", err)
+	}
+	if is.TotalIncome != 1000 || is.TotalExpenses != 300 || is.NetIncome != 700 {
+		t.Errorf("Expected income 1000, expenses 300, net 700 after closing; got %d, %d, %d", is.TotalIncome, is.TotalExpenses, is.NetIncome)
+	}
+	bs, err := service.GetBalanceSheet("CHF", end)
+	if err != nil {
+		t.Fatalf("GetBalanceSheet failed: %v", err)
+	}
+	if bs.CurrentEarnings != 0 || !bs.Balanced {
+		t.Errorf("Expected no current earnings on a balanced sheet after closing, got %d (balanced %v)", bs.CurrentEarnings, bs.Balanced)
+	}
+
+	// Closing again finds nothing left to close
+	again, err := service.CloseYear("CHF", end)
+	if err != nil {
+		t.Fatalf("Second CloseYear failed: %v", err)
+	}
+	if again.Transaction != nil || again.AccountsClosed != 0 {
+		t.Errorf("Expected nothing to close the second time, got %d accounts", again.AccountsClosed)
+	}
+
+	if _, err := service.SetPeriodStatus(period.ID, PeriodHardClosed); err != nil {
+		t.Fatalf("Failed to hard-close period: %v", err)
+	}
+	if _, err := service.SetPeriodStatus(period.ID, PeriodOpen); !errors.Is(err, ErrInvalidPeriodStatus) {
+		t.Errorf("Expected a hard-closed period to stay closed, got %v", err)
+	}
+}
+
+func TestPostingAllowed(t *testing.T) {
+	cases := []struct {
+		status    AccountStatus
+		scope     FreezeScope
+		direction EntryDirection
+		want      bool
+	}{
+		{AccountActive, "", Debit, true},
+		{AccountActive, "", Credit, true},
+		{AccountPending, "", Credit, false},
+		{AccountDormant, "", Debit, false},
+		{AccountDormant, "", Credit, true},
+		{AccountFrozen, FreezeDebits, Debit, false},
+		{AccountFrozen, FreezeDebits, Credit, true},
+		{AccountFrozen, FreezeCredits, Debit, true},
+		{AccountFrozen, FreezeCredits, Credit, false},
+		{AccountFrozen, FreezeAll, Debit, false},
+		{AccountFrozen, FreezeAll, Credit, false},
+		{AccountClosed, "", Credit, false},
+	}
+	for _, c := range cases {
+		if got := postingAllowed(c.status, c.scope, c.direction); got != c.want {
+			t.Errorf("postingAllowed(%s, %q, %s) = %v, want %v", c.status, c.scope, c.direction, got, c.want)
+		}
+	}
+
+	if validAccountTransition(AccountClosed, AccountActive) {
+		t.Error("A closed account must not be reopened")
+	}
+	if validAccountTransition(AccountFrozen, AccountClosed) {
+		t.Error("A frozen account must be unfrozen before closing")
+	}
+	if !validAccountTransition(AccountPending, AccountActive) {
+		t.Error("A pending account must be activatable")
+	}
+}
+
+func TestAccountLifecycle(t *testing.T) {
+	db, err := connectDB()
+	if err != nil {
+		t.Skip("Skipping test: could not connect to database")
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		t.Skip("Skipping test: database not reachable")
+	}
+
+	service := NewService(db)
+
+	cash, err := service.GetOrCreateSystemAccount("Cash In", Asset, "USD")
+	if err != nil {
+		t.Fatalf("Failed to get cash account: %v", err)
+	}
+	acc, err := service.CreatePendingAccount("Lifecycle Deposit", Liability, "USD", "CASH", "INDIVIDUAL", nil)
+	if err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+	if acc.Status != AccountPending {
+		t.Fatalf("Expected PENDING, got %s", acc.Status)
+	}
+
+	deposit := func(amount int64) error {
+		_, err := service.PostTransaction(fmt.Sprintf("REF-%d", time.Now().UnixNano()), "Deposit", []Entry{
+			{AccountID: cash, Direction: Debit, Amount: amount},
+			{AccountID: acc.ID, Direction: Credit, Amount: amount},
+		})
+		return err
+	}
+	withdraw := func(amount int64) error {
+		_, err := service.PostTransaction(fmt.Sprintf("REF-%d", time.Now().UnixNano()), "Withdraw", []Entry{
+			{AccountID: acc.ID, Direction: Debit, Amount: amount},
+			{AccountID: cash, Direction: Credit, Amount: amount},
+		})
+		return err
+	}
+	setStatus := func(status AccountStatus, scope FreezeScope) error {
+		_, err := service.SetAccountStatus(acc.ID, AccountStatusChange{Status: status, FreezeScope: scope, Reason: "test"})
+		return err
+	}
+
+	if err := deposit(100); !errors.Is(err, ErrAccountNotPostable) {
+		t.Errorf("Expected ErrAccountNotPostable on a PENDING account, got %v", err)
+	}
+	if err := setStatus(AccountActive, ""); err != nil {
+		t.Fatalf("Failed to activate: %v", err)
+	}
+	if err := deposit(100); err != nil {
+		t.Fatalf("Deposit to active account failed: %v", err)
+	}
+
+	// Debit freeze: money can come in but not go out
+	if err := setStatus(AccountFrozen, FreezeDebits); err != nil {
+		t.Fatalf("Failed to freeze: %v", err)
+	}
+	if err := withdraw(10); !errors.Is(err, ErrAccountNotPostable) {
+		t.Errorf("Expected ErrAccountNotPostable withdrawing from a debit-frozen account, got %v", err)
+	}
+	if err := deposit(10); err != nil {
+		t.Errorf("Deposit to a debit-frozen account failed: %v", err)
+	}
+	if err := setStatus(AccountClosed, ""); !errors.Is(err, ErrInvalidAccountState) {
+		t.Errorf("Expected ErrInvalidAccountState closing a frozen account, got %v", err)
+	}
+	if err := setStatus(AccountActive, ""); err != nil {
+		t.Fatalf("Failed to unfreeze: %v", err)
+	}
+
+	// Closing needs a zero balance
+	if err := setStatus(AccountClosed, ""); !errors.Is(err, ErrAccountNotEmpty) {
+		t.Errorf("Expected ErrAccountNotEmpty closing a funded account, got %v", err)
+	}
+	if err := withdraw(110); err != nil {
+		t.Fatalf("Failed to empty account: %v", err)
+	}
+	if err := setStatus(AccountClosed, ""); err != nil {
+		t.Fatalf("Failed to close empty account: %v", err)
+	}
+	if err := deposit(1); !errors.Is(err, ErrAccountNotPostable) {
+		t.Errorf("Expected ErrAccountNotPostable on a CLOSED account, got %v", err)
+	}
+	if err := setStatus(AccountActive, ""); !errors.Is(err, ErrInvalidAccountState) {
+		t.Errorf("Expected ErrInvalidAccountState reopening a closed account, got %v", err)
+	}
+}
+
+func TestValidateAndExpandTemplate(t *testing.T) {
+	deposit := PostingTemplate{EventType: EventDeposit, Description: "Deposit", Lines: []PostingTemplateLine{
+		{Direction: Debit, Role: RoleGL, GLCode: GLCodePaymentSettlement},
+		{Direction: Credit, Role: RoleAccount},
+	}}
+	if err := validateTemplate(&deposit); err != nil {
+		t.Fatalf("Expected default deposit template to be valid, got %v", err)
+	}
+
+	invalid := map[string]PostingTemplate{
+		"unknown event":   {EventType: "LOTTERY", Description: "x", Lines: deposit.Lines},
+		"unbalanced":      {EventType: EventFee, Description: "x", Lines: append([]PostingTemplateLine{{Direction: Debit, Role: RoleAccount}}, deposit.Lines...)},
+		"no account line": {EventType: EventFee, Description: "x", Lines: []PostingTemplateLine{{Direction: Debit, Role: RoleGL, GLCode: "1200"}, {Direction: Credit, Role: RoleGL, GLCode: "4100"}}},
+		"gl without code": {EventType: EventFee, Description: "x", Lines: []PostingTemplateLine{{Direction: Debit, Role: RoleAccount}, {Direction: Credit, Role: RoleGL}}},
+		"single line":     {EventType: EventFee, Description: "x", Lines: deposit.Lines[:1]},
+	}
+	for name, tmpl := range invalid {
+		if err := validateTemplate(&tmpl); !errors.Is(err, ErrInvalidTemplate) {
+			t.Errorf("%s: expected ErrInvalidTemplate, got %v", name, err)
+		}
+	}
+
+	account, settlement := uuid.New(), uuid.New()
+	entries, err := expandTemplate(deposit.Lines, 500, "USD", func(l PostingTemplateLine) (uuid.UUID, error) {
+		if l.Role == RoleGL {
+			return settlement, nil
+		}
+		return account, nil
+	})
+	if err != nil {
+		t.Fatalf("expandTemplate failed: %v", err)
+	}
+	if len(entries) != 2 || entries[0].AccountID != settlement || entries[0].Direction != Debit ||
+		entries[1].AccountID != account || entries[1].Direction != Credit || entries[1].Amount != 500 || entries[1].Currency != "USD" {
+		t.Errorf("Unexpected entries %+v", entries)
+	}
+}
+
+func TestEventPosterRequest(t *testing.T) {
+	payable := uuid.New()
+	poster := &eventPoster{
+		account: &Account{ID: uuid.New(), Currency: "CHF"},
+		tmpl: &PostingTemplate{EventType: EventInterestAccrual, Description: "Interest accrual", Lines: []PostingTemplateLine{
+			{Direction: Debit, Role: RoleGL, GLCode: GLCodeInterestExpense},
+			{Direction: Credit, Role: RoleGL, GLCode: GLCodeInterestPayable},
+		}},
+		systemAccounts: map[string]uuid.UUID{GLCodeInterestExpense: uuid.New(), GLCodeInterestPayable: payable},
+	}
+	valueDate := DateOf(time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC))
+	req, err := poster.request(42, "INT-1", valueDate)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if req.Reference != "INT-1" || req.Description != "Interest accrual" || !req.ValueDate.Equal(valueDate) ||
+		len(req.Entries) != 2 || req.Entries[1].AccountID != payable || req.Entries[1].Amount != 42 || req.Entries[1].Currency != "CHF" {
+		t.Errorf("Unexpected request %+v", req)
+	}
+	if _, err := poster.request(0, "INT-2", valueDate); err == nil {
+		t.Error("Expected an error for a zero amount")
+	}
+}
+
+func TestPostEvent_ProductTemplate(t *testing.T) {
+	db, err := connectDB()
+	if err != nil {
+		t.Skip("Skipping test: could not connect to database")
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		t.Skip("Skipping test: database not reachable")
+	}
+
+	service := NewService(db)
+
+	product, err := service.CreateProduct(fmt.Sprintf("Template Product %d", time.Now().UnixNano()), 0)
+	if err != nil {
+		t.Fatalf("Failed to create product: %v", err)
+	}
+	acc, err := service.CreateAccount("Template Fee Account", Liability, "USD", "CASH", "INDIVIDUAL", nil)
+	if err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+	if err := service.AssignProduct(acc.ID, product.ID); err != nil {
+		t.Fatalf("Failed to assign product: %v", err)
+	}
+
+	// Fees on this product are booked as interest income instead of fee income
+	tmpl, err := service.CreatePostingTemplate(PostingTemplate{
+		EventType:   EventFee,
+		ProductID:   &product.ID,
+		Description: "Product Fee",
+		Lines: []PostingTemplateLine{
+			{Direction: Debit, Role: RoleAccount},
+			{Direction: Credit, Role: RoleGL, GLCode: GLCodeInterestIncome},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create template: %v", err)
+	}
+	defer service.DeletePostingTemplate(tmpl.ID)
+
+	tx, err := service.PostEvent(PostingEvent{Type: EventFee, AccountID: acc.ID, Amount: 25, Reference: fmt.Sprintf("FEE-%d", time.Now().UnixNano())})
+	if err != nil {
+		t.Fatalf("PostEvent failed: %v", err)
+	}
+	if tx.Description != "Product Fee" {
+		t.Errorf("Expected the product template's description, got %q", tx.Description)
+	}
+	income, err := service.GetOrCreateGLSystemAccount(GLCodeInterestIncome, "USD")
+	if err != nil {
+		t.Fatalf("Failed to get income account: %v", err)
+	}
+	found := false
+	for _, e := range tx.Entries {
+		if e.AccountID == income && e.Direction == Credit && e.Amount == 25 {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected a 25 credit to the interest income account, got %+v", tx.Entries)
+	}
+
+	if _, err := service.CreatePostingTemplate(*tmpl); !errors.Is(err, ErrInvalidTemplate) {
+		t.Errorf("Expected ErrInvalidTemplate for a duplicate scope, got %v", err)
+	}
+}
+
+func TestGetOrCreateSystemAccount_Concurrent(t *testing.T) {
+	db, err := connectDB()
+	if err != nil {
+		t.Skip("Skipping test: could not connect to database")
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		t.Skip("Skipping test: database not reachable")
+	}
+
+	service := NewService(db)
+	suffix := time.Now().UnixNano() % 1000000
+	code := fmt.Sprintf("4%06d", suffix)
+	if _, err := service.CreateGLAccount(code, "Concurrent Income", Income, "4000", false, true); err != nil {
+		t.Fatalf("Failed to create GL account: %v", err)
+	}
+	name := fmt.Sprintf("Concurrent Suspense %d", suffix)
+
+	// Every first use racing to create the account must get the same one
+	const callers = 8
+	glIDs := make([]uuid.UUID, callers)
+	namedIDs := make([]uuid.UUID, callers)
+	errs := make([]error, 2*callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			glIDs[i], errs[i] = service.GetOrCreateGLSystemAccount(code, "USD")
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			namedIDs[i], errs[callers+i] = service.GetOrCreateSystemAccount(name, Liability, "USD")
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			t.Fatalf("GetOrCreate failed: %v", err)
+		}
+	}
+	for i := 1; i < callers; i++ {
+		if glIDs[i] != glIDs[0] {
+			t.Errorf("Expected one GL system account, got %s and %s", glIDs[0], glIDs[i])
+		}
+		if namedIDs[i] != namedIDs[0] {
+			t.Errorf("Expected one named system account, got %s and %s", namedIDs[0], namedIDs[i])
+		}
+	}
+}
+
+func TestGetOrCreateGLSystemAccount_SameName(t *testing.T) {
+	db, err := connectDB()
+	if err != nil {
+		t.Skip("Skipping test: could not connect to database")
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		t.Skip("Skipping test: database not reachable")
+	}
+
+	// GL names need not be unique; each GL account still gets its own system account
+	service := NewService(db)
+	suffix := time.Now().UnixNano() % 1000000
+	name := fmt.Sprintf("Commission Income %d", suffix)
+	var ids []uuid.UUID
+	for _, code := range []string{fmt.Sprintf("4%06dA", suffix), fmt.Sprintf("4%06dB", suffix)} {
+		if _, err := service.CreateGLAccount(code, name, Income, "4000", false, true); err != nil {
+			t.Fatalf("Failed to create GL account %s: %v", code, err)
+		}
+		id, err := service.GetOrCreateGLSystemAccount(code, "USD")
+		if err != nil {
+			t.Fatalf("GetOrCreateGLSystemAccount(%s) failed: %v", code, err)
+		}
+		ids = append(ids, id)
+	}
+	if ids[0] == ids[1] {
+		t.Errorf("Expected a system account per GL account, got %s for both", ids[0])
+	}
+
+	// A named system account may share the name too
+	named, err := service.GetOrCreateSystemAccount(name, Income, "EUR")
+	if err != nil {
+		t.Fatalf("GetOrCreateSystemAccount failed: %v", err)
+	}
+	if named == ids[0] || named == ids[1] {
+		t.Errorf("Expected a separate EUR account, got %s", named)
+	}
+}
+
+func TestListCursorKeyMustBeTimestamp(t *testing.T) {
+	// Rejected before any query is run
+	service := NewService(nil)
+	page := common.PageRequest{Cursor: common.EncodeCursor("not-a-time", uuid.New())}
+	if _, _, err := service.ListAccounts(page); !errors.Is(err, common.ErrInvalidCursor) {
+		t.Errorf("ListAccounts: expected ErrInvalidCursor, got %v", err)
+	}
+	if _, _, err := service.GetTransactions(TransactionFilter{AccountID: uuid.New()}, page); !errors.Is(err, common.ErrInvalidCursor) {
+		t.Errorf("GetTransactions: expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestGetTransactions_CursorAndFilters(t *testing.T) {
+	db, err := connectDB()
+	if err != nil {
+		t.Skip("Skipping test: could not connect to database")
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		t.Skip("Skipping test: database not reachable")
+	}
+
+	service := NewService(db)
+
+	cash, err := service.GetOrCreateSystemAccount("Cash In", Asset, "USD")
+	if err != nil {
+		t.Fatalf("Failed to get cash account: %v", err)
+	}
+	acc, err := service.CreateAccount("History Account", Liability, "USD", "CASH", "INDIVIDUAL", nil)
+	if err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+	prefix := fmt.Sprintf("HIST-%d-", time.Now().UnixNano())
+	for i := 1; i <= 5; i++ {
+		_, err := service.PostTransaction(fmt.Sprintf("%s%d", prefix, i), "Deposit", []Entry{
+			{AccountID: cash, Direction: Debit, Amount: int64(i * 100)},
+			{AccountID: acc.ID, Direction: Credit, Amount: int64(i * 100)},
+		})
+		if err != nil {
+			t.Fatalf("Failed to post: %v", err)
+		}
+	}
+
+	// Page through two at a time: 2 + 2 + 1, newest first, no duplicates
+	seen := make(map[uuid.UUID]bool)
+	var refs []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("Too many pages")
+		}
+		page, next, err := service.GetTransactions(TransactionFilter{AccountID: acc.ID}, common.PageRequest{Limit: 2, Cursor: cursor})
+		if err != nil {
+			t.Fatalf("GetTransactions failed: %v", err)
+		}
+		for _, tx := range page {
+			if seen[tx.ID] {
+				t.Errorf("Transaction %s returned twice", tx.ID)
+			}
+			seen[tx.ID] = true
+			refs = append(refs, tx.Reference)
+			if len(tx.Entries) != 2 {
+				t.Errorf("Expected 2 entries on %s, got %d", tx.Reference, len(tx.Entries))
+			}
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	if len(refs) != 5 || refs[0] != prefix+"5" || refs[4] != prefix+"1" {
+		t.Errorf("Expected 5 transactions newest first, got %v", refs)
+	}
+
+	filtered, _, err := service.GetTransactions(TransactionFilter{
+		AccountID: acc.ID, Direction: Credit, MinAmount: 200, MaxAmount: 400, ReferencePrefix: prefix, CounterpartyID: cash,
+	}, common.PageRequest{})
+	if err != nil {
+		t.Fatalf("GetTransactions failed: %v", err)
+	}
+	if len(filtered) != 3 {
+		t.Errorf("Expected 3 transactions between 200 and 400, got %d", len(filtered))
+	}
+	if none, _, _ := service.GetTransactions(TransactionFilter{AccountID: acc.ID, Direction: Debit}, common.PageRequest{}); len(none) != 0 {
+		t.Errorf("Expected no debits, got %d", len(none))
+	}
+}
+
+func TestGenerateStatement(t *testing.T) {
+	db, err := connectDB()
+	if err != nil {
+		t.Skip("Skipping test: could not connect to database")
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		t.Skip("Skipping test: database not reachable")
+	}
+
+	service := NewService(db)
+
+	cash, err := service.GetOrCreateSystemAccount("Cash In", Asset, "USD")
+	if err != nil {
+		t.Fatalf("Failed to get cash account: %v", err)
+	}
+	acc, err := service.CreateAccount("Statement Account", Liability, "USD", "CASH", "INDIVIDUAL", nil)
+	if err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+	prefix := fmt.Sprintf("STMT-%d-", time.Now().UnixNano())
+	for i, e := range []struct {
+		dir    EntryDirection
+		amount int64
+	}{{Credit, 1000}, {Debit, 300}} {
+		cashDir := Debit
+		if e.dir == Debit {
+			cashDir = Credit
+		}
+		_, err := service.PostTransaction(fmt.Sprintf("%s%d", prefix, i), "Statement test", []Entry{
+			{AccountID: cash, Direction: cashDir, Amount: e.amount},
+			{AccountID: acc.ID, Direction: e.dir, Amount: e.amount},
+		})
+		if err != nil {
+			t.Fatalf("Failed to post: %v", err)
+		}
+	}
+
+	today := DateOf(time.Now())
+	st, err := service.GenerateStatement(acc.ID, today, today)
+	if err != nil {
+		t.Fatalf("GenerateStatement failed: %v", err)
+	}
+	if st.OpeningBalance != 0 || st.ClosingBalance != 700 {
+		t.Errorf("Expected opening 0 and closing 700, got %d and %d", st.OpeningBalance, st.ClosingBalance)
+	}
+	if len(st.Lines) != 2 || st.Lines[0].Balance != 1000 {
+		t.Fatalf("Expected 2 lines starting at 1000, got %+v", st.Lines)
+	}
+
+	if err := service.SaveStatement(st); err != nil {
+		t.Fatalf("SaveStatement failed: %v", err)
+	}
+	stored, err := service.GetStatement(*st.ID)
+	if err != nil {
+		t.Fatalf("GetStatement failed: %v", err)
+	}
+	if stored.ClosingBalance != 700 || len(stored.Lines) != 2 {
+		t.Errorf("Stored statement differs: %+v", stored)
+	}
+	if _, err := service.GetStatement(uuid.New()); !errors.Is(err, ErrStatementNotFound) {
+		t.Errorf("Expected ErrStatementNotFound, got %v", err)
+	}
+}
+
+func TestPost_Metadata(t *testing.T) {
+	db, err := connectDB()
+	if err != nil {
+		t.Skip("Skipping test: could not connect to database")
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		t.Skip("Skipping test: database not reachable")
+	}
+
+	service := NewService(db)
+
+	cash, err := service.GetOrCreateSystemAccount("Cash In", Asset, "USD")
+	if err != nil {
+		t.Fatalf("Failed to get cash account: %v", err)
+	}
+	acc, err := service.CreateAccount("Metadata Account", Liability, "USD", "CASH", "INDIVIDUAL", nil)
+	if err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+	invoice := fmt.Sprintf("INV-%d", time.Now().UnixNano())
+	posted, err := service.Post(PostingRequest{
+		Reference:   invoice,
+		Description: "Invoice payment",
+		Metadata:    Metadata{"invoice": invoice, "channel": "api"},
+		Entries: []Entry{
+			{AccountID: cash, Direction: Debit, Amount: 700},
+			{AccountID: acc.ID, Direction: Credit, Amount: 700, Narrative: "Payment for " + invoice,
+				Counterparty: &Counterparty{Name: "ACME Ltd", AccountNumber: "DE89370400440532013000"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Post failed: %v", err)
+	}
+
+	// Search by metadata across all accounts
+	found, _, err := service.GetTransactions(TransactionFilter{Metadata: map[string]string{"invoice": invoice, "channel": ""}}, common.PageRequest{})
+	if err != nil {
+		t.Fatalf("GetTransactions failed: %v", err)
+	}
+	if len(found) != 1 || found[0].ID != posted.ID {
+		t.Fatalf("Expected only %s, got %d transactions", invoice, len(found))
+	}
+	if found[0].Metadata["channel"] != "api" {
+		t.Errorf("Expected metadata to be read back, got %v", found[0].Metadata)
+	}
+	for _, e := range found[0].Entries {
+		if e.AccountID == acc.ID && (e.Narrative == "" || e.Counterparty == nil || e.Counterparty.Name != "ACME Ltd") {
+			t.Errorf("Expected narrative and counterparty on the account entry, got %+v", e)
+		}
+	}
+
+	var payload []byte
+	err = db.QueryRow(`SELECT payload FROM outbox_events WHERE aggregate_id = $1 AND event_type = $2`, posted.ID.String(), EventTransactionPosted).Scan(&payload)
+	if err != nil {
+		t.Fatalf("Failed to load event: %v", err)
+	}
+	if !strings.Contains(string(payload), invoice) || !strings.Contains(string(payload), "ACME Ltd") {
+		t.Errorf("Expected metadata and counterparty in the event payload, got %s", payload)
+	}
+}
+
+func TestPendingTransactions(t *testing.T) {
+	db, err := connectDB()
+	if err != nil {
+		t.Skip("Skipping test: could not connect to database")
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		t.Skip("Skipping test: database not reachable")
+	}
+
+	service := NewService(db)
+
+	cash, err := service.GetOrCreateSystemAccount("Cash In", Asset, "USD")
+	if err != nil {
+		t.Fatalf("Failed to get system account: %v", err)
+	}
+	acc, err := service.CreateAccount("Pending User", Liability, "USD", "CASH", "INDIVIDUAL", nil)
+	if err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+	_, err = service.PostTransaction(fmt.Sprintf("DEP-%d", time.Now().UnixNano()), "Deposit", []Entry{
+		{AccountID: cash, Direction: Debit, Amount: 1000},
+		{AccountID: acc.ID, Direction: Credit, Amount: 1000},
+	})
+	if err != nil {
+		t.Fatalf("Failed to fund account: %v", err)
+	}
+
+	balance := func() *AccountBalance {
+		bal, err := service.GetAvailableBalance(acc.ID)
+		if err != nil {
+			t.Fatalf("Failed to get balance: %v", err)
+		}
+		return bal
+	}
+	authorize := func(amount int64) *Transaction {
+		p, err := service.Authorize(PostingRequest{
+			Reference:   fmt.Sprintf("AUTH-%d", time.Now().UnixNano()),
+			Description: "Card authorization",
+			Entries: []Entry{
+				{AccountID: acc.ID, Direction: Debit, Amount: amount},
+				{AccountID: cash, Direction: Credit, Amount: amount},
+			},
+		}, time.Now().Add(time.Hour))
+		if err != nil {
+			t.Fatalf("Authorize failed: %v", err)
+		}
+		return p
+	}
+
+	// Authorizing 300 holds the funds without touching the ledger balance
+	pending := authorize(300)
+	if pending.Status != TxPending {
+		t.Errorf("Expected PENDING, got %s", pending.Status)
+	}
+	if bal := balance(); bal.Balance != 1000 || bal.AvailableBalance != 700 {
+		t.Errorf("Expected balance 1000, available 700; got %+v", bal)
+	}
+	if _, err := service.ReverseTransaction(pending.ID, "test"); !errors.Is(err, ErrInvalidTxStatus) {
+		t.Errorf("Expected ErrInvalidTxStatus reversing a pending transaction, got %v", err)
+	}
+
+	// Posting for a smaller final amount releases the rest
+	posted, err := service.PostPending(pending.ID, 250)
+	if err != nil {
+		t.Fatalf("PostPending failed: %v", err)
+	}
+	if posted.ID != pending.ID || posted.Status != TxPosted || len(posted.Entries) != 2 {
+		t.Errorf("Unexpected posted transaction %+v", posted)
+	}
+	if bal := balance(); bal.Balance != 750 || bal.AvailableBalance != 750 {
+		t.Errorf("Expected balance and available 750; got %+v", bal)
+	}
+	if _, err := service.PostPending(pending.ID, 0); !errors.Is(err, ErrInvalidTxStatus) {
+		t.Errorf("Expected ErrInvalidTxStatus posting twice, got %v", err)
+	}
+
+	// Voiding releases the hold and posts nothing
+	voided := authorize(500)
+	if _, err := service.VoidPending(voided.ID, "customer cancelled"); err != nil {
+		t.Fatalf("VoidPending failed: %v", err)
+	}
+	if bal := balance(); bal.Balance != 750 || bal.AvailableBalance != 750 {
+		t.Errorf("Expected balance and available 750 after void; got %+v", bal)
+	}
+	got, err := service.GetTransaction(voided.ID)
+	if err != nil {
+		t.Fatalf("GetTransaction failed: %v", err)
+	}
+	if got.Status != TxVoided || got.VoidReason != "customer cancelled" || len(got.Entries) != 2 {
+		t.Errorf("Unexpected voided transaction %+v", got)
+	}
+	if _, err := service.PostPending(voided.ID, 0); !errors.Is(err, ErrInvalidTxStatus) {
+		t.Errorf("Expected ErrInvalidTxStatus posting a voided transaction, got %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+package ledger
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// accountTransitions lists the statuses each status may move to. FROZEN to FROZEN
+// changes the freeze scope. A frozen account must be unfrozen before it is closed.
+var accountTransitions = map[AccountStatus][]AccountStatus{
+	AccountPending: {AccountActive, AccountClosed},
+	AccountActive:  {AccountDormant, AccountFrozen, AccountClosed},
+	AccountDormant: {AccountActive, AccountFrozen, AccountClosed},
+	AccountFrozen:  {AccountActive, AccountFrozen},
+}
+
+func validAccountTransition(from, to AccountStatus) bool {
+	for _, s := range accountTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// postingAllowed reports whether an account in status may take an entry in direction.
+func postingAllowed(status AccountStatus, scope FreezeScope, direction EntryDirection) bool {
+	switch status {
+	case AccountActive:
+		return true
+	case AccountDormant:
+		return direction == Credit
+	case AccountFrozen:
+		switch scope {
+		case FreezeDebits:
+			return direction == Credit
+		case FreezeCredits:
+			return direction == Debit
+		}
+		return false
+	}
+	return false
+}
+
+// checkAccountStatuses rejects entries the account's status does not allow, and
+// entries to accounts mapped to a non-postable GL account. Callers lock the
+// accounts with lockAccounts first, so a status change cannot slip in before the
+// posting commits.
+func checkAccountStatuses(tx *sql.Tx, entries []Entry) error {
+	ids := make([]string, 0, len(entries))
+	for _, e := range entries {
+		ids = append(ids, e.AccountID.String())
+	}
+
+	rows, err := tx.Query(`
+		SELECT a.id, a.status, COALESCE(a.freeze_scope, ''), COALESCE(g.code, ''), COALESCE(g.is_postable, TRUE)
+		FROM accounts a
+		LEFT JOIN gl_accounts g ON g.id = a.gl_account_id
+		WHERE a.id = ANY($1)
+	`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to load account statuses: %w", err)
+	}
+	type state struct {
+		status   AccountStatus
+		scope    FreezeScope
+		glCode   string
+		postable bool
+	}
+	states := make(map[uuid.UUID]state)
+	for rows.Next() {
+		var id uuid.UUID
+		var st state
+		if err := rows.Scan(&id, &st.status, &st.scope, &st.glCode, &st.postable); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan account status: %w", err)
+		}
+		states[id] = st
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to load account statuses: %w", err)
+	}
+
+	for _, e := range entries {
+		st, ok := states[e.AccountID]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrAccountNotFound, e.AccountID)
+		}
+		if !postingAllowed(st.status, st.scope, e.Direction) {
+			status := string(st.status)
+			if st.scope != "" {
+				status += " (" + string(st.scope) + ")"
+			}
+			return fmt.Errorf("%w: %s to account %s, which is %s", ErrAccountNotPostable, e.Direction, e.AccountID, status)
+		}
+		if !st.postable {
+			return fmt.Errorf("%w: account %s is mapped to non-postable GL account %s", ErrAccountNotPostable, e.AccountID, st.glCode)
+		}
+	}
+	return nil
+}
+
+// AccountStatusChange is a requested lifecycle transition.
+type AccountStatusChange struct {
+	Status      AccountStatus `json:"status"`
+	FreezeScope FreezeScope   `json:"freeze_scope,omitempty"` // Required when Status is FROZEN
+	Reason      string        `json:"reason,omitempty"`
+}
+
+// SetAccountStatus moves an account to a new lifecycle status and records the change
+// in audit_logs. Closing requires a zero balance and no active holds.
+func (s *Service) SetAccountStatus(accountID uuid.UUID, change AccountStatusChange) (*Account, error) {
+	if change.Status == AccountFrozen {
+		switch change.FreezeScope {
+		case FreezeDebits, FreezeCredits, FreezeAll:
+		default:
+			return nil, fmt.Errorf("freeze_scope must be DEBIT, CREDIT or ALL")
+		}
+	} else if change.FreezeScope != "" {
+		return nil, fmt.Errorf("freeze_scope only applies to FROZEN")
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// 1. Lock the account
+	var current AccountStatus
+	var currentScope FreezeScope
+	var balance int64
+	err = tx.QueryRow(`SELECT status, COALESCE(freeze_scope, ''), balance FROM accounts WHERE id = $1 FOR UPDATE`, accountID).
+		Scan(&current, &currentScope, &balance)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, accountID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load account: %w", err)
+	}
+	if !validAccountTransition(current, change.Status) || (current == AccountFrozen && change.Status == AccountFrozen && currentScope == change.FreezeScope) {
+		return nil, fmt.Errorf("%w: %s to %s", ErrInvalidAccountState, current, change.Status)
+	}
+
+	// 2. Closing needs an empty account
+	if change.Status == AccountClosed {
+		var holds int
+		err := tx.QueryRow(`
+			SELECT COUNT(*) FROM holds
+			WHERE account_id = $1 AND status = 'ACTIVE' AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+		`, accountID).Scan(&holds)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count holds: %w", err)
+		}
+		if balance != 0 || holds > 0 {
+			return nil, fmt.Errorf("%w: balance %d, %d active holds", ErrAccountNotEmpty, balance, holds)
+		}
+	}
+
+	// 3. Update and audit
+	var scope, reason sql.NullString
+	if change.FreezeScope != "" {
+		scope = sql.NullString{String: string(change.FreezeScope), Valid: true}
+	}
+	if change.Reason != "" {
+		reason = sql.NullString{String: change.Reason, Valid: true}
+	}
+	_, err = tx.Exec(`
+		UPDATE accounts SET status = $1, freeze_scope = $2, status_reason = $3, status_changed_at = NOW()
+		WHERE id = $4
+	`, change.Status, scope, reason, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update account status: %w", err)
+	}
+
+	changes, err := json.Marshal(map[string]any{
+		"status":       map[string]any{"old": current, "new": change.Status},
+		"freeze_scope": map[string]any{"old": currentScope, "new": change.FreezeScope},
+		"reason":       change.Reason,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal audit changes: %w", err)
+	}
+	_, err = tx.Exec(`
+		INSERT INTO audit_logs (entity_name, entity_id, action, actor_id, changes)
+		VALUES ('ACCOUNT', $1, 'STATUS_CHANGE', $2, $3)
+	`, accountID, SystemActorID, changes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write audit log: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return s.GetAccount(accountID)
+}
@@ -0,0 +1,166 @@
+package ledger
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Postings lock every account they touch in one global order: customer accounts
+// first, then system accounts, each in ascending ID order. Two postings over the
+// same accounts therefore queue instead of deadlocking, whatever order their
+// entries are in. Customer accounts are locked up front so funds checks run under
+// the lock; system accounts (settlement, interest expense, ...) are hot and have no
+// funds check, so their rows are only locked by the balance update at the end of the
+// posting, which keeps them locked for as short a time as possible.
+
+// maxTxAttempts bounds how often a posting is retried after a deadlock or
+// serialization failure.
+const maxTxAttempts = 5
+
+// txRetryBackoff is the base delay before a retry; it grows with each attempt and is jittered.
+const txRetryBackoff = 10 * time.Millisecond
+
+// lockOrder returns accountIDs without duplicates in lock order: customer accounts,
+// then system accounts, each sorted by ID.
+func lockOrder(accountIDs []uuid.UUID, system map[uuid.UUID]bool) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(accountIDs))
+	order := make([]uuid.UUID, 0, len(accountIDs))
+	for _, id := range accountIDs {
+		if !seen[id] {
+			seen[id] = true
+			order = append(order, id)
+		}
+	}
+	sort.Slice(order, func(i, j int) bool {
+		if system[order[i]] != system[order[j]] {
+			return !system[order[i]]
+		}
+		return bytes.Compare(order[i][:], order[j][:]) < 0
+	})
+	return order
+}
+
+// lockAccounts locks the customer accounts among accountIDs in ID order and returns
+// all of them in lock order, the order their balances must be updated in. Unknown
+// accounts are skipped; the status and currency checks report them.
+func lockAccounts(tx *sql.Tx, accountIDs []uuid.UUID) ([]uuid.UUID, error) {
+	ids := make([]string, 0, len(accountIDs))
+	for _, id := range accountIDs {
+		ids = append(ids, id.String())
+	}
+
+	// ORDER BY before FOR NO KEY UPDATE locks the rows in ID order
+	rows, err := tx.Query(`
+		SELECT id
+		FROM accounts
+		WHERE id = ANY($1) AND account_category IS DISTINCT FROM $2
+		ORDER BY id
+		FOR NO KEY UPDATE
+	`, pq.Array(ids), categorySystem)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock accounts: %w", err)
+	}
+	locked := make(map[uuid.UUID]bool)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan account: %w", err)
+		}
+		locked[id] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to lock accounts: %w", err)
+	}
+
+	// Whatever was not locked is a system account (or missing)
+	system := make(map[uuid.UUID]bool)
+	for _, id := range accountIDs {
+		if !locked[id] {
+			system[id] = true
+		}
+	}
+	return lockOrder(accountIDs, system), nil
+}
+
+// lockAllAccounts locks every account among accountIDs, the customer accounts and
+// then the system accounts, each in ID order. A transaction that posts several times
+// takes all its locks up front this way: locking posting by posting would interleave
+// system and customer accounts and could deadlock with concurrent postings.
+func lockAllAccounts(tx *sql.Tx, accountIDs []uuid.UUID) error {
+	if _, err := lockAccounts(tx, accountIDs); err != nil {
+		return err
+	}
+	ids := make([]string, 0, len(accountIDs))
+	for _, id := range accountIDs {
+		ids = append(ids, id.String())
+	}
+	_, err := tx.Exec(`
+		SELECT id
+		FROM accounts
+		WHERE id = ANY($1) AND account_category = $2
+		ORDER BY id
+		FOR NO KEY UPDATE
+	`, pq.Array(ids), categorySystem)
+	if err != nil {
+		return fmt.Errorf("failed to lock system accounts: %w", err)
+	}
+	return nil
+}
+
+// isRetryable reports whether err aborted a transaction that can simply be run again.
+func isRetryable(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	switch pqErr.Code {
+	case "40001", // serialization_failure
+		"40P01": // deadlock_detected
+		return true
+	}
+	return false
+}
+
+// inTx runs fn in a database transaction and commits it. If the transaction fails
+// with a deadlock or serialization error it is rolled back and fn runs again in a
+// new transaction, up to maxTxAttempts times, so fn must not have side effects
+// outside tx.
+func (s *Service) inTx(fn func(tx *sql.Tx) error) error {
+	var err error
+	for attempt := 1; attempt <= maxTxAttempts; attempt++ {
+		if attempt > 1 {
+			backoff := txRetryBackoff * time.Duration(attempt*attempt)
+			time.Sleep(backoff/2 + time.Duration(rand.Int63n(int64(backoff))))
+		}
+		if err = s.runTx(fn); err == nil || !isRetryable(err) {
+			return err
+		}
+	}
+	return fmt.Errorf("giving up after %d attempts: %w", maxTxAttempts, err)
+}
+
+func (s *Service) runTx(fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
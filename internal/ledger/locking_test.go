@@ -0,0 +1,140 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+func TestLockOrder(t *testing.T) {
+	ids := []uuid.UUID{
+		uuid.MustParse("30000000-0000-0000-0000-000000000000"),
+		uuid.MustParse("10000000-0000-0000-0000-000000000000"),
+		uuid.MustParse("20000000-0000-0000-0000-000000000000"),
+		uuid.MustParse("00000000-0000-0000-0000-000000000001"),
+	}
+	system := map[uuid.UUID]bool{ids[3]: true, ids[1]: true}
+
+	// Entry order and duplicates must not matter
+	a := lockOrder([]uuid.UUID{ids[0], ids[1], ids[2], ids[3], ids[0]}, system)
+	b := lockOrder([]uuid.UUID{ids[3], ids[2], ids[1], ids[0]}, system)
+	want := []uuid.UUID{ids[2], ids[0], ids[3], ids[1]}
+	for _, got := range [][]uuid.UUID{a, b} {
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("Expected lock order %v, got %v", want, got)
+		}
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&pq.Error{Code: "40P01"}, true},
+		{fmt.Errorf("failed to update account balance: %w", &pq.Error{Code: "40001"}), true},
+		{&pq.Error{Code: "23505"}, false},
+		{ErrInsufficientFunds, false},
+		{errors.New("connection refused"), false},
+	}
+	for _, tt := range tests {
+		if got := isRetryable(tt.err); got != tt.want {
+			t.Errorf("isRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+// TestConcurrentPosting posts transfers in both directions between a ring of
+// accounts, through a shared system account, from many goroutines at once. No
+// posting may fail with a deadlock, and every cached balance must match its entries.
+func TestConcurrentPosting(t *testing.T) {
+	db, err := connectDB()
+	if err != nil {
+		t.Skip("Skipping test: could not connect to database")
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		t.Skip("Skipping test: database not reachable")
+	}
+
+	service := NewService(db)
+
+	cash, err := service.GetOrCreateSystemAccount("Cash In", Asset, "USD")
+	if err != nil {
+		t.Fatalf("Failed to get system account: %v", err)
+	}
+	const accounts, workers, transfers = 4, 16, 25
+	ids := make([]uuid.UUID, accounts)
+	for i := range ids {
+		acc, err := service.CreateAccount(fmt.Sprintf("Stress %d", i), Liability, "USD", "CASH", "INDIVIDUAL", nil)
+		if err != nil {
+			t.Fatalf("Failed to create account: %v", err)
+		}
+		ids[i] = acc.ID
+		_, err = service.PostTransaction(fmt.Sprintf("STRESS-FUND-%d-%d", i, time.Now().UnixNano()), "Funding", []Entry{
+			{AccountID: cash, Direction: Debit, Amount: 1_000_000},
+			{AccountID: acc.ID, Direction: Credit, Amount: 1_000_000},
+		})
+		if err != nil {
+			t.Fatalf("Failed to fund account: %v", err)
+		}
+	}
+
+	prefix := fmt.Sprintf("STRESS-%d", time.Now().UnixNano())
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*transfers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for n := 0; n < transfers; n++ {
+				// Alternate directions so postings lock the same accounts in opposite entry order
+				from, to := ids[(w+n)%accounts], ids[(w+n+1)%accounts]
+				if (w+n)%2 == 1 {
+					from, to = to, from
+				}
+				_, err := service.PostTransaction(fmt.Sprintf("%s-%d-%d", prefix, w, n), "Stress transfer", []Entry{
+					{AccountID: from, Direction: Debit, Amount: 7},
+					{AccountID: cash, Direction: Debit, Amount: 1},
+					{AccountID: to, Direction: Credit, Amount: 7},
+					{AccountID: cash, Direction: Credit, Amount: 1},
+				})
+				if err != nil {
+					errs <- err
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("Concurrent posting failed: %v", err)
+	}
+
+	var total int64
+	for _, id := range ids {
+		var cached, fromEntries int64
+		err := db.QueryRow(`
+			SELECT a.balance, COALESCE((
+			    SELECT SUM(CASE WHEN e.direction = 'DEBIT' THEN e.amount ELSE -e.amount END)
+			    FROM entries e WHERE e.account_id = a.id
+			), 0)
+			FROM accounts a WHERE a.id = $1
+		`, id).Scan(&cached, &fromEntries)
+		if err != nil {
+			t.Fatalf("Failed to load balance: %v", err)
+		}
+		if cached != fromEntries {
+			t.Errorf("Account %s: cached balance %d, entries sum to %d", id, cached, fromEntries)
+		}
+		total += cached
+	}
+	if total != -accounts*1_000_000 {
+		t.Errorf("Expected transfers to preserve the total %d, got %d", -accounts*1_000_000, total)
+	}
+}
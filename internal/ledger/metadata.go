@@ -0,0 +1,73 @@
+package ledger
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// MaxMetadataSize bounds the encoded size of a transaction's metadata.
+const MaxMetadataSize = 8 << 10
+
+// Metadata is free-form key/value data attached to a transaction, stored as a JSONB
+// object. Empty metadata is stored as NULL.
+type Metadata map[string]any
+
+// Value implements driver.Valuer.
+func (m Metadata) Value() (driver.Value, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(m)
+}
+
+// Scan implements sql.Scanner.
+func (m *Metadata) Scan(src any) error {
+	*m = nil
+	b, ok := src.([]byte)
+	if src == nil || ok && len(b) == 0 {
+		return nil
+	}
+	if !ok {
+		return fmt.Errorf("cannot scan %T into Metadata", src)
+	}
+	return json.Unmarshal(b, m)
+}
+
+func validateMetadata(m Metadata) error {
+	for k := range m {
+		if k == "" {
+			return fmt.Errorf("%w: empty key", ErrInvalidMetadata)
+		}
+	}
+	if b, err := json.Marshal(m); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMetadata, err)
+	} else if len(b) > MaxMetadataSize {
+		return fmt.Errorf("%w: %d bytes exceeds %d", ErrInvalidMetadata, len(b), MaxMetadataSize)
+	}
+	return nil
+}
+
+// Counterparty identifies the other party of an entry, typically outside the bank.
+type Counterparty struct {
+	Name          string `json:"name,omitempty"`
+	AccountNumber string `json:"account_number,omitempty"` // IBAN or local account number
+	BankCode      string `json:"bank_code,omitempty"`      // BIC or sort code
+}
+
+// Value implements driver.Valuer; a nil counterparty is stored as NULL.
+func (c *Counterparty) Value() (driver.Value, error) {
+	if c == nil {
+		return nil, nil
+	}
+	return json.Marshal(c)
+}
+
+// Scan implements sql.Scanner.
+func (c *Counterparty) Scan(src any) error {
+	b, ok := src.([]byte)
+	if !ok {
+		return fmt.Errorf("cannot scan %T into Counterparty", src)
+	}
+	return json.Unmarshal(b, c)
+}
@@ -0,0 +1,46 @@
+package ledger
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMetadataValueAndScan(t *testing.T) {
+	if v, err := Metadata(nil).Value(); err != nil || v != nil {
+		t.Errorf("Expected empty metadata to be stored as NULL, got %v, %v", v, err)
+	}
+
+	m := Metadata{"channel": "branch", "batch": float64(7)}
+	v, err := m.Value()
+	if err != nil {
+		t.Fatalf("Value failed: %v", err)
+	}
+	var back Metadata
+	if err := back.Scan(v); err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if back["channel"] != "branch" || back["batch"] != float64(7) {
+		t.Errorf("Round trip changed metadata: %v", back)
+	}
+	if err := back.Scan(nil); err != nil || back != nil {
+		t.Errorf("Expected NULL to scan as nil metadata, got %v, %v", back, err)
+	}
+
+	var cp Counterparty
+	if err := cp.Scan([]byte(`{"name":"ACME Ltd","bank_code":"DEUTDEFF"}`)); err != nil || cp.Name != "ACME Ltd" || cp.BankCode != "DEUTDEFF" {
+		t.Errorf("Unexpected counterparty %+v, %v", cp, err)
+	}
+}
+
+func TestValidateMetadata(t *testing.T) {
+	if err := validateMetadata(Metadata{"invoice": "INV-1"}); err != nil {
+		t.Errorf("Expected valid metadata, got %v", err)
+	}
+	if err := validateMetadata(Metadata{"": "x"}); !errors.Is(err, ErrInvalidMetadata) {
+		t.Errorf("Expected ErrInvalidMetadata for an empty key, got %v", err)
+	}
+	if err := validateMetadata(Metadata{"blob": strings.Repeat("x", MaxMetadataSize)}); !errors.Is(err, ErrInvalidMetadata) {
+		t.Errorf("Expected ErrInvalidMetadata for oversized metadata, got %v", err)
+	}
+}
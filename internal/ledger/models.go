@@ -28,9 +28,33 @@ type Account struct {
 	ClientID        uuid.NullUUID  `json:"client_id"`
 	OwnershipType   sql.NullString `json:"ownership_type"`
 	FeeScheduleID   sql.NullString `json:"fee_schedule_id"`
+	OverdraftLimit  *int64         `json:"overdraft_limit,omitempty"` // Overrides the product limit when set
+	Status          AccountStatus  `json:"status"`
+	FreezeScope     FreezeScope    `json:"freeze_scope,omitempty"` // Set only while FROZEN
+	StatusReason    string         `json:"status_reason,omitempty"`
+	GLAccountID     *uuid.UUID     `json:"gl_account_id,omitempty"` // Chart of accounts mapping
 	CreatedAt       time.Time      `json:"created_at"`
 }
 
+type AccountStatus string
+
+const (
+	AccountPending AccountStatus = "PENDING" // Opened but not yet approved: no postings
+	AccountActive  AccountStatus = "ACTIVE"
+	AccountDormant AccountStatus = "DORMANT" // Inactive: credits only until reactivated
+	AccountFrozen  AccountStatus = "FROZEN"  // Blocks the directions in FreezeScope
+	AccountClosed  AccountStatus = "CLOSED"  // Final: no postings
+)
+
+// FreezeScope is the entry direction a FROZEN account blocks.
+type FreezeScope string
+
+const (
+	FreezeDebits  FreezeScope = "DEBIT"
+	FreezeCredits FreezeScope = "CREDIT"
+	FreezeAll     FreezeScope = "ALL"
+)
+
 type ProductStatus string
 
 const (
@@ -46,15 +70,103 @@ type Product struct {
 	Status          ProductStatus `json:"status"`
 	Version         int           `json:"version"`
 	ParentProductID *uuid.UUID    `json:"parent_product_id,omitempty"`
+	OverdraftLimit  int64         `json:"overdraft_limit"`                 // Minor units an account may go below zero available
+	ControlGLID     *uuid.UUID    `json:"control_gl_account_id,omitempty"` // GL account its accounts are mapped to
+	InterestTerms                 // Day-count convention, tiers, minimum balance and rounding
 	CreatedAt       time.Time     `json:"created_at"`
 }
 
+// GLAccount is a node in the chart of accounts. Header accounts only group their
+// children; ledger accounts are mapped to the others.
+type GLAccount struct {
+	ID         uuid.UUID   `json:"id"`
+	Code       string      `json:"code"`
+	Name       string      `json:"name"`
+	Type       AccountType `json:"type"`
+	ParentID   *uuid.UUID  `json:"parent_id,omitempty"`
+	ParentCode string      `json:"parent_code,omitempty"`
+	IsHeader   bool        `json:"is_header"`
+	IsPostable bool        `json:"is_postable"` // Postings to accounts mapped here are allowed
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+// EventType is a business event that is posted through a posting template.
+type EventType string
+
+const (
+	EventDeposit         EventType = "DEPOSIT"
+	EventWithdrawal      EventType = "WITHDRAWAL"
+	EventTransfer        EventType = "TRANSFER"
+	EventInterestAccrual EventType = "INTEREST_ACCRUAL"
+	EventFee             EventType = "FEE"
+
+	// Credit interest accrues into Interest Payable and is capitalized to the
+	// account; debit interest accrues into Interest Receivable and is charged to it.
+	EventInterestCapitalization      EventType = "INTEREST_CAPITALIZATION"
+	EventDebitInterestAccrual        EventType = "DEBIT_INTEREST_ACCRUAL"
+	EventDebitInterestCapitalization EventType = "DEBIT_INTEREST_CAPITALIZATION"
+)
+
+// TemplateAccountRole selects the account a template line posts to.
+type TemplateAccountRole string
+
+const (
+	RoleAccount      TemplateAccountRole = "ACCOUNT"      // The event's account
+	RoleCounterparty TemplateAccountRole = "COUNTERPARTY" // The other account of a transfer
+	RoleGL           TemplateAccountRole = "GL"           // The system account of GLCode in the event currency
+)
+
+// PostingTemplate maps an event to ledger entries. ProductID and Currency narrow
+// the template; nil/empty means any.
+type PostingTemplate struct {
+	ID          uuid.UUID             `json:"id"`
+	EventType   EventType             `json:"event_type"`
+	ProductID   *uuid.UUID            `json:"product_id,omitempty"`
+	Currency    string                `json:"currency,omitempty"`
+	Description string                `json:"description"`
+	Lines       []PostingTemplateLine `json:"lines"`
+	CreatedAt   time.Time             `json:"created_at"`
+	UpdatedAt   time.Time             `json:"updated_at"`
+}
+
+// PostingTemplateLine posts the event amount in Direction to the account picked by Role.
+type PostingTemplateLine struct {
+	Direction EntryDirection      `json:"direction"`
+	Role      TemplateAccountRole `json:"account_role"`
+	GLCode    string              `json:"gl_code,omitempty"` // Required for RoleGL
+}
+
+// TransactionStatus is the lifecycle state of a transaction. Most transactions are
+// posted directly; authorized ones start PENDING and end POSTED or VOIDED.
+type TransactionStatus string
+
+const (
+	TxPending TransactionStatus = "PENDING" // Authorized: holds reduce available balance, no entries yet
+	TxPosted  TransactionStatus = "POSTED"
+	TxVoided  TransactionStatus = "VOIDED" // Authorization cancelled or expired, never posted
+)
+
 type Transaction struct {
-	ID          uuid.UUID `json:"id"`
-	Reference   string    `json:"reference"`
-	Description string    `json:"description"`
-	PostedAt    time.Time `json:"posted_at"`
-	Entries     []Entry   `json:"entries"`
+	ID             uuid.UUID         `json:"id"`
+	Reference      string            `json:"reference"`
+	Description    string            `json:"description"`
+	Status         TransactionStatus `json:"status"`
+	PostedAt       time.Time         `json:"posted_at"`             // For a pending or voided transaction: when it was recorded
+	ValueDate      time.Time         `json:"value_date"`            // When the money counts for balances and interest
+	EffectiveDate  time.Time         `json:"effective_date"`        // Accounting date the transaction is booked to
+	ReversalOf     *uuid.UUID        `json:"reversal_of,omitempty"` // Set on a reversal: the transaction it undoes
+	ReversedBy     *uuid.UUID        `json:"reversed_by,omitempty"` // Set on a reversed transaction: its reversal
+	ReversalReason string            `json:"reversal_reason,omitempty"`
+	Metadata       Metadata          `json:"metadata,omitempty"`
+	AuthorizedAt   *time.Time        `json:"authorized_at,omitempty"` // Set on transactions that were authorized first
+	ExpiresAt      *time.Time        `json:"expires_at,omitempty"`    // When a pending authorization is voided
+	VoidReason     string            `json:"void_reason,omitempty"`
+	Entries        []Entry           `json:"entries"` // For a pending or voided transaction: the authorized entries
+
+	closing      bool // Year-end closing entries (stored as is_closing): allowed into soft-closed periods, no funds check
+	fromPending  bool // Posts the existing pending transaction row ID instead of inserting one
+	noFundsCheck bool // Charges the bank applies regardless of available funds
 }
 
 type EntryDirection string
@@ -70,9 +182,79 @@ type Entry struct {
 	AccountID     uuid.UUID      `json:"account_id"`
 	Direction     EntryDirection `json:"direction"`
 	Amount        int64          `json:"amount"`
+	Currency      string         `json:"currency,omitempty"` // Must match the account; filled in when omitted
+	Narrative     string         `json:"narrative,omitempty"`
+	Counterparty  *Counterparty  `json:"counterparty,omitempty"`
 	CreatedAt     time.Time      `json:"created_at"`
 }
 
+// FXRate quotes 1 unit of Base in Quote (major units), e.g. EUR/USD 1.08.
+type FXRate struct {
+	ID    uuid.UUID `json:"id"`
+	Base  string    `json:"base_currency"`
+	Quote string    `json:"quote_currency"`
+	Rate  string    `json:"rate"` // Decimal string, kept exact
+	AsOf  time.Time `json:"as_of"`
+}
+
+type HoldStatus string
+
+const (
+	HoldStatusActive   HoldStatus = "ACTIVE"
+	HoldStatusCaptured HoldStatus = "CAPTURED"
+	HoldStatusReleased HoldStatus = "RELEASED"
+	HoldStatusExpired  HoldStatus = "EXPIRED"
+)
+
+// Hold reserves funds on an account without moving them.
+type Hold struct {
+	ID             uuid.UUID  `json:"id"`
+	AccountID      uuid.UUID  `json:"account_id"`
+	Amount         int64      `json:"amount"`
+	Reference      string     `json:"reference,omitempty"`
+	Description    string     `json:"description,omitempty"`
+	Status         HoldStatus `json:"status"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	CapturedAmount *int64     `json:"captured_amount,omitempty"`
+	TransactionID  *uuid.UUID `json:"transaction_id,omitempty"` // Set when captured, or from the start for a pending transaction's hold
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// AccountBalance is the funds view of an account.
+// Balance is expressed in the account's normal direction (positive for a funded
+// customer deposit even though the stored Liability balance is negative).
+type AccountBalance struct {
+	AccountID        uuid.UUID   `json:"account_id"`
+	Type             AccountType `json:"type"`
+	Currency         string      `json:"currency"`
+	LedgerBalance    int64       `json:"ledger_balance"` // Signed, as stored (debit +, credit -)
+	Balance          int64       `json:"balance"`
+	HeldAmount       int64       `json:"held_amount"`
+	AvailableBalance int64       `json:"available_balance"` // Balance - HeldAmount
+	OverdraftLimit   int64       `json:"overdraft_limit"`
+}
+
+type PeriodStatus string
+
+const (
+	PeriodOpen       PeriodStatus = "OPEN"
+	PeriodSoftClosed PeriodStatus = "SOFT_CLOSED" // Only year-end closing entries may be posted
+	PeriodHardClosed PeriodStatus = "HARD_CLOSED" // Final: nothing may be posted
+)
+
+// AccountingPeriod is a date range (inclusive) that postings are booked into by effective date.
+type AccountingPeriod struct {
+	ID        uuid.UUID    `json:"id"`
+	Name      string       `json:"name"`
+	StartDate time.Time    `json:"start_date"`
+	EndDate   time.Time    `json:"end_date"`
+	Status    PeriodStatus `json:"status"`
+	ClosedAt  *time.Time   `json:"closed_at,omitempty"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
 type ConfigStatus string
 
 const (
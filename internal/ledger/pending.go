@@ -0,0 +1,391 @@
+package ledger
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// pendingRequest is what a pending transaction will post, stored in
+// transactions.pending_request. Zero dates mean the day it is posted.
+type pendingRequest struct {
+	Entries       []Entry   `json:"entries"`
+	ValueDate     time.Time `json:"value_date"`
+	EffectiveDate time.Time `json:"effective_date"`
+}
+
+// Authorize records a transaction as PENDING without posting it. Every non-system
+// account the transaction would move against its normal balance gets a hold for
+// that amount, so the authorization reduces available balances but not ledger
+// balances. It fails like Post would if an account cannot cover its hold. The
+// transaction is later posted with PostPending or voided with VoidPending, and is
+// voided automatically once expiresAt passes.
+func (s *Service) Authorize(req PostingRequest, expiresAt time.Time) (*Transaction, error) {
+	if err := s.validateRequest(req); err != nil {
+		return nil, err
+	}
+	if !expiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("authorization expiry must be in the future")
+	}
+
+	var result *Transaction
+	err := s.inTx(func(tx *sql.Tx) error {
+		var err error
+		result, err = s.authorizeInTx(tx, req, expiresAt)
+		return err
+	})
+	return result, err
+}
+
+// authorizeInTx records the pending transaction and its holds within tx.
+func (s *Service) authorizeInTx(tx *sql.Tx, req PostingRequest, expiresAt time.Time) (*Transaction, error) {
+	// 1. Lock Accounts, Check Currencies and Account Status, as a posting would
+	entries := req.Entries
+	if err := resolveEntryCurrencies(tx, entries); err != nil {
+		return nil, err
+	}
+	net, order := netByAccount(entries)
+	if _, err := lockAccounts(tx, order); err != nil {
+		return nil, err
+	}
+	if err := checkAccountStatuses(tx, entries); err != nil {
+		return nil, err
+	}
+
+	// 2. Insert the Pending Transaction
+	t := &Transaction{
+		ID:          uuid.New(),
+		Reference:   req.Reference,
+		Description: req.Description,
+		Status:      TxPending,
+		Metadata:    req.Metadata,
+		ExpiresAt:   &expiresAt,
+		Entries:     entries,
+	}
+	doc, err := json.Marshal(pendingRequest{Entries: entries, ValueDate: DateOf(req.ValueDate), EffectiveDate: DateOf(req.EffectiveDate)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal pending request: %w", err)
+	}
+	err = tx.QueryRow(`
+		INSERT INTO transactions (id, reference, description, metadata, status, authorized_at, expires_at, pending_request)
+		VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP, $6, $7)
+		RETURNING posted_at, authorized_at, value_date, effective_date
+	`, t.ID, t.Reference, t.Description, t.Metadata, t.Status, expiresAt, doc).Scan(&t.PostedAt, &t.AuthorizedAt, &t.ValueDate, &t.EffectiveDate)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" && pqErr.Constraint == "transactions_reference_key" {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateReference, t.Reference)
+		}
+		return nil, fmt.Errorf("failed to insert transaction: %w", err)
+	}
+
+	// 3. Hold the Funds the Posting would take
+	for _, accountID := range order {
+		b, category, err := loadBalance(tx, accountID, false)
+		if err != nil {
+			return nil, err
+		}
+		amount := -NaturalBalance(b.Type, net[accountID])
+		if category == categorySystem || amount <= 0 {
+			continue
+		}
+		_, err = placeHold(tx, &Hold{
+			AccountID:     accountID,
+			Amount:        amount,
+			Reference:     t.Reference,
+			Description:   t.Description,
+			ExpiresAt:     &expiresAt,
+			TransactionID: &t.ID,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 4. Stage Event (Outbox)
+	payload := map[string]interface{}{
+		"transaction_id": t.ID,
+		"reference":      t.Reference,
+		"expires_at":     expiresAt,
+		"entries":        entries,
+	}
+	if len(t.Metadata) > 0 {
+		payload["metadata"] = t.Metadata
+	}
+	if err := s.publishEvent(tx, EventTransactionAuthorized, t.ID, payload); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// PostPending posts a pending transaction and settles its holds. finalAmount 0
+// posts the authorized entries; otherwise every entry is posted for finalAmount,
+// which is only possible when all authorized entries share one amount (as in a
+// two-line transaction). The final amount may exceed the authorized one if the
+// accounts can cover it.
+func (s *Service) PostPending(id uuid.UUID, finalAmount int64) (*Transaction, error) {
+	if finalAmount < 0 {
+		return nil, fmt.Errorf("final amount must not be negative")
+	}
+
+	var result *Transaction
+	err := s.inTx(func(tx *sql.Tx) error {
+		var err error
+		result, err = s.postPendingInTx(tx, id, finalAmount)
+		return err
+	})
+	return result, err
+}
+
+// postPendingInTx captures the holds of pending transaction id and posts it within tx.
+func (s *Service) postPendingInTx(tx *sql.Tx, id uuid.UUID, finalAmount int64) (*Transaction, error) {
+	// 1. Lock the Pending Transaction
+	t, req, err := lockPending(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if t.ExpiresAt != nil && !t.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: authorization %s expired at %s", ErrInvalidTxStatus, id, t.ExpiresAt.Format(time.RFC3339))
+	}
+
+	entries := req.Entries
+	if finalAmount > 0 {
+		for _, e := range entries {
+			if e.Amount != entries[0].Amount {
+				return nil, fmt.Errorf("final amount can only be set when all entries share one amount")
+			}
+		}
+		for i := range entries {
+			entries[i].Amount = finalAmount
+		}
+	}
+
+	// 2. Capture the Holds first, so they no longer count against the funds check
+	net, _ := netByAccount(entries)
+	rows, err := tx.Query(`SELECT id, account_id FROM holds WHERE transaction_id = $1 AND status = 'ACTIVE' FOR UPDATE`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load holds: %w", err)
+	}
+	captured := make(map[uuid.UUID]uuid.UUID)
+	for rows.Next() {
+		var holdID, accountID uuid.UUID
+		if err := rows.Scan(&holdID, &accountID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan hold: %w", err)
+		}
+		captured[holdID] = accountID
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load holds: %w", err)
+	}
+	for holdID, accountID := range captured {
+		amount := net[accountID]
+		if amount < 0 {
+			amount = -amount
+		}
+		_, err := tx.Exec(`UPDATE holds SET status = $1, captured_amount = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $3`,
+			HoldStatusCaptured, amount, holdID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to capture hold: %w", err)
+		}
+	}
+
+	// 3. Post the Entries into the existing transaction
+	t.fromPending = true
+	t.ValueDate, t.EffectiveDate = req.ValueDate, req.EffectiveDate
+	t.Entries = entries
+	posted, err := s.postInTx(tx, t)
+	if err != nil {
+		return nil, err
+	}
+
+	return posted, nil
+}
+
+// VoidPending cancels a pending transaction and releases its holds. Nothing is posted.
+func (s *Service) VoidPending(id uuid.UUID, reason string) (*Transaction, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	t, _, err := lockPending(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(`UPDATE holds SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE transaction_id = $2 AND status = 'ACTIVE'`,
+		HoldStatusReleased, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to release holds: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE transactions SET status = $1, void_reason = $2 WHERE id = $3`, TxVoided, reason, id); err != nil {
+		return nil, fmt.Errorf("failed to void transaction: %w", err)
+	}
+	t.Status, t.VoidReason = TxVoided, reason
+
+	payload := map[string]interface{}{
+		"transaction_id": t.ID,
+		"reference":      t.Reference,
+		"reason":         reason,
+	}
+	if err := s.publishEvent(tx, EventTransactionVoided, t.ID, payload); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit void: %w", err)
+	}
+	return t, nil
+}
+
+// ExpirePendingTransactions voids the pending transactions whose authorization
+// expired and returns how many were voided. Their holds stopped reducing the
+// available balance at expiry already.
+func (s *Service) ExpirePendingTransactions() (int, error) {
+	rows, err := s.db.Query(`SELECT id FROM transactions WHERE status = 'PENDING' AND expires_at <= CURRENT_TIMESTAMP ORDER BY expires_at`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find expired authorizations: %w", err)
+	}
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to find expired authorizations: %w", err)
+	}
+
+	voided := 0
+	for _, id := range ids {
+		if _, err := s.VoidPending(id, "authorization expired"); err != nil {
+			// Posted or voided meanwhile
+			if errors.Is(err, ErrInvalidTxStatus) {
+				continue
+			}
+			log.Printf("Failed to void expired transaction %s: %v", id, err)
+			continue
+		}
+		voided++
+	}
+	return voided, nil
+}
+
+// GetTransaction returns a transaction with its entries, or for a pending or voided
+// transaction its authorized entries.
+func (s *Service) GetTransaction(id uuid.UUID) (*Transaction, error) {
+	t, req, err := scanTransaction(s.db.QueryRow(transactionSelect+` WHERE t.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrTransactionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if t.Status == TxPosted {
+		if err := s.loadEntries([]*Transaction{t}); err != nil {
+			return nil, err
+		}
+	} else if req != nil {
+		t.Entries = req.Entries
+	}
+	return t, nil
+}
+
+// ListPendingTransactions returns the pending transactions that touch an account,
+// oldest first.
+func (s *Service) ListPendingTransactions(accountID uuid.UUID) ([]*Transaction, error) {
+	match, _ := json.Marshal(map[string]any{"entries": []map[string]any{{"account_id": accountID}}})
+	rows, err := s.db.Query(transactionSelect+`
+		WHERE t.status = 'PENDING' AND t.pending_request @> $1::JSONB
+		ORDER BY t.authorized_at, t.id
+	`, string(match))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var transactions []*Transaction
+	for rows.Next() {
+		t, req, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		if req != nil {
+			t.Entries = req.Entries
+		}
+		transactions = append(transactions, t)
+	}
+	return transactions, rows.Err()
+}
+
+const transactionSelect = `
+	SELECT t.id, t.reference, COALESCE(t.description, ''), t.status, t.posted_at, t.value_date, t.effective_date,
+	       t.reversal_of_id, t.reversed_by_id, COALESCE(t.reversal_reason, ''), t.metadata,
+	       t.authorized_at, t.expires_at, COALESCE(t.void_reason, ''), t.pending_request
+	FROM transactions t`
+
+func scanTransaction(row rowScanner) (*Transaction, *pendingRequest, error) {
+	var t Transaction
+	var doc []byte
+	err := row.Scan(&t.ID, &t.Reference, &t.Description, &t.Status, &t.PostedAt, &t.ValueDate, &t.EffectiveDate,
+		&t.ReversalOf, &t.ReversedBy, &t.ReversalReason, &t.Metadata, &t.AuthorizedAt, &t.ExpiresAt, &t.VoidReason, &doc)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("failed to scan transaction: %w", err)
+	}
+	if len(doc) == 0 {
+		return &t, nil, nil
+	}
+	var req pendingRequest
+	if err := json.Unmarshal(doc, &req); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode pending request: %w", err)
+	}
+	return &t, &req, nil
+}
+
+// lockPending locks a transaction and checks that it is still pending.
+func lockPending(tx *sql.Tx, id uuid.UUID) (*Transaction, *pendingRequest, error) {
+	t, req, err := scanTransaction(tx.QueryRow(transactionSelect+` WHERE t.id = $1 FOR UPDATE`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil, ErrTransactionNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if t.Status != TxPending || req == nil {
+		return nil, nil, fmt.Errorf("%w: transaction %s is %s", ErrInvalidTxStatus, id, t.Status)
+	}
+	return t, req, nil
+}
+
+// netByAccount sums the signed change (debit +, credit -) entries make per account,
+// returning the accounts in order of first appearance.
+func netByAccount(entries []Entry) (map[uuid.UUID]int64, []uuid.UUID) {
+	net := make(map[uuid.UUID]int64)
+	var order []uuid.UUID
+	for _, e := range entries {
+		if _, ok := net[e.AccountID]; !ok {
+			order = append(order, e.AccountID)
+		}
+		if e.Direction == Debit {
+			net[e.AccountID] += e.Amount
+		} else {
+			net[e.AccountID] -= e.Amount
+		}
+	}
+	return net, order
+}
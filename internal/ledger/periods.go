@@ -0,0 +1,312 @@
+package ledger
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// CreatePeriod opens a new accounting period covering start to end (inclusive).
+func (s *Service) CreatePeriod(name string, start, end time.Time) (*AccountingPeriod, error) {
+	if name == "" {
+		return nil, fmt.Errorf("period name is required")
+	}
+	start, end = DateOf(start), DateOf(end)
+	if start.IsZero() || end.IsZero() {
+		return nil, fmt.Errorf("period start and end dates are required")
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("period end date is before its start date")
+	}
+
+	p := &AccountingPeriod{Name: name, StartDate: start, EndDate: end, Status: PeriodOpen}
+	err := s.db.QueryRow(`
+		INSERT INTO accounting_periods (name, start_date, end_date, status)
+		VALUES ($1, $2::DATE, $3::DATE, $4)
+		RETURNING id, created_at, updated_at
+	`, name, start.Format("2006-01-02"), end.Format("2006-01-02"), p.Status).Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Constraint == "accounting_periods_no_overlap" {
+			return nil, fmt.Errorf("%w: %s to %s", ErrPeriodOverlap, start.Format("2006-01-02"), end.Format("2006-01-02"))
+		}
+		return nil, fmt.Errorf("failed to create accounting period: %w", err)
+	}
+	return p, nil
+}
+
+const periodColumns = `id, name, start_date, end_date, status, closed_at, created_at, updated_at`
+
+func scanPeriod(row rowScanner) (*AccountingPeriod, error) {
+	var p AccountingPeriod
+	var closedAt sql.NullTime
+	if err := row.Scan(&p.ID, &p.Name, &p.StartDate, &p.EndDate, &p.Status, &closedAt, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if closedAt.Valid {
+		p.ClosedAt = &closedAt.Time
+	}
+	return &p, nil
+}
+
+// ListPeriods returns all accounting periods in date order.
+func (s *Service) ListPeriods() ([]AccountingPeriod, error) {
+	rows, err := s.db.Query(`SELECT ` + periodColumns + ` FROM accounting_periods ORDER BY start_date`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list accounting periods: %w", err)
+	}
+	defer rows.Close()
+
+	periods := []AccountingPeriod{}
+	for rows.Next() {
+		p, err := scanPeriod(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan accounting period: %w", err)
+		}
+		periods = append(periods, *p)
+	}
+	return periods, rows.Err()
+}
+
+// validPeriodTransition reports whether a period may move from one status to another.
+// A soft close can be reopened for corrections; a hard close is final.
+func validPeriodTransition(from, to PeriodStatus) bool {
+	switch from {
+	case PeriodOpen:
+		return to == PeriodSoftClosed
+	case PeriodSoftClosed:
+		return to == PeriodOpen || to == PeriodHardClosed
+	}
+	return false
+}
+
+// SetPeriodStatus opens, soft-closes or hard-closes a period. The period row is
+// locked, so the change waits for postings already being booked into it.
+func (s *Service) SetPeriodStatus(id uuid.UUID, status PeriodStatus) (*AccountingPeriod, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	p, err := scanPeriod(tx.QueryRow(`SELECT `+periodColumns+` FROM accounting_periods WHERE id = $1 FOR UPDATE`, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrPeriodNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load accounting period: %w", err)
+	}
+	if !validPeriodTransition(p.Status, status) {
+		return nil, fmt.Errorf("%w: %s to %s", ErrInvalidPeriodStatus, p.Status, status)
+	}
+
+	p, err = scanPeriod(tx.QueryRow(`
+		UPDATE accounting_periods
+		SET status = $1,
+		    closed_at = CASE WHEN $1::VARCHAR = 'OPEN' THEN NULL ELSE COALESCE(closed_at, NOW()) END,
+		    updated_at = NOW()
+		WHERE id = $2
+		RETURNING `+periodColumns, status, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update accounting period: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return p, nil
+}
+
+// checkPeriod rejects a posting whose effective date falls in a closed period.
+// The period row is share-locked so it cannot be closed until the posting commits.
+func checkPeriod(tx *sql.Tx, effectiveDate time.Time, closing bool) error {
+	var name string
+	var status PeriodStatus
+	err := tx.QueryRow(`
+		SELECT name, status FROM accounting_periods
+		WHERE $1::DATE BETWEEN start_date AND end_date
+		FOR SHARE
+	`, effectiveDate.Format("2006-01-02")).Scan(&name, &status)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load accounting period: %w", err)
+	}
+	if status == PeriodOpen || (status == PeriodSoftClosed && closing) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s is %s (effective date %s)", ErrPeriodClosed, name, status, effectiveDate.Format("2006-01-02"))
+}
+
+// SetRetainedEarningsAccount configures the EQUITY account that year-end close
+// sweeps income and expenses into for the account's currency.
+func (s *Service) SetRetainedEarningsAccount(accountID uuid.UUID) error {
+	account, err := s.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+	if account == nil {
+		return fmt.Errorf("%w: %s", ErrAccountNotFound, accountID)
+	}
+	if account.Type != Equity {
+		return fmt.Errorf("retained earnings account must be EQUITY, got %s", account.Type)
+	}
+	_, err = s.db.Exec(`
+		INSERT INTO retained_earnings_accounts (currency, account_id)
+		VALUES ($1, $2)
+		ON CONFLICT (currency) DO UPDATE SET account_id = EXCLUDED.account_id, updated_at = NOW()
+	`, account.Currency, account.ID)
+	if err != nil {
+		return fmt.Errorf("failed to set retained earnings account: %w", err)
+	}
+	return nil
+}
+
+// GetRetainedEarningsAccounts returns the configured account per currency.
+func (s *Service) GetRetainedEarningsAccounts() (map[string]uuid.UUID, error) {
+	rows, err := s.db.Query(`SELECT currency, account_id FROM retained_earnings_accounts`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load retained earnings accounts: %w", err)
+	}
+	defer rows.Close()
+
+	accounts := make(map[string]uuid.UUID)
+	for rows.Next() {
+		var currency string
+		var id uuid.UUID
+		if err := rows.Scan(&currency, &id); err != nil {
+			return nil, fmt.Errorf("failed to scan retained earnings account: %w", err)
+		}
+		accounts[currency] = id
+	}
+	return accounts, rows.Err()
+}
+
+// YearEndClose is the outcome of closing a year in one currency.
+type YearEndClose struct {
+	Currency                string       `json:"currency"`
+	YearEnd                 time.Time    `json:"year_end"`
+	RetainedEarningsAccount uuid.UUID    `json:"retained_earnings_account_id"`
+	NetIncome               int64        `json:"net_income"` // Profit is positive
+	AccountsClosed          int          `json:"accounts_closed"`
+	Transaction             *Transaction `json:"transaction,omitempty"` // Nil when there was nothing to close
+}
+
+// CloseYear zeroes every INCOME and EXPENSE account in currency as of yearEnd into
+// the configured retained earnings account. The closing transaction is booked on
+// yearEnd, which must be the last day of a SOFT_CLOSED period, and every earlier
+// period must already be closed so no more postings can land before it.
+// Its reference is YE-<currency>-<date>, so a year can only be closed once.
+func (s *Service) CloseYear(currency string, yearEnd time.Time) (*YearEndClose, error) {
+	yearEnd = DateOf(yearEnd)
+	if yearEnd.IsZero() {
+		return nil, fmt.Errorf("year end date is required")
+	}
+	date := yearEnd.Format("2006-01-02")
+	result := &YearEndClose{Currency: currency, YearEnd: yearEnd}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// 1. The closing period must be soft-closed, and nothing before it open
+	var name string
+	var status PeriodStatus
+	err = tx.QueryRow(`SELECT name, status FROM accounting_periods WHERE end_date = $1::DATE FOR UPDATE`, date).Scan(&name, &status)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: no period ends on %s", ErrPeriodNotFound, date)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load accounting period: %w", err)
+	}
+	if status != PeriodSoftClosed {
+		return nil, fmt.Errorf("%w: year-end close needs %s to be %s, it is %s", ErrInvalidPeriodStatus, name, PeriodSoftClosed, status)
+	}
+	var open int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM accounting_periods WHERE end_date < $1::DATE AND status = 'OPEN'`, date).Scan(&open); err != nil {
+		return nil, fmt.Errorf("failed to check earlier periods: %w", err)
+	}
+	if open > 0 {
+		return nil, fmt.Errorf("%w: %d earlier periods are still open", ErrInvalidPeriodStatus, open)
+	}
+
+	// 2. Retained earnings account
+	err = tx.QueryRow(`SELECT account_id FROM retained_earnings_accounts WHERE currency = $1`, currency).Scan(&result.RetainedEarningsAccount)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrNoRetainedEarnings, currency)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load retained earnings account: %w", err)
+	}
+
+	// 3. Reverse each income and expense balance as of the year end
+	rows, err := tx.Query(`
+		SELECT a.id, SUM(CASE WHEN e.direction = 'DEBIT' THEN e.amount ELSE -e.amount END)
+		FROM accounts a
+		JOIN entries e ON e.account_id = a.id
+		JOIN transactions t ON t.id = e.transaction_id
+		WHERE a.currency = $1 AND a.type IN ('INCOME', 'EXPENSE') AND t.effective_date <= $2::DATE
+		GROUP BY a.id
+		HAVING SUM(CASE WHEN e.direction = 'DEBIT' THEN e.amount ELSE -e.amount END) <> 0
+		ORDER BY a.id
+	`, currency, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load income and expense balances: %w", err)
+	}
+	var entries []Entry
+	var net int64 // Signed sum of the balances being closed (debit +)
+	for rows.Next() {
+		var id uuid.UUID
+		var balance int64
+		if err := rows.Scan(&id, &balance); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan balance: %w", err)
+		}
+		if balance > 0 {
+			entries = append(entries, Entry{AccountID: id, Direction: Credit, Amount: balance})
+		} else {
+			entries = append(entries, Entry{AccountID: id, Direction: Debit, Amount: -balance})
+		}
+		net += balance
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load income and expense balances: %w", err)
+	}
+
+	result.AccountsClosed = len(entries)
+	result.NetIncome = -net
+	if len(entries) == 0 {
+		return result, nil
+	}
+
+	// A net credit (profit) is credited to retained earnings, a loss debited
+	switch {
+	case net < 0:
+		entries = append(entries, Entry{AccountID: result.RetainedEarningsAccount, Direction: Credit, Amount: -net})
+	case net > 0:
+		entries = append(entries, Entry{AccountID: result.RetainedEarningsAccount, Direction: Debit, Amount: net})
+	}
+
+	result.Transaction, err = s.postInTx(tx, &Transaction{
+		Reference:     fmt.Sprintf("YE-%s-%s", currency, date),
+		Description:   fmt.Sprintf("Year-end close %s to retained earnings", name),
+		ValueDate:     yearEnd,
+		EffectiveDate: yearEnd,
+		Entries:       entries,
+		closing:       true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return result, nil
+}
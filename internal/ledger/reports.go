@@ -0,0 +1,315 @@
+package ledger
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Reports are per currency and use the effective (accounting) date of transactions.
+
+// AccountActivity is one account's opening balance and movements over a period.
+// Amounts are signed as stored (debit +, credit -) except Debits/Credits, which are totals.
+// YearEndDebits/YearEndCredits are the part of Debits/Credits posted by year-end closing transactions.
+type AccountActivity struct {
+	AccountID      uuid.UUID   `json:"account_id"`
+	Name           string      `json:"name"`
+	Type           AccountType `json:"type"`
+	GLAccountID    *uuid.UUID  `json:"gl_account_id,omitempty"`
+	OpeningBalance int64       `json:"opening_balance"`
+	Debits         int64       `json:"debits"`
+	Credits        int64       `json:"credits"`
+	YearEndDebits  int64       `json:"year_end_debits,omitempty"`
+	YearEndCredits int64       `json:"year_end_credits,omitempty"`
+	ClosingBalance int64       `json:"closing_balance"`
+}
+
+// IntegrityCheck compares total debits and credits of every entry in the report's
+// currency up to the report date. Any difference means the ledger is corrupt.
+type IntegrityCheck struct {
+	TotalDebits  int64 `json:"total_debits"`
+	TotalCredits int64 `json:"total_credits"`
+	Difference   int64 `json:"difference"`
+	Balanced     bool  `json:"balanced"`
+}
+
+type TrialBalanceLine struct {
+	AccountActivity
+	Debit  int64 `json:"debit"`  // Closing balance when it is a debit
+	Credit int64 `json:"credit"` // Closing balance when it is a credit
+}
+
+type TrialBalance struct {
+	Currency    string             `json:"currency"`
+	From        *time.Time         `json:"from,omitempty"`
+	To          time.Time          `json:"to"`
+	Lines       []TrialBalanceLine `json:"lines"`
+	TotalDebit  int64              `json:"total_debit"`
+	TotalCredit int64              `json:"total_credit"`
+	Check       IntegrityCheck     `json:"check"`
+}
+
+// ReportLine is an account amount in its normal direction.
+type ReportLine struct {
+	AccountID uuid.UUID   `json:"account_id"`
+	Name      string      `json:"name"`
+	Type      AccountType `json:"type"`
+	Amount    int64       `json:"amount"`
+}
+
+type BalanceSheet struct {
+	Currency         string         `json:"currency"`
+	AsOf             time.Time      `json:"as_of"`
+	Assets           []ReportLine   `json:"assets"`
+	Liabilities      []ReportLine   `json:"liabilities"`
+	Equity           []ReportLine   `json:"equity"`
+	TotalAssets      int64          `json:"total_assets"`
+	TotalLiabilities int64          `json:"total_liabilities"`
+	TotalEquity      int64          `json:"total_equity"`
+	CurrentEarnings  int64          `json:"current_earnings"` // Income less expenses not yet closed to equity
+	Balanced         bool           `json:"balanced"`         // Assets == Liabilities + Equity + CurrentEarnings
+	Check            IntegrityCheck `json:"check"`
+}
+
+type IncomeStatement struct {
+	Currency      string         `json:"currency"`
+	From          *time.Time     `json:"from,omitempty"`
+	To            time.Time      `json:"to"`
+	Income        []ReportLine   `json:"income"`
+	Expenses      []ReportLine   `json:"expenses"`
+	TotalIncome   int64          `json:"total_income"`
+	TotalExpenses int64          `json:"total_expenses"`
+	NetIncome     int64          `json:"net_income"`
+	Check         IntegrityCheck `json:"check"`
+}
+
+// GLReport aggregates account activity by GL account. With Level 0 Lines holds the
+// top-level accounts with their subtrees; otherwise a flat list at that depth.
+type GLReport struct {
+	Currency string         `json:"currency"`
+	From     *time.Time     `json:"from,omitempty"`
+	To       time.Time      `json:"to"`
+	Level    int            `json:"level"`
+	Lines    []*GLBalance   `json:"lines"`
+	Unmapped *GLBalance     `json:"unmapped,omitempty"` // Activity of accounts without a GL mapping
+	Check    IntegrityCheck `json:"check"`
+}
+
+// GetAccountActivity returns the opening balance (before from) and the debits and
+// credits between from and to (inclusive) of every account in currency.
+// A zero from starts at the beginning of the ledger. Accounts without any activity are omitted.
+func (s *Service) GetAccountActivity(currency string, from, to time.Time) ([]AccountActivity, error) {
+	fromStr := "-infinity"
+	if !from.IsZero() {
+		fromStr = from.Format("2006-01-02")
+	}
+	query := `
+		SELECT a.id, a.name, a.type, a.gl_account_id,
+		       COALESCE(SUM(CASE WHEN t.effective_date < $2::DATE THEN
+		           CASE WHEN e.direction = 'DEBIT' THEN e.amount ELSE -e.amount END END), 0),
+		       COALESCE(SUM(CASE WHEN t.effective_date >= $2::DATE AND e.direction = 'DEBIT' THEN e.amount END), 0),
+		       COALESCE(SUM(CASE WHEN t.effective_date >= $2::DATE AND e.direction = 'CREDIT' THEN e.amount END), 0),
+		       COALESCE(SUM(CASE WHEN t.effective_date >= $2::DATE AND t.is_closing AND e.direction = 'DEBIT' THEN e.amount END), 0),
+		       COALESCE(SUM(CASE WHEN t.effective_date >= $2::DATE AND t.is_closing AND e.direction = 'CREDIT' THEN e.amount END), 0)
+		FROM accounts a
+		JOIN entries e ON e.account_id = a.id
+		JOIN transactions t ON t.id = e.transaction_id
+		WHERE a.currency = $1 AND t.effective_date <= $3::DATE
+		GROUP BY a.id, a.name, a.type, a.gl_account_id
+		ORDER BY a.type, a.name, a.id
+	`
+	rows, err := s.db.Query(query, currency, fromStr, to.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load account activity: %w", err)
+	}
+	defer rows.Close()
+
+	var activity []AccountActivity
+	for rows.Next() {
+		var a AccountActivity
+		if err := rows.Scan(&a.AccountID, &a.Name, &a.Type, &a.GLAccountID, &a.OpeningBalance, &a.Debits, &a.Credits, &a.YearEndDebits, &a.YearEndCredits); err != nil {
+			return nil, fmt.Errorf("failed to scan account activity: %w", err)
+		}
+		a.ClosingBalance = a.OpeningBalance + a.Debits - a.Credits
+		activity = append(activity, a)
+	}
+	return activity, rows.Err()
+}
+
+// CheckEntriesBalance totals every entry in currency with an effective date up to to.
+func (s *Service) CheckEntriesBalance(currency string, to time.Time) (IntegrityCheck, error) {
+	query := `
+		SELECT COALESCE(SUM(CASE WHEN e.direction = 'DEBIT' THEN e.amount END), 0),
+		       COALESCE(SUM(CASE WHEN e.direction = 'CREDIT' THEN e.amount END), 0)
+		FROM entries e
+		JOIN transactions t ON t.id = e.transaction_id
+		WHERE e.currency = $1 AND t.effective_date <= $2::DATE
+	`
+	var c IntegrityCheck
+	if err := s.db.QueryRow(query, currency, to.Format("2006-01-02")).Scan(&c.TotalDebits, &c.TotalCredits); err != nil {
+		return c, fmt.Errorf("failed to total entries: %w", err)
+	}
+	c.Difference = c.TotalDebits - c.TotalCredits
+	c.Balanced = c.Difference == 0
+	return c, nil
+}
+
+// GetTrialBalance lists every account's closing balance at to, with movements since from.
+func (s *Service) GetTrialBalance(currency string, from, to time.Time) (*TrialBalance, error) {
+	activity, check, err := s.reportData(currency, from, to)
+	if err != nil {
+		return nil, err
+	}
+	tb := BuildTrialBalance(activity)
+	tb.Currency, tb.To, tb.Check = currency, DateOf(to), check
+	if !from.IsZero() {
+		f := DateOf(from)
+		tb.From = &f
+	}
+	return tb, nil
+}
+
+// GetBalanceSheet reports assets, liabilities and equity as of asOf.
+func (s *Service) GetBalanceSheet(currency string, asOf time.Time) (*BalanceSheet, error) {
+	activity, check, err := s.reportData(currency, time.Time{}, asOf)
+	if err != nil {
+		return nil, err
+	}
+	bs := BuildBalanceSheet(activity)
+	bs.Currency, bs.AsOf, bs.Check = currency, DateOf(asOf), check
+	return bs, nil
+}
+
+// GetIncomeStatement reports income and expenses booked between from and to.
+func (s *Service) GetIncomeStatement(currency string, from, to time.Time) (*IncomeStatement, error) {
+	activity, check, err := s.reportData(currency, from, to)
+	if err != nil {
+		return nil, err
+	}
+	is := BuildIncomeStatement(activity)
+	is.Currency, is.To, is.Check = currency, DateOf(to), check
+	if !from.IsZero() {
+		f := DateOf(from)
+		is.From = &f
+	}
+	return is, nil
+}
+
+// GetGLReport rolls account activity between from and to up the chart of accounts.
+func (s *Service) GetGLReport(currency string, from, to time.Time, level int) (*GLReport, error) {
+	if level < 0 {
+		return nil, fmt.Errorf("level must not be negative")
+	}
+	activity, check, err := s.reportData(currency, from, to)
+	if err != nil {
+		return nil, err
+	}
+	chart, err := s.ListGLAccounts()
+	if err != nil {
+		return nil, err
+	}
+	roots, unmapped := BuildGLRollup(chart, activity)
+	r := &GLReport{Currency: currency, To: DateOf(to), Level: level, Lines: roots, Check: check}
+	if level > 0 {
+		r.Lines = FlattenGL(roots, level)
+	}
+	if unmapped.OpeningBalance != 0 || unmapped.Debits != 0 || unmapped.Credits != 0 {
+		r.Unmapped = unmapped
+	}
+	if !from.IsZero() {
+		f := DateOf(from)
+		r.From = &f
+	}
+	return r, nil
+}
+
+func (s *Service) reportData(currency string, from, to time.Time) ([]AccountActivity, IntegrityCheck, error) {
+	if _, err := currencyDecimals(s.db, currency); err != nil {
+		return nil, IntegrityCheck{}, err
+	}
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if !from.IsZero() && DateOf(from).After(DateOf(to)) {
+		return nil, IntegrityCheck{}, fmt.Errorf("from date is after to date")
+	}
+	activity, err := s.GetAccountActivity(currency, from, to)
+	if err != nil {
+		return nil, IntegrityCheck{}, err
+	}
+	check, err := s.CheckEntriesBalance(currency, to)
+	if err != nil {
+		return nil, IntegrityCheck{}, err
+	}
+	return activity, check, nil
+}
+
+// BuildTrialBalance places each closing balance in the debit or credit column.
+func BuildTrialBalance(activity []AccountActivity) *TrialBalance {
+	tb := &TrialBalance{Lines: []TrialBalanceLine{}}
+	for _, a := range activity {
+		line := TrialBalanceLine{AccountActivity: a}
+		if a.ClosingBalance >= 0 {
+			line.Debit = a.ClosingBalance
+		} else {
+			line.Credit = -a.ClosingBalance
+		}
+		tb.TotalDebit += line.Debit
+		tb.TotalCredit += line.Credit
+		tb.Lines = append(tb.Lines, line)
+	}
+	return tb
+}
+
+// BuildBalanceSheet groups closing balances by type. Income and expense accounts that
+// have not been closed to equity are reported together as current earnings.
+func BuildBalanceSheet(activity []AccountActivity) *BalanceSheet {
+	bs := &BalanceSheet{Assets: []ReportLine{}, Liabilities: []ReportLine{}, Equity: []ReportLine{}}
+	for _, a := range activity {
+		line := ReportLine{AccountID: a.AccountID, Name: a.Name, Type: a.Type, Amount: NaturalBalance(a.Type, a.ClosingBalance)}
+		switch a.Type {
+		case Asset:
+			bs.Assets = append(bs.Assets, line)
+			bs.TotalAssets += line.Amount
+		case Liability:
+			bs.Liabilities = append(bs.Liabilities, line)
+			bs.TotalLiabilities += line.Amount
+		case Equity:
+			bs.Equity = append(bs.Equity, line)
+			bs.TotalEquity += line.Amount
+		case Income, Expense:
+			// Income is credit-normal: a credit balance (negative) is a profit.
+			bs.CurrentEarnings -= a.ClosingBalance
+		}
+	}
+	bs.Balanced = bs.TotalAssets == bs.TotalLiabilities+bs.TotalEquity+bs.CurrentEarnings
+	return bs
+}
+
+// BuildIncomeStatement reports the period movement of income and expense accounts.
+// Year-end closing entries are left out, so a closed year still shows its result.
+func BuildIncomeStatement(activity []AccountActivity) *IncomeStatement {
+	is := &IncomeStatement{Income: []ReportLine{}, Expenses: []ReportLine{}}
+	for _, a := range activity {
+		debits, credits := a.Debits-a.YearEndDebits, a.Credits-a.YearEndCredits
+		movement := NaturalBalance(a.Type, debits-credits)
+		line := ReportLine{AccountID: a.AccountID, Name: a.Name, Type: a.Type, Amount: movement}
+		switch a.Type {
+		case Income:
+			if debits == 0 && credits == 0 {
+				continue
+			}
+			is.Income = append(is.Income, line)
+			is.TotalIncome += movement
+		case Expense:
+			if debits == 0 && credits == 0 {
+				continue
+			}
+			is.Expenses = append(is.Expenses, line)
+			is.TotalExpenses += movement
+		}
+	}
+	is.NetIncome = is.TotalIncome - is.TotalExpenses
+	return is
+}
@@ -0,0 +1,181 @@
+package ledger
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// sampleActivity is a small ledger: 1000 capital, 300 customer deposit,
+// 50 fee income and 20 interest expense, all settled through cash.
+func sampleActivity() []AccountActivity {
+	acts := []AccountActivity{
+		{Name: "Cash", Type: Asset, OpeningBalance: 1000, Debits: 350, Credits: 20},
+		{Name: "Customer Deposits", Type: Liability, Credits: 300},
+		{Name: "Capital", Type: Equity, OpeningBalance: -1000},
+		{Name: "Fee Income", Type: Income, Credits: 50},
+		{Name: "Interest Expense", Type: Expense, Debits: 20},
+	}
+	for i := range acts {
+		acts[i].AccountID = uuid.New()
+		acts[i].ClosingBalance = acts[i].OpeningBalance + acts[i].Debits - acts[i].Credits
+	}
+	return acts
+}
+
+func TestBuildTrialBalance(t *testing.T) {
+	tb := BuildTrialBalance(sampleActivity())
+	if tb.TotalDebit != tb.TotalCredit {
+		t.Errorf("Expected trial balance to balance, debit=%d credit=%d", tb.TotalDebit, tb.TotalCredit)
+	}
+	if tb.TotalDebit != 1350 {
+		t.Errorf("Expected total debit 1350, got %d", tb.TotalDebit)
+	}
+	if tb.Lines[1].Credit != 300 || tb.Lines[1].Debit != 0 {
+		t.Errorf("Expected deposits in the credit column, got %+v", tb.Lines[1])
+	}
+}
+
+func TestBuildBalanceSheet(t *testing.T) {
+	bs := BuildBalanceSheet(sampleActivity())
+	if bs.TotalAssets != 1330 {
+		t.Errorf("Expected assets 1330, got %d", bs.TotalAssets)
+	}
+	if bs.TotalLiabilities != 300 || bs.TotalEquity != 1000 {
+		t.Errorf("Expected liabilities 300 and equity 1000, got %d and %d", bs.TotalLiabilities, bs.TotalEquity)
+	}
+	if bs.CurrentEarnings != 30 {
+		t.Errorf("Expected current earnings 30, got %d", bs.CurrentEarnings)
+	}
+	if !bs.Balanced {
+		t.Error("Expected balance sheet to balance")
+	}
+}
+
+func TestBuildIncomeStatement(t *testing.T) {
+	is := BuildIncomeStatement(sampleActivity())
+	if is.TotalIncome != 50 || is.TotalExpenses != 20 || is.NetIncome != 30 {
+		t.Errorf("Expected income 50, expenses 20, net 30; got %d, %d, %d", is.TotalIncome, is.TotalExpenses, is.NetIncome)
+	}
+	if len(is.Income) != 1 || len(is.Expenses) != 1 {
+		t.Errorf("Expected one income and one expense line, got %d and %d", len(is.Income), len(is.Expenses))
+	}
+}
+
+func TestBuildIncomeStatementAfterYearEnd(t *testing.T) {
+	// The year-end close debits income 50 and credits expense 20 to zero them
+	acts := sampleActivity()
+	acts[3].Debits, acts[3].YearEndDebits = 50, 50
+	acts[4].Credits, acts[4].YearEndCredits = 20, 20
+	onlyClose := AccountActivity{AccountID: uuid.New(), Name: "Other Income", Type: Income, Debits: 10, YearEndDebits: 10, OpeningBalance: -10}
+	acts = append(acts, onlyClose)
+
+	is := BuildIncomeStatement(acts)
+	if is.TotalIncome != 50 || is.TotalExpenses != 20 || is.NetIncome != 30 {
+		t.Errorf("Expected income 50, expenses 20, net 30; got %d, %d, %d", is.TotalIncome, is.TotalExpenses, is.NetIncome)
+	}
+	if len(is.Income) != 1 {
+		t.Errorf("Expected an account with only closing entries to be left out, got %d income lines", len(is.Income))
+	}
+}
+
+func TestBuildGLRollup(t *testing.T) {
+	gl := func(code string, typ AccountType, parent *GLAccount, header bool) GLAccount {
+		g := GLAccount{ID: uuid.New(), Code: code, Name: code, Type: typ, IsHeader: header, IsPostable: !header}
+		if parent != nil {
+			g.ParentID, g.ParentCode = &parent.ID, parent.Code
+		}
+		return g
+	}
+	assets := gl("1000", Asset, nil, true)
+	cash := gl("1100", Asset, &assets, false)
+	liabilities := gl("2000", Liability, nil, true)
+	deposits := gl("2100", Liability, &liabilities, false)
+	chart := []GLAccount{deposits, cash, liabilities, assets}
+
+	acts := sampleActivity()
+	acts[0].GLAccountID = &cash.ID
+	acts[1].GLAccountID = &deposits.ID
+	// Capital, income and expense are left unmapped
+
+	roots, unmapped := BuildGLRollup(chart, acts)
+	if len(roots) != 2 || roots[0].Code != "1000" || roots[1].Code != "2000" {
+		t.Fatalf("Expected roots 1000 and 2000, got %+v", roots)
+	}
+	if roots[0].Balance != 1330 || roots[0].Children[0].Balance != 1330 {
+		t.Errorf("Expected assets to roll up 1330, got %d", roots[0].Balance)
+	}
+	if roots[1].Balance != 300 || roots[1].Level != 1 || roots[1].Children[0].Level != 2 {
+		t.Errorf("Expected liabilities 300 at level 1, got %+v", roots[1])
+	}
+	// -1000 capital, -50 income, +20 expense
+	if unmapped.ClosingBalance != -1030 {
+		t.Errorf("Expected unmapped closing balance -1030, got %d", unmapped.ClosingBalance)
+	}
+
+	if lines := FlattenGL(roots, 0); len(lines) != 4 || lines[1].Code != "1100" {
+		t.Errorf("Expected depth-first listing of all 4 accounts, got %d", len(lines))
+	}
+	lines := FlattenGL(roots, 2)
+	if len(lines) != 2 || lines[0].Code != "1100" || lines[1].Code != "2100" {
+		t.Errorf("Expected level 2 to list 1100 and 2100, got %+v", lines)
+	}
+	if lines[0].Children != nil {
+		t.Error("Expected flattened lines without children")
+	}
+}
+
+func TestBuildStatement(t *testing.T) {
+	st := &Statement{
+		AccountType:    Liability,
+		Decimals:       2,
+		OpeningBalance: 1000,
+		Lines: []StatementLine{
+			{Reference: "DEP", Direction: Credit, Amount: 500},
+			{Reference: "WDL", Direction: Debit, Amount: 200},
+			{Reference: "FEE", Direction: Debit, Amount: 5},
+		},
+	}
+	BuildStatement(st)
+
+	// A credit raises a deposit account's balance, a debit lowers it
+	want := []int64{1500, 1300, 1295}
+	for i, l := range st.Lines {
+		if l.Balance != want[i] {
+			t.Errorf("Line %s: expected running balance %d, got %d", l.Reference, want[i], l.Balance)
+		}
+	}
+	if st.TotalDebits != 205 || st.TotalCredits != 500 {
+		t.Errorf("Expected totals 205/500, got %d/%d", st.TotalDebits, st.TotalCredits)
+	}
+	if st.ClosingBalance != 1295 {
+		t.Errorf("Expected closing balance 1295, got %d", st.ClosingBalance)
+	}
+
+	rows := st.CSVRows()
+	if len(rows) != 6 {
+		t.Fatalf("Expected header, opening, 3 lines and closing rows, got %d", len(rows))
+	}
+	if last := rows[len(rows)-1]; last[6] != "12.95" || last[4] != "2.05" {
+		t.Errorf("Unexpected closing row %v", last)
+	}
+}
+
+func TestFormatMinor(t *testing.T) {
+	cases := []struct {
+		amount   int64
+		decimals int
+		want     string
+	}{
+		{12345, 2, "123.45"},
+		{-5, 2, "-0.05"},
+		{0, 2, "0.00"},
+		{1000, 0, "1000"},
+		{1, 3, "0.001"},
+	}
+	for _, c := range cases {
+		if got := FormatMinor(c.amount, c.decimals); got != c.want {
+			t.Errorf("FormatMinor(%d, %d) = %q, want %q", c.amount, c.decimals, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package ledger
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// ReverseTransaction undoes a posted transaction by posting its mirror image.
+// Every entry is re-posted with the opposite direction, so balances return to
+// where they were while the audit trail keeps both transactions. The mirror
+// carries reversal_of_id and the original is flagged with reversed_by_id; a
+// transaction can only be reversed once.
+func (s *Service) ReverseTransaction(id uuid.UUID, reason string) (*Transaction, error) {
+	if reason == "" {
+		return nil, fmt.Errorf("reversal reason is required")
+	}
+
+	var result *Transaction
+	err := s.inTx(func(tx *sql.Tx) error {
+		var err error
+		result, err = s.reverseInTx(tx, id, reason)
+		return err
+	})
+	return result, err
+}
+
+// reverseInTx posts the mirror of transaction id and flags the original within tx.
+func (s *Service) reverseInTx(tx *sql.Tx, id uuid.UUID, reason string) (*Transaction, error) {
+	// 1. Lock the original so concurrent reversals serialize on it
+	var reference string
+	var status TransactionStatus
+	var reversalOf, reversedBy *uuid.UUID
+	var closing bool
+	err := tx.QueryRow(`SELECT reference, status, reversal_of_id, reversed_by_id, is_closing FROM transactions WHERE id = $1 FOR UPDATE`, id).
+		Scan(&reference, &status, &reversalOf, &reversedBy, &closing)
+	if err == sql.ErrNoRows {
+		return nil, ErrTransactionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load transaction: %w", err)
+	}
+	if status != TxPosted {
+		return nil, fmt.Errorf("%w: transaction %s is %s; void a pending transaction instead", ErrInvalidTxStatus, id, status)
+	}
+	if reversedBy != nil {
+		return nil, ErrAlreadyReversed
+	}
+	if reversalOf != nil {
+		return nil, ErrReversalOfReversal
+	}
+
+	// 2. Build Mirror Entries
+	rows, err := tx.Query(`
+		SELECT account_id, direction, amount, currency, COALESCE(narrative, ''), counterparty
+		FROM entries WHERE transaction_id = $1 ORDER BY created_at, id
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch entries: %w", err)
+	}
+	var mirror []Entry
+	for rows.Next() {
+		var e Entry
+		if err := rows.Scan(&e.AccountID, &e.Direction, &e.Amount, &e.Currency, &e.Narrative, &e.Counterparty); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan entry: %w", err)
+		}
+		if e.Direction == Debit {
+			e.Direction = Credit
+		} else {
+			e.Direction = Debit
+		}
+		mirror = append(mirror, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read entries: %w", err)
+	}
+
+	// 3. Post Mirror Transaction
+	// Reopening a year-end close is itself a closing transaction, kept out of income statements too
+	reversal, err := s.postInTx(tx, &Transaction{
+		Reference:      "REV-" + reference,
+		Description:    fmt.Sprintf("Reversal of %s: %s", reference, reason),
+		ReversalOf:     &id,
+		ReversalReason: reason,
+		Entries:        mirror,
+		closing:        closing,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 4. Flag Original
+	if _, err := tx.Exec(`UPDATE transactions SET reversed_by_id = $1 WHERE id = $2`, reversal.ID, id); err != nil {
+		return nil, fmt.Errorf("failed to flag reversed transaction: %w", err)
+	}
+
+	err = s.publishEvent(tx, EventTransactionReversed, id, map[string]interface{}{
+		"transaction_id": id,
+		"reference":      reference,
+		"reversed_by":    reversal.ID,
+		"reason":         reason,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reversal, nil
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/nathanmocogni/core-banking-system/internal/common"
 	"github.com/nathanmocogni/core-banking-system/internal/integration"
 )
 
@@ -48,7 +49,7 @@ type SecurityPrice struct {
 type SecurityRepository interface {
 	CreateSecurity(ctx context.Context, sec *Security) error
 	GetSecurity(ctx context.Context, symbol string) (*Security, error)
-	ListSecurities(ctx context.Context) ([]Security, error)
+	ListSecurities(ctx context.Context, page common.PageRequest) ([]Security, string, error)
 	AddPrice(ctx context.Context, price *SecurityPrice) error
 	GetLatestPrice(ctx context.Context, securityID uuid.UUID) (*SecurityPrice, error)
 }
@@ -109,7 +110,20 @@ func (r *PostgresSecurityRepository) GetSecurity(ctx context.Context, symbol str
 	return &sec, nil
 }
 
-func (r *PostgresSecurityRepository) ListSecurities(ctx context.Context) ([]Security, error) {
+// ListSecurities returns a page of securities ordered by symbol, and the cursor of
+// the next page ("" on the last page).
+func (r *PostgresSecurityRepository) ListSecurities(ctx context.Context, page common.PageRequest) ([]Security, string, error) {
+	cursor, err := common.DecodeCursor(page.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	limit := page.PageSize()
+	var after sql.NullString
+	var afterID uuid.UUID
+	if cursor != nil {
+		after, afterID = sql.NullString{String: cursor.Key, Valid: true}, cursor.ID
+	}
+
 	query := `
 		SELECT
 			id, symbol, name, type, currency, isin, cusip, sedol, bloom_reuters_code,
@@ -117,11 +131,13 @@ func (r *PostgresSecurityRepository) ListSecurities(ctx context.Context) ([]Secu
 			frequency, day_count_convention, issue_date, maturity_date, primary_exchange,
 			trading_lot_size, price_source, created_at, updated_at
 		FROM securities
-		ORDER BY symbol
+		WHERE $1::VARCHAR IS NULL OR (symbol, id) > ($1::VARCHAR, $2::UUID)
+		ORDER BY symbol, id
+		LIMIT $3
 	`
-	rows, err := r.DB.QueryContext(ctx, query)
+	rows, err := r.DB.QueryContext(ctx, query, after, afterID, limit+1)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	defer rows.Close()
 
@@ -134,11 +150,20 @@ func (r *PostgresSecurityRepository) ListSecurities(ctx context.Context) ([]Secu
 			&sec.Frequency, &sec.DayCountConvention, &sec.IssueDate, &sec.MaturityDate, &sec.PrimaryExchange,
 			&sec.TradingLotSize, &sec.PriceSource, &sec.CreatedAt, &sec.UpdatedAt,
 		); err != nil {
-			return nil, err
+			return nil, "", err
 		}
 		securities = append(securities, sec)
 	}
-	return securities, nil
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	var next string
+	if len(securities) > limit {
+		securities = securities[:limit]
+		next = common.EncodeCursor(securities[limit-1].Symbol, securities[limit-1].ID)
+	}
+	return securities, next, nil
 }
 
 func (r *PostgresSecurityRepository) AddPrice(ctx context.Context, price *SecurityPrice) error {
@@ -1,30 +1,41 @@
 package ledger
 
 import (
-	"context"
 	"database/sql"
 	"fmt"
-
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/nathanmocogni/core-banking-system/internal/events"
+	"github.com/lib/pq"
+	"github.com/nathanmocogni/core-banking-system/internal/common"
 )
 
 type Service struct {
-	db       *sql.DB
-	producer *events.Producer
+	db     *sql.DB
+	policy PostingPolicy
 }
 
-func NewService(db *sql.DB, producer *events.Producer) *Service {
+// NewService creates a ledger service with the default posting policy.
+// Events are staged in the outbox table; delivery is handled by events.OutboxRelay.
+func NewService(db *sql.DB) *Service {
 	return &Service{
-		db:       db,
-		producer: producer,
+		db:     db,
+		policy: DefaultPostingPolicy,
 	}
 }
 
 // CreateAccount creates a new account in the ledger.
 func (s *Service) CreateAccount(name string, accType AccountType, currency string, category, ownership string, clientID *uuid.UUID) (*Account, error) {
+	return s.createAccount(name, accType, currency, category, ownership, clientID, AccountActive)
+}
+
+// CreatePendingAccount opens an account in PENDING status. It accepts no postings
+// until it is activated with SetAccountStatus.
+func (s *Service) CreatePendingAccount(name string, accType AccountType, currency string, category, ownership string, clientID *uuid.UUID) (*Account, error) {
+	return s.createAccount(name, accType, currency, category, ownership, clientID, AccountPending)
+}
+
+func (s *Service) createAccount(name string, accType AccountType, currency string, category, ownership string, clientID *uuid.UUID, status AccountStatus) (*Account, error) {
 	// Validate inputs
 	if name == "" {
 		return nil, fmt.Errorf("account name is required")
@@ -32,6 +43,9 @@ func (s *Service) CreateAccount(name string, accType AccountType, currency strin
 	if len(currency) != 3 {
 		return nil, fmt.Errorf("invalid currency code")
 	}
+	if _, err := currencyDecimals(s.db, currency); err != nil {
+		return nil, err
+	}
 
 	account := &Account{
 		Name:            name,
@@ -39,19 +53,26 @@ func (s *Service) CreateAccount(name string, accType AccountType, currency strin
 		Currency:        currency,
 		AccountCategory: sql.NullString{String: category, Valid: category != ""},
 		OwnershipType:   sql.NullString{String: ownership, Valid: ownership != ""},
+		Status:          status,
 	}
 	if clientID != nil {
 		account.ClientID = uuid.NullUUID{UUID: *clientID, Valid: true}
 	}
 
+	// Customer accounts are mapped to their control GL account
+	var controlGL sql.NullString
+	if code, ok := customerControlGL[accType]; ok && clientID != nil {
+		controlGL = sql.NullString{String: code, Valid: true}
+	}
+
 	query := `
-		INSERT INTO accounts (name, type, currency, account_category, ownership_type, client_id)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, balance, created_at
+		INSERT INTO accounts (name, type, currency, account_category, ownership_type, client_id, status, gl_account_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, (SELECT id FROM gl_accounts WHERE code = $8::VARCHAR))
+		RETURNING id, balance, gl_account_id, created_at
 	`
 
-	err := s.db.QueryRow(query, account.Name, account.Type, account.Currency, account.AccountCategory, account.OwnershipType, account.ClientID).Scan(
-		&account.ID, &account.Balance, &account.CreatedAt,
+	err := s.db.QueryRow(query, account.Name, account.Type, account.Currency, account.AccountCategory, account.OwnershipType, account.ClientID, account.Status, controlGL).Scan(
+		&account.ID, &account.Balance, &account.GLAccountID, &account.CreatedAt,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create account: %w", err)
@@ -63,7 +84,8 @@ func (s *Service) CreateAccount(name string, accType AccountType, currency strin
 // GetAccount retrieves an account by its ID.
 func (s *Service) GetAccount(id uuid.UUID) (*Account, error) {
 	query := `
-		SELECT id, name, type, currency, balance, account_category, ownership_type, client_id, created_at
+		SELECT id, name, type, currency, balance, account_category, ownership_type, client_id, overdraft_limit,
+		       status, COALESCE(freeze_scope, ''), COALESCE(status_reason, ''), gl_account_id, created_at
 		FROM accounts
 		WHERE id = $1
 	`
@@ -71,7 +93,8 @@ func (s *Service) GetAccount(id uuid.UUID) (*Account, error) {
 	account := &Account{}
 	err := s.db.QueryRow(query, id).Scan(
 		&account.ID, &account.Name, &account.Type, &account.Currency, &account.Balance,
-		&account.AccountCategory, &account.OwnershipType, &account.ClientID, &account.CreatedAt,
+		&account.AccountCategory, &account.OwnershipType, &account.ClientID, &account.OverdraftLimit,
+		&account.Status, &account.FreezeScope, &account.StatusReason, &account.GLAccountID, &account.CreatedAt,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil // Not found
@@ -83,17 +106,36 @@ func (s *Service) GetAccount(id uuid.UUID) (*Account, error) {
 	return account, nil
 }
 
-// ListAccounts retrieves all accounts.
-func (s *Service) ListAccounts() ([]*Account, error) {
+// ListAccounts returns a page of accounts, newest first, and the cursor of the next
+// page ("" on the last page).
+func (s *Service) ListAccounts(page common.PageRequest) ([]*Account, string, error) {
+	cursor, err := common.DecodeCursor(page.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	limit := page.PageSize()
+
 	query := `
-		SELECT id, name, type, currency, balance, account_category, ownership_type, client_id, created_at
+		SELECT id, name, type, currency, balance, account_category, ownership_type, client_id, overdraft_limit,
+		       status, COALESCE(freeze_scope, ''), COALESCE(status_reason, ''), gl_account_id, created_at
 		FROM accounts
-		ORDER BY created_at DESC
+		WHERE $1::TIMESTAMPTZ IS NULL OR (created_at, id) < ($1::TIMESTAMPTZ, $2::UUID)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3
 	`
+	var after sql.NullTime
+	var afterID uuid.UUID
+	if cursor != nil {
+		createdAt, err := time.Parse(time.RFC3339Nano, cursor.Key)
+		if err != nil {
+			return nil, "", common.ErrInvalidCursor
+		}
+		after, afterID = sql.NullTime{Time: createdAt, Valid: true}, cursor.ID
+	}
 
-	rows, err := s.db.Query(query)
+	rows, err := s.db.Query(query, after, afterID, limit+1)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list accounts: %w", err)
+		return nil, "", fmt.Errorf("failed to list accounts: %w", err)
 	}
 	defer rows.Close()
 
@@ -102,24 +144,43 @@ func (s *Service) ListAccounts() ([]*Account, error) {
 		var account Account
 		if err := rows.Scan(
 			&account.ID, &account.Name, &account.Type, &account.Currency, &account.Balance,
-			&account.AccountCategory, &account.OwnershipType, &account.ClientID, &account.CreatedAt,
+			&account.AccountCategory, &account.OwnershipType, &account.ClientID, &account.OverdraftLimit,
+			&account.Status, &account.FreezeScope, &account.StatusReason, &account.GLAccountID, &account.CreatedAt,
 		); err != nil {
-			return nil, fmt.Errorf("failed to scan account: %w", err)
+			return nil, "", fmt.Errorf("failed to scan account: %w", err)
 		}
 		accounts = append(accounts, &account)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("failed to list accounts: %w", err)
+	}
 
-	return accounts, nil
+	var next string
+	if len(accounts) > limit {
+		accounts = accounts[:limit]
+		last := accounts[limit-1]
+		next = common.EncodeCursor(last.CreatedAt.Format(time.RFC3339Nano), last.ID)
+	}
+	return accounts, next, nil
 }
 
-// PostTransaction records a new transaction in the ledger.
+// PostTransaction records a new transaction in the ledger, value-dated today.
 // It enforces double-entry accounting rules (Debits == Credits) and ACID properties.
+// Use Post for back- or forward-dated postings.
 func (s *Service) PostTransaction(reference string, description string, entries []Entry) (*Transaction, error) {
-	// 1. Validate: Debits must equal Credits
+	return s.Post(PostingRequest{
+		Reference:   reference,
+		Description: description,
+		Entries:     entries,
+	})
+}
+
+// validateEntries checks that every amount is positive and that debits equal credits.
+func validateEntries(entries []Entry) error {
 	var totalDebit, totalCredit int64
 	for _, entry := range entries {
 		if entry.Amount <= 0 {
-			return nil, fmt.Errorf("entry amount must be positive")
+			return fmt.Errorf("entry amount must be positive")
 		}
 		if entry.Direction == Debit {
 			totalDebit += entry.Amount
@@ -129,88 +190,132 @@ func (s *Service) PostTransaction(reference string, description string, entries
 	}
 
 	if totalDebit != totalCredit {
-		return nil, fmt.Errorf("transaction is not balanced: debits=%d, credits=%d", totalDebit, totalCredit)
+		return fmt.Errorf("transaction is not balanced: debits=%d, credits=%d", totalDebit, totalCredit)
 	}
+	return nil
+}
 
-	// 2. Start Database Transaction (ACID)
-	tx, err := s.db.Begin()
-	if err != nil {
-		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+// postInTx writes the transaction header, its entries, the balance updates and the
+// TransactionPosted outbox event using tx, rejecting the posting with
+// ErrPeriodClosed if its effective date is in a closed accounting period, with
+// ErrAccountNotPostable if an account's status forbids an entry and with
+// ErrInsufficientFunds if it would overdraw an account. The caller validates and commits.
+func (s *Service) postInTx(tx *sql.Tx, t *Transaction) (*Transaction, error) {
+	// 1. Insert Transaction Header (or post the pending one)
+	if !t.fromPending {
+		t.ID = uuid.New()
+	}
+	t.Status = TxPosted
+	var reversalReason sql.NullString
+	if t.ReversalReason != "" {
+		reversalReason = sql.NullString{String: t.ReversalReason, Valid: true}
+	}
+	today := DateOf(time.Now())
+	if t.ValueDate.IsZero() {
+		t.ValueDate = today
+	}
+	if t.EffectiveDate.IsZero() {
+		t.EffectiveDate = today
+	}
+	if err := checkPeriod(tx, t.EffectiveDate, t.closing); err != nil {
+		return nil, err
 	}
-	defer tx.Rollback() // Rollback if not committed
-
-	// 3. Insert Transaction Header
-	transactionID := uuid.New()
 	txQuery := `
-		INSERT INTO transactions (id, reference, description)
-		VALUES ($1, $2, $3)
+		INSERT INTO transactions (id, reference, description, reversal_of_id, reversal_reason, value_date, effective_date, metadata, is_closing)
+		VALUES ($1, $2, $3, $4, $5, $6::DATE, $7::DATE, $8, $9)
 		RETURNING posted_at
 	`
-	var postedAt time.Time
-	err = tx.QueryRow(txQuery, transactionID, reference, description).Scan(&postedAt)
+	var err error
+	if t.fromPending {
+		err = tx.QueryRow(`
+			UPDATE transactions
+			SET status = 'POSTED', description = $2, value_date = $3::DATE, effective_date = $4::DATE, posted_at = CURRENT_TIMESTAMP
+			WHERE id = $1 AND status = 'PENDING'
+			RETURNING posted_at
+		`, t.ID, t.Description, t.ValueDate.Format("2006-01-02"), t.EffectiveDate.Format("2006-01-02")).Scan(&t.PostedAt)
+	} else {
+		err = tx.QueryRow(txQuery, t.ID, t.Reference, t.Description, t.ReversalOf, reversalReason,
+			t.ValueDate.Format("2006-01-02"), t.EffectiveDate.Format("2006-01-02"), t.Metadata, t.closing).Scan(&t.PostedAt)
+	}
 	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" && pqErr.Constraint == "transactions_reference_key" {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateReference, t.Reference)
+		}
 		return nil, fmt.Errorf("failed to insert transaction: %w", err)
 	}
 
-	// 4. Insert Entries and Update Balances
+	// 2. Lock Accounts, Check Currencies and Account Status
+	// Every entry is in its account's currency and the transaction balances per currency.
+	entries := t.Entries
+	if err := resolveEntryCurrencies(tx, entries); err != nil {
+		return nil, err
+	}
+	net, touched := netByAccount(entries)
+	order, err := lockAccounts(tx, touched)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAccountStatuses(tx, entries); err != nil {
+		return nil, err
+	}
+
+	// 3. Insert Entries
 	entryQuery := `
-		INSERT INTO entries (transaction_id, account_id, direction, amount)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO entries (transaction_id, account_id, direction, amount, currency, narrative, counterparty)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7)
 		RETURNING id, created_at
 	`
-
 	for i := range entries {
-		entries[i].TransactionID = transactionID
-		err = tx.QueryRow(entryQuery, transactionID, entries[i].AccountID, entries[i].Direction, entries[i].Amount).Scan(
+		entries[i].TransactionID = t.ID
+		err = tx.QueryRow(entryQuery, t.ID, entries[i].AccountID, entries[i].Direction, entries[i].Amount, entries[i].Currency,
+			entries[i].Narrative, entries[i].Counterparty).Scan(
 			&entries[i].ID, &entries[i].CreatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to insert entry: %w", err)
 		}
+	}
 
-		// Update Account Balance (Read Model)
-		var amountChange int64
-		if entries[i].Direction == Debit {
-			amountChange = entries[i].Amount
-		} else {
-			amountChange = -entries[i].Amount
+	// 4. Update Balances (Read Model) and Enforce Available Balance and Overdraft Limits
+	// One update per account with its net change, in lock order, each checked under
+	// the lock. Closing entries only move balances between GL accounts and are exempt,
+	// as are charges such as capitalized debit interest.
+	for _, accountID := range order {
+		if net[accountID] == 0 {
+			continue
 		}
-
-		_, err = tx.Exec(`UPDATE accounts SET balance = balance + $1 WHERE id = $2`, amountChange, entries[i].AccountID)
+		_, err = tx.Exec(`UPDATE accounts SET balance = balance + $1 WHERE id = $2`, net[accountID], accountID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to update account balance: %w", err)
 		}
+		if !t.closing && !t.noFundsCheck {
+			if err := checkFunds(tx, accountID, net[accountID]); err != nil {
+				return nil, err
+			}
+		}
 	}
 
-	// 5. Commit
-	if err := tx.Commit(); err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	// 5. Stage Event (Outbox)
+	// Written in the same transaction so every committed posting produces exactly one event.
+	payload := map[string]interface{}{
+		"transaction_id": t.ID,
+		"reference":      t.Reference,
+		"posted_at":      t.PostedAt,
+		"value_date":     t.ValueDate.Format("2006-01-02"),
+		"effective_date": t.EffectiveDate.Format("2006-01-02"),
+		"entries":        entries,
 	}
-
-	// 6. Publish Event (Best Effort)
-	// In a real system, use Outbox Pattern.
-	if s.producer != nil {
-		go func() {
-			err := s.producer.Publish(context.Background(), transactionID.String(), map[string]interface{}{
-				"event":          "TransactionPosted",
-				"transaction_id": transactionID,
-				"reference":      reference,
-				"posted_at":      postedAt,
-				"entries":        entries,
-			})
-			if err != nil {
-				fmt.Printf("Failed to publish event: %v\n", err)
-			}
-		}()
+	if t.ReversalOf != nil {
+		payload["reversal_of"] = t.ReversalOf
+	}
+	if len(t.Metadata) > 0 {
+		payload["metadata"] = t.Metadata
+	}
+	if err := s.publishEvent(tx, EventTransactionPosted, t.ID, payload); err != nil {
+		return nil, err
 	}
 
-	return &Transaction{
-		ID:          transactionID,
-		Reference:   reference,
-		Description: description,
-		PostedAt:    postedAt,
-		Entries:     entries,
-	}, nil
+	return t, nil
 }
 
 func (s *Service) CreateProduct(name string, interestRateBPS int64) (*Product, error) {
@@ -219,6 +324,7 @@ func (s *Service) CreateProduct(name string, interestRateBPS int64) (*Product, e
 		InterestRateBPS: interestRateBPS,
 		Status:          ProductStatusDraft,
 		Version:         1,
+		InterestTerms:   DefaultInterestTerms,
 	}
 
 	query := `
@@ -236,8 +342,14 @@ func (s *Service) CreateProduct(name string, interestRateBPS int64) (*Product, e
 func (s *Service) UpdateProduct(id uuid.UUID, name string, interestRateBPS int64, status ProductStatus) (*Product, error) {
 	// 1. Fetch current product state
 	currentProduct := &Product{}
-	err := s.db.QueryRow("SELECT id, name, interest_rate_bps, status, version FROM products WHERE id = $1", id).
-		Scan(&currentProduct.ID, &currentProduct.Name, &currentProduct.InterestRateBPS, &currentProduct.Status, &currentProduct.Version)
+	err := s.db.QueryRow(`
+		SELECT id, name, interest_rate_bps, status, version,
+		       day_count_convention, tier_mode, interest_tiers, min_interest_balance, interest_rounding, capitalization_frequency,
+		       debit_interest_rate_bps, overdraft_penalty_rate_bps
+		FROM products WHERE id = $1
+	`, id).Scan(&currentProduct.ID, &currentProduct.Name, &currentProduct.InterestRateBPS, &currentProduct.Status, &currentProduct.Version,
+		&currentProduct.DayCount, &currentProduct.TierMode, &currentProduct.Tiers, &currentProduct.MinBalance, &currentProduct.Rounding, &currentProduct.Capitalization,
+		&currentProduct.DebitRateBPS, &currentProduct.PenaltyRateBPS)
 	if err != nil {
 		return nil, fmt.Errorf("product not found: %w", err)
 	}
@@ -280,20 +392,34 @@ func (s *Service) UpdateProduct(id uuid.UUID, name string, interestRateBPS int64
 func (s *Service) CloneProduct(id uuid.UUID) (*Product, error) {
 	// 1. Fetch original
 	original := &Product{}
-	err := s.db.QueryRow("SELECT name, interest_rate_bps FROM products WHERE id = $1", id).
-		Scan(&original.Name, &original.InterestRateBPS)
+	err := s.db.QueryRow(`
+		SELECT name, interest_rate_bps, day_count_convention, tier_mode, interest_tiers, min_interest_balance, interest_rounding, capitalization_frequency,
+		       debit_interest_rate_bps, overdraft_penalty_rate_bps
+		FROM products WHERE id = $1
+	`, id).Scan(&original.Name, &original.InterestRateBPS, &original.DayCount, &original.TierMode, &original.Tiers, &original.MinBalance, &original.Rounding, &original.Capitalization,
+		&original.DebitRateBPS, &original.PenaltyRateBPS)
 	if err != nil {
 		return nil, fmt.Errorf("original product not found: %w", err)
 	}
 
-	// 2. Create new version (Draft)
+	// 2. Create new version (Draft) with the same interest terms
 	newVersionName := fmt.Sprintf("%s (v2)", original.Name) // Simplified naming logic
-	return s.CreateProduct(newVersionName, original.InterestRateBPS)
+	clone, err := s.CreateProduct(newVersionName, original.InterestRateBPS)
+	if err != nil {
+		return nil, err
+	}
+	if err := saveInterestTerms(s.db, clone.ID, original.InterestTerms); err != nil {
+		return nil, err
+	}
+	clone.InterestTerms = original.InterestTerms
+	return clone, nil
 }
 
 func (s *Service) ListProducts() ([]*Product, error) {
 	query := `
-		SELECT id, name, interest_rate_bps, status, version, parent_product_id, created_at
+		SELECT id, name, interest_rate_bps, status, version, parent_product_id, overdraft_limit, control_gl_account_id,
+		       day_count_convention, tier_mode, interest_tiers, min_interest_balance, interest_rounding, capitalization_frequency,
+		       debit_interest_rate_bps, overdraft_penalty_rate_bps, created_at
 		FROM products
 		ORDER BY name, version DESC
 	`
@@ -306,7 +432,8 @@ func (s *Service) ListProducts() ([]*Product, error) {
 	var products []*Product
 	for rows.Next() {
 		var p Product
-		if err := rows.Scan(&p.ID, &p.Name, &p.InterestRateBPS, &p.Status, &p.Version, &p.ParentProductID, &p.CreatedAt); err != nil {
+		if err := rows.Scan(&p.ID, &p.Name, &p.InterestRateBPS, &p.Status, &p.Version, &p.ParentProductID, &p.OverdraftLimit, &p.ControlGLID,
+			&p.DayCount, &p.TierMode, &p.Tiers, &p.MinBalance, &p.Rounding, &p.Capitalization, &p.DebitRateBPS, &p.PenaltyRateBPS, &p.CreatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan product: %w", err)
 		}
 		products = append(products, &p)
@@ -414,8 +541,18 @@ func (s *Service) ListFees() ([]*Fee, error) {
 	return fees, nil
 }
 
+// AssignProduct links an account to a product and maps it to the product's control
+// GL account when one of the same type is set.
 func (s *Service) AssignProduct(accountID uuid.UUID, productID uuid.UUID) error {
-	query := `UPDATE accounts SET product_id = $1 WHERE id = $2`
+	query := `
+		UPDATE accounts a
+		SET product_id = $1,
+		    gl_account_id = COALESCE((
+		        SELECT g.id FROM products p JOIN gl_accounts g ON g.id = p.control_gl_account_id
+		        WHERE p.id = $1 AND g.type = a.type
+		    ), a.gl_account_id)
+		WHERE a.id = $2
+	`
 	_, err := s.db.Exec(query, productID, accountID)
 	if err != nil {
 		return fmt.Errorf("failed to assign product: %w", err)
@@ -423,141 +560,123 @@ func (s *Service) AssignProduct(accountID uuid.UUID, productID uuid.UUID) error
 	return nil
 }
 
-// CalculateInterest iterates over all accounts with a product and accrues interest.
-// It calculates daily interest based on the account balance and the product's interest rate.
-// A transaction is posted for each eligible account, debiting the system expense account and crediting the user account.
+// CalculateInterest accrues today's interest. See CalculateInterestAsOf.
 func (s *Service) CalculateInterest() ([]*Transaction, error) {
-	// 1. Fetch eligible accounts
-	// Note: Liability accounts have negative balance. We calculate interest on the absolute amount.
+	return s.CalculateInterestAsOf(time.Now())
+}
+
+// CalculateInterestAsOf accrues interest for businessDate on every account with an
+// interest-bearing product. Each day's interest is calculated on the account balance as
+// of that day (from entries, so forward-dated postings are excluded): credit positions
+// earn the product's credit rate and tiers, debit positions are charged its debit rate.
+// See accrueInterestDay.
+//
+// Accrual is keyed by account and business date. Running a date twice accrues nothing
+// the second time, and a run for a later date first catches up the days missed since
+// the account was last accrued, oldest first, as far back as the posting policy allows.
+func (s *Service) CalculateInterestAsOf(businessDate time.Time) ([]*Transaction, error) {
+	businessDate = DateOf(businessDate)
+	today := DateOf(time.Now())
+	if businessDate.After(today) {
+		return nil, fmt.Errorf("%w: business date %s is in the future", ErrDateOutOfPolicy, businessDate.Format("2006-01-02"))
+	}
+	earliest := today.AddDate(0, 0, -s.policy.MaxBackdateDays)
+
+	// 1. Fetch eligible accounts not yet accrued for businessDate
 	query := `
-		SELECT a.id, a.balance, p.interest_rate_bps
+		SELECT a.id, a.type, ai.accrued_through, p.interest_rate_bps, COALESCE(a.overdraft_limit, p.overdraft_limit, 0),
+		       p.day_count_convention, p.tier_mode, p.interest_tiers, p.min_interest_balance, p.interest_rounding,
+		       p.debit_interest_rate_bps, p.overdraft_penalty_rate_bps
 		FROM accounts a
 		JOIN products p ON a.product_id = p.id
-		WHERE p.interest_rate_bps > 0 AND a.balance != 0
+		LEFT JOIN account_interest ai ON ai.account_id = a.id
+		WHERE (p.interest_rate_bps > 0 OR p.interest_tiers IS NOT NULL OR p.debit_interest_rate_bps > 0 OR p.overdraft_penalty_rate_bps > 0)
+		  AND a.status NOT IN ('PENDING', 'CLOSED')
+		  AND (ai.accrued_through IS NULL OR ai.accrued_through < $1::DATE)
 	`
-	rows, err := s.db.Query(query)
+	rows, err := s.db.Query(query, businessDate.Format("2006-01-02"))
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch accounts for interest: %w", err)
 	}
 	defer rows.Close()
 
-	var transactions []*Transaction
-
-	// Get or create the system account for "Interest Expense".
-	systemExpenseID, err := s.GetOrCreateSystemAccount("Bank Interest Expense", Expense)
-	if err != nil {
-		return nil, err
-	}
-
+	var accounts []interestAccount
 	for rows.Next() {
-		var accountID uuid.UUID
-		var balance int64
-		var rateBPS int64
-		if err := rows.Scan(&accountID, &balance, &rateBPS); err != nil {
-			continue
-		}
-
-		// 2. Calculate Daily Interest
-		// Use Absolute Balance to handle Liability accounts (negative balance) correctly.
-		absBalance := balance
-		if absBalance < 0 {
-			absBalance = -absBalance
-		}
-
-		// Formula: Balance * (RateBPS / 10000) / 365
-		// We use integer math: (Balance * RateBPS) / (10000 * 365)
-		dailyInterest := (absBalance * rateBPS) / (10000 * 365)
-
-		if dailyInterest <= 0 {
+		var a interestAccount
+		if err := rows.Scan(&a.id, &a.accType, &a.accruedThrough, &a.rateBPS, &a.overdraftLimit,
+			&a.terms.DayCount, &a.terms.TierMode, &a.terms.Tiers, &a.terms.MinBalance, &a.terms.Rounding,
+			&a.terms.DebitRateBPS, &a.terms.PenaltyRateBPS); err != nil {
 			continue
 		}
+		accounts = append(accounts, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch accounts for interest: %w", err)
+	}
+	rows.Close()
 
-		// 3. Post Transaction
-		// Debit: Bank Interest Expense (Expense increases)
-		// Credit: User Account (Liability increases, effectively paying interest to the user)
-		entries := []Entry{
-			{AccountID: systemExpenseID, Direction: Debit, Amount: dailyInterest},
-			{AccountID: accountID, Direction: Credit, Amount: dailyInterest},
+	// 2. Accrue each missing day in order; each day builds on the previous day's carry
+	var transactions []*Transaction
+	for _, a := range accounts {
+		from := businessDate
+		if a.accruedThrough != nil {
+			from = DateOf(*a.accruedThrough).AddDate(0, 0, 1)
+			if from.Before(earliest) {
+				fmt.Printf("Skipping interest for account %s from %s to %s: outside the posting policy\n",
+					a.id, from.Format("2006-01-02"), earliest.AddDate(0, 0, -1).Format("2006-01-02"))
+				from = earliest
+			}
 		}
-
-		tx, err := s.PostTransaction(fmt.Sprintf("INT-%s-%d", accountID, time.Now().UnixNano()), "Daily Interest Accrual", entries)
-		if err != nil {
-			// Log error but continue processing other accounts
-			fmt.Printf("Failed to post interest for account %s: %v\n", accountID, err)
-			continue
+		posters := s.resolveAccrualPosters(a.id)
+		for day := from; !day.After(businessDate); day = day.AddDate(0, 0, 1) {
+			tx, err := s.accrueInterestDay(a, posters, day)
+			if err != nil {
+				// Log error but continue processing other accounts; the next run retries from here
+				fmt.Printf("Failed to post interest for account %s on %s: %v\n", a.id, day.Format("2006-01-02"), err)
+				break
+			}
+			if tx != nil {
+				transactions = append(transactions, tx)
+			}
 		}
-		transactions = append(transactions, tx)
 	}
 
 	return transactions, nil
 }
 
-func (s *Service) GetOrCreateSystemAccount(name string, accType AccountType) (uuid.UUID, error) {
-	var id uuid.UUID
-	err := s.db.QueryRow("SELECT id FROM accounts WHERE name = $1", name).Scan(&id)
-	if err == sql.ErrNoRows {
-		// Create it
-		acc, err := s.CreateAccount(name, accType, "USD", "SYSTEM", "SYSTEM", nil)
-		if err != nil {
-			return uuid.Nil, err
-		}
-		return acc.ID, nil
+// GetOrCreateSystemAccount returns the internal account with the given name in the
+// given currency, creating it on first use. Each currency has its own system accounts.
+// GL-backed system accounts, which are named after their GL account, are found too;
+// when several share the name the oldest is returned.
+func (s *Service) GetOrCreateSystemAccount(name string, accType AccountType, currency string) (uuid.UUID, error) {
+	find := func() (uuid.UUID, error) {
+		var id uuid.UUID
+		err := s.db.QueryRow(`
+			SELECT id FROM accounts
+			WHERE name = $1 AND currency = $2 AND account_category = 'SYSTEM'
+			ORDER BY created_at, id LIMIT 1
+		`, name, currency).Scan(&id)
+		return id, err
+	}
+	id, err := find()
+	if err != sql.ErrNoRows {
+		return id, err
+	}
+
+	// Create it; a concurrent caller creating the same account wins the unique index
+	if name == "" {
+		return uuid.Nil, fmt.Errorf("account name is required")
 	}
-	if err != nil {
+	if _, err := currencyDecimals(s.db, currency); err != nil {
 		return uuid.Nil, err
 	}
-	return id, nil
-}
-
-// GetTransactions retrieves the transaction history for a specific account.
-func (s *Service) GetTransactions(accountID uuid.UUID, limit, offset int) ([]*Transaction, error) {
-	// Query to fetch transactions where the account was involved in an entry.
-	// We join with entries to filter by account_id, but we want the transaction details.
-	// Note: This is a simplified query. In a real system, we might want to return the specific entry for this account
-	// or the full transaction with all entries. Let's return the full transaction.
-	query := `
-		SELECT DISTINCT t.id, t.reference, t.description, t.posted_at
-		FROM transactions t
-		JOIN entries e ON t.id = e.transaction_id
-		WHERE e.account_id = $1
-		ORDER BY t.posted_at DESC
-		LIMIT $2 OFFSET $3
-	`
-
-	rows, err := s.db.Query(query, accountID, limit, offset)
+	_, err = s.db.Exec(`
+		INSERT INTO accounts (name, type, currency, account_category, ownership_type, status)
+		VALUES ($1, $2, $3, 'SYSTEM', 'SYSTEM', $4)
+		ON CONFLICT (name, currency) WHERE account_category = 'SYSTEM' AND gl_account_id IS NULL DO NOTHING
+	`, name, accType, currency, AccountActive)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch transactions: %w", err)
-	}
-	defer rows.Close()
-
-	var transactions []*Transaction
-	for rows.Next() {
-		var t Transaction
-		if err := rows.Scan(&t.ID, &t.Reference, &t.Description, &t.PostedAt); err != nil {
-			return nil, fmt.Errorf("failed to scan transaction: %w", err)
-		}
-		transactions = append(transactions, &t)
-	}
-
-	// Fetch entries for each transaction (N+1 problem, but okay for prototype with small limit)
-	// Optimization: Fetch all entries in one go using IN clause if needed.
-	for _, t := range transactions {
-		entryQuery := `SELECT id, account_id, direction, amount, created_at FROM entries WHERE transaction_id = $1`
-		entryRows, err := s.db.Query(entryQuery, t.ID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to fetch entries: %w", err)
-		}
-		defer entryRows.Close()
-
-		for entryRows.Next() {
-			var e Entry
-			e.TransactionID = t.ID
-			if err := entryRows.Scan(&e.ID, &e.AccountID, &e.Direction, &e.Amount, &e.CreatedAt); err != nil {
-				return nil, fmt.Errorf("failed to scan entry: %w", err)
-			}
-			t.Entries = append(t.Entries, e)
-		}
+		return uuid.Nil, fmt.Errorf("failed to create account: %w", err)
 	}
-
-	return transactions, nil
+	return find()
 }
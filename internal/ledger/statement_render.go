@@ -0,0 +1,113 @@
+package ledger
+
+import (
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// FormatMinor formats an amount in minor units with the given number of decimals,
+// e.g. FormatMinor(-12345, 2) is "-123.45".
+func FormatMinor(amount int64, decimals int) string {
+	neg := amount < 0
+	if neg {
+		amount = -amount
+	}
+	s := strconv.FormatInt(amount, 10)
+	if decimals > 0 {
+		if len(s) <= decimals {
+			s = strings.Repeat("0", decimals-len(s)+1) + s
+		}
+		s = s[:len(s)-decimals] + "." + s[len(s)-decimals:]
+	}
+	if neg {
+		s = "-" + s
+	}
+	return s
+}
+
+// CSVRows renders the statement as CSV rows: a header, an opening balance row, one
+// row per line and a closing row with the totals.
+func (st *Statement) CSVRows() [][]string {
+	amt := func(v int64) string { return FormatMinor(v, st.Decimals) }
+	rows := [][]string{
+		{"value_date", "posted_at", "reference", "description", "debit", "credit", "balance"},
+		{st.From.Format("2006-01-02"), "", "", "Opening balance", "", "", amt(st.OpeningBalance)},
+	}
+	for _, l := range st.Lines {
+		debit, credit := "", ""
+		if l.Direction == Debit {
+			debit = amt(l.Amount)
+		} else {
+			credit = amt(l.Amount)
+		}
+		rows = append(rows, []string{
+			l.ValueDate.Format("2006-01-02"), l.PostedAt.UTC().Format("2006-01-02T15:04:05Z"),
+			l.Reference, l.Description, debit, credit, amt(l.Balance),
+		})
+	}
+	return append(rows, []string{
+		st.To.Format("2006-01-02"), "", "", "Closing balance", amt(st.TotalDebits), amt(st.TotalCredits), amt(st.ClosingBalance),
+	})
+}
+
+var statementHTML = template.Must(template.New("statement").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Statement {{.Name}} {{.From}} to {{.To}}</title>
+<style>
+body { font-family: sans-serif; font-size: 12px; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border-bottom: 1px solid #ccc; padding: 4px; text-align: left; }
+td.num, th.num { text-align: right; }
+</style>
+</head>
+<body>
+<h1>Account statement</h1>
+<p>{{.Name}} ({{.AccountID}})<br>Currency: {{.Currency}}<br>Period: {{.From}} to {{.To}}</p>
+<table>
+<tr><th>Value date</th><th>Reference</th><th>Description</th><th class="num">Debit</th><th class="num">Credit</th><th class="num">Balance</th></tr>
+<tr><td>{{.From}}</td><td></td><td>Opening balance</td><td></td><td></td><td class="num">{{.Opening}}</td></tr>
+{{range .Lines}}<tr><td>{{.Date}}</td><td>{{.Reference}}</td><td>{{.Description}}</td><td class="num">{{.Debit}}</td><td class="num">{{.Credit}}</td><td class="num">{{.Balance}}</td></tr>
+{{end}}<tr><th>{{.To}}</th><th></th><th>Closing balance</th><th class="num">{{.TotalDebits}}</th><th class="num">{{.TotalCredits}}</th><th class="num">{{.Closing}}</th></tr>
+</table>
+<p>Generated {{.GeneratedAt}}</p>
+</body>
+</html>
+`))
+
+// WriteHTML renders the statement as a printable HTML page.
+func (st *Statement) WriteHTML(w io.Writer) error {
+	type line struct {
+		Date, Reference, Description, Debit, Credit, Balance string
+	}
+	amt := func(v int64) string { return FormatMinor(v, st.Decimals) }
+	data := struct {
+		Name, AccountID, Currency, From, To                      string
+		Opening, TotalDebits, TotalCredits, Closing, GeneratedAt string
+		Lines                                                    []line
+	}{
+		Name:         st.AccountName,
+		AccountID:    st.AccountID.String(),
+		Currency:     st.Currency,
+		From:         st.From.Format("2006-01-02"),
+		To:           st.To.Format("2006-01-02"),
+		Opening:      amt(st.OpeningBalance),
+		TotalDebits:  amt(st.TotalDebits),
+		TotalCredits: amt(st.TotalCredits),
+		Closing:      amt(st.ClosingBalance),
+		GeneratedAt:  st.GeneratedAt.UTC().Format("2006-01-02 15:04 MST"),
+	}
+	for _, l := range st.Lines {
+		ln := line{Date: l.ValueDate.Format("2006-01-02"), Reference: l.Reference, Description: l.Description, Balance: amt(l.Balance)}
+		if l.Direction == Debit {
+			ln.Debit = amt(l.Amount)
+		} else {
+			ln.Credit = amt(l.Amount)
+		}
+		data.Lines = append(data.Lines, ln)
+	}
+	return statementHTML.Execute(w, data)
+}
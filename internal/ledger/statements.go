@@ -0,0 +1,255 @@
+package ledger
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StatementLine is one entry on a statement. Balance is the running balance after
+// the entry, in the account's normal direction.
+type StatementLine struct {
+	TransactionID uuid.UUID      `json:"transaction_id"`
+	Reference     string         `json:"reference"`
+	Description   string         `json:"description"`
+	ValueDate     time.Time      `json:"value_date"`
+	PostedAt      time.Time      `json:"posted_at"`
+	Direction     EntryDirection `json:"direction"`
+	Amount        int64          `json:"amount"`
+	Balance       int64          `json:"balance"`
+}
+
+// Statement lists an account's entries with value dates between From and To
+// (inclusive). Balances are in the account's normal direction, so a customer
+// deposit account in credit shows a positive balance.
+type Statement struct {
+	ID             *uuid.UUID      `json:"id,omitempty"` // Set once stored
+	AccountID      uuid.UUID       `json:"account_id"`
+	AccountName    string          `json:"account_name"`
+	AccountType    AccountType     `json:"account_type"`
+	Currency       string          `json:"currency"`
+	Decimals       int             `json:"decimals"`
+	From           time.Time       `json:"from"`
+	To             time.Time       `json:"to"`
+	OpeningBalance int64           `json:"opening_balance"`
+	TotalDebits    int64           `json:"total_debits"`
+	TotalCredits   int64           `json:"total_credits"`
+	ClosingBalance int64           `json:"closing_balance"`
+	Lines          []StatementLine `json:"lines"`
+	GeneratedAt    time.Time       `json:"generated_at"`
+}
+
+// BuildStatement fills in the running balances, totals and closing balance of st
+// from its opening balance and lines.
+func BuildStatement(st *Statement) {
+	st.TotalDebits, st.TotalCredits = 0, 0
+	balance := st.OpeningBalance
+	for i := range st.Lines {
+		l := &st.Lines[i]
+		signed := l.Amount
+		if l.Direction == Debit {
+			st.TotalDebits += l.Amount
+		} else {
+			st.TotalCredits += l.Amount
+			signed = -signed
+		}
+		balance += NaturalBalance(st.AccountType, signed)
+		l.Balance = balance
+	}
+	st.ClosingBalance = balance
+}
+
+// GenerateStatement builds the statement of an account for from..to by value date.
+func (s *Service) GenerateStatement(accountID uuid.UUID, from, to time.Time) (*Statement, error) {
+	from, to = DateOf(from), DateOf(to)
+	if from.IsZero() || to.IsZero() {
+		return nil, fmt.Errorf("from and to dates are required")
+	}
+	if from.After(to) {
+		return nil, fmt.Errorf("from date is after to date")
+	}
+	acc, err := s.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+	if acc == nil {
+		return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, accountID)
+	}
+	decimals, err := currencyDecimals(s.db, acc.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	st := &Statement{
+		AccountID:   acc.ID,
+		AccountName: acc.Name,
+		AccountType: acc.Type,
+		Currency:    acc.Currency,
+		Decimals:    decimals,
+		From:        from,
+		To:          to,
+		GeneratedAt: time.Now().UTC(),
+	}
+	opening, err := s.GetBalanceAsOf(accountID, from.AddDate(0, 0, -1))
+	if err != nil {
+		return nil, err
+	}
+	st.OpeningBalance = NaturalBalance(acc.Type, opening)
+
+	rows, err := s.db.Query(`
+		SELECT t.id, t.reference, t.description, t.value_date, t.posted_at, e.direction, e.amount
+		FROM entries e
+		JOIN transactions t ON t.id = e.transaction_id
+		WHERE e.account_id = $1 AND t.value_date BETWEEN $2::DATE AND $3::DATE
+		ORDER BY t.value_date, t.posted_at, e.created_at, e.id
+	`, accountID, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load statement entries: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var l StatementLine
+		if err := rows.Scan(&l.TransactionID, &l.Reference, &l.Description, &l.ValueDate, &l.PostedAt, &l.Direction, &l.Amount); err != nil {
+			return nil, fmt.Errorf("failed to scan statement entry: %w", err)
+		}
+		st.Lines = append(st.Lines, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load statement entries: %w", err)
+	}
+
+	BuildStatement(st)
+	return st, nil
+}
+
+// SaveStatement stores a generated statement, replacing an earlier one for the same
+// account and period, and sets its ID.
+func (s *Service) SaveStatement(st *Statement) error {
+	doc, err := json.Marshal(st)
+	if err != nil {
+		return fmt.Errorf("failed to marshal statement: %w", err)
+	}
+	var id uuid.UUID
+	err = s.db.QueryRow(`
+		INSERT INTO account_statements (account_id, period_start, period_end, opening_balance, closing_balance, total_debits, total_credits, statement, generated_at)
+		VALUES ($1, $2::DATE, $3::DATE, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (account_id, period_start, period_end) DO UPDATE
+		SET opening_balance = EXCLUDED.opening_balance, closing_balance = EXCLUDED.closing_balance,
+		    total_debits = EXCLUDED.total_debits, total_credits = EXCLUDED.total_credits,
+		    statement = EXCLUDED.statement, generated_at = EXCLUDED.generated_at
+		RETURNING id
+	`, st.AccountID, st.From.Format("2006-01-02"), st.To.Format("2006-01-02"),
+		st.OpeningBalance, st.ClosingBalance, st.TotalDebits, st.TotalCredits, doc, st.GeneratedAt).Scan(&id)
+	if err != nil {
+		return fmt.Errorf("failed to store statement: %w", err)
+	}
+	st.ID = &id
+	return nil
+}
+
+// GetStatement returns a stored statement as it was generated.
+func (s *Service) GetStatement(id uuid.UUID) (*Statement, error) {
+	var doc []byte
+	err := s.db.QueryRow(`SELECT statement FROM account_statements WHERE id = $1`, id).Scan(&doc)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrStatementNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load statement: %w", err)
+	}
+	var st Statement
+	if err := json.Unmarshal(doc, &st); err != nil {
+		return nil, fmt.Errorf("failed to decode statement: %w", err)
+	}
+	st.ID = &id
+	return &st, nil
+}
+
+// StatementSummary describes a stored statement without its lines.
+type StatementSummary struct {
+	ID             uuid.UUID `json:"id"`
+	AccountID      uuid.UUID `json:"account_id"`
+	From           time.Time `json:"from"`
+	To             time.Time `json:"to"`
+	OpeningBalance int64     `json:"opening_balance"`
+	ClosingBalance int64     `json:"closing_balance"`
+	TotalDebits    int64     `json:"total_debits"`
+	TotalCredits   int64     `json:"total_credits"`
+	GeneratedAt    time.Time `json:"generated_at"`
+}
+
+// ListStatements returns an account's stored statements, latest period first.
+func (s *Service) ListStatements(accountID uuid.UUID) ([]StatementSummary, error) {
+	rows, err := s.db.Query(`
+		SELECT id, account_id, period_start, period_end, opening_balance, closing_balance, total_debits, total_credits, generated_at
+		FROM account_statements
+		WHERE account_id = $1
+		ORDER BY period_end DESC
+	`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statements: %w", err)
+	}
+	defer rows.Close()
+
+	var statements []StatementSummary
+	for rows.Next() {
+		var st StatementSummary
+		if err := rows.Scan(&st.ID, &st.AccountID, &st.From, &st.To, &st.OpeningBalance, &st.ClosingBalance, &st.TotalDebits, &st.TotalCredits, &st.GeneratedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan statement: %w", err)
+		}
+		statements = append(statements, st)
+	}
+	return statements, rows.Err()
+}
+
+// MonthBounds returns the first and last day of the calendar month containing t.
+func MonthBounds(t time.Time) (time.Time, time.Time) {
+	first := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return first, first.AddDate(0, 1, -1)
+}
+
+// GenerateMonthlyStatements generates and stores the statement of every active
+// customer account for the calendar month containing month. A failure on one
+// account does not stop the others; the number stored and the failures are returned.
+func (s *Service) GenerateMonthlyStatements(month time.Time) (int, []error) {
+	from, to := MonthBounds(month)
+	rows, err := s.db.Query(`
+		SELECT id FROM accounts
+		WHERE account_category <> 'SYSTEM' AND status = 'ACTIVE' AND created_at::DATE <= $1::DATE
+		ORDER BY id
+	`, to.Format("2006-01-02"))
+	if err != nil {
+		return 0, []error{fmt.Errorf("failed to list accounts: %w", err)}
+	}
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, []error{fmt.Errorf("failed to scan account: %w", err)}
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, []error{fmt.Errorf("failed to list accounts: %w", err)}
+	}
+
+	var stored int
+	var errs []error
+	for _, id := range ids {
+		st, err := s.GenerateStatement(id, from, to)
+		if err == nil {
+			err = s.SaveStatement(st)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("account %s: %w", id, err))
+			continue
+		}
+		stored++
+	}
+	return stored, errs
+}
@@ -0,0 +1,531 @@
+package ledger
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// eventTypes lists the events posting templates can be defined for.
+var eventTypes = map[EventType]bool{
+	EventDeposit:         true,
+	EventWithdrawal:      true,
+	EventTransfer:        true,
+	EventInterestAccrual: true,
+	EventFee:             true,
+
+	EventInterestCapitalization:      true,
+	EventDebitInterestAccrual:        true,
+	EventDebitInterestCapitalization: true,
+}
+
+// accrualEvents post between GL accounts only; the event's account is tracked in
+// the interest accrual sub-ledger instead.
+var accrualEvents = map[EventType]bool{
+	EventInterestAccrual:      true,
+	EventDebitInterestAccrual: true,
+}
+
+// PostingEvent is a business event to be posted through its template.
+type PostingEvent struct {
+	Type           EventType
+	AccountID      uuid.UUID
+	CounterpartyID uuid.UUID // Transfers only
+	Amount         int64     // Minor units of the account's currency
+	Reference      string
+	Description    string // Defaults to the template's
+	ValueDate      time.Time
+	EffectiveDate  time.Time
+	Metadata       Metadata
+}
+
+// validateTemplate checks a template's lines. Every line posts the full event
+// amount, so a template balances when it has as many debits as credits.
+func validateTemplate(t *PostingTemplate) error {
+	if !eventTypes[t.EventType] {
+		return fmt.Errorf("%w: unknown event type %q", ErrInvalidTemplate, t.EventType)
+	}
+	if t.Description == "" {
+		return fmt.Errorf("%w: description is required", ErrInvalidTemplate)
+	}
+	if len(t.Lines) < 2 {
+		return fmt.Errorf("%w: at least two lines are required", ErrInvalidTemplate)
+	}
+	var debits, credits int
+	var hasAccount bool
+	for i, l := range t.Lines {
+		switch l.Direction {
+		case Debit:
+			debits++
+		case Credit:
+			credits++
+		default:
+			return fmt.Errorf("%w: line %d has invalid direction %q", ErrInvalidTemplate, i+1, l.Direction)
+		}
+		switch l.Role {
+		case RoleAccount:
+			hasAccount = true
+		case RoleCounterparty:
+		case RoleGL:
+			if l.GLCode == "" {
+				return fmt.Errorf("%w: line %d needs a gl_code", ErrInvalidTemplate, i+1)
+			}
+			continue
+		default:
+			return fmt.Errorf("%w: line %d has invalid account_role %q", ErrInvalidTemplate, i+1, l.Role)
+		}
+		if l.GLCode != "" {
+			return fmt.Errorf("%w: line %d: gl_code only applies to GL lines", ErrInvalidTemplate, i+1)
+		}
+	}
+	if debits != credits {
+		return fmt.Errorf("%w: %d debit and %d credit lines do not balance", ErrInvalidTemplate, debits, credits)
+	}
+	if !hasAccount && !accrualEvents[t.EventType] {
+		return fmt.Errorf("%w: no line posts to the event's ACCOUNT", ErrInvalidTemplate)
+	}
+	return nil
+}
+
+// checkTemplateGLs makes sure every GL line names an existing non-header GL account.
+func checkTemplateGLs(q rowQuerier, lines []PostingTemplateLine) error {
+	for _, l := range lines {
+		if l.Role != RoleGL {
+			continue
+		}
+		g, err := getGLAccount(q, l.GLCode)
+		if err != nil {
+			return err
+		}
+		if g.IsHeader {
+			return fmt.Errorf("%w: %s is a header GL account", ErrInvalidTemplate, l.GLCode)
+		}
+	}
+	return nil
+}
+
+// expandTemplate turns template lines into entries of amount in currency. account
+// resolves the account a line posts to.
+func expandTemplate(lines []PostingTemplateLine, amount int64, currency string, account func(PostingTemplateLine) (uuid.UUID, error)) ([]Entry, error) {
+	entries := make([]Entry, 0, len(lines))
+	for _, l := range lines {
+		id, err := account(l)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, Entry{AccountID: id, Direction: l.Direction, Amount: amount, Currency: currency})
+	}
+	return entries, nil
+}
+
+// CreatePostingTemplate stores a template. Only one template may exist per event
+// type, product and currency.
+func (s *Service) CreatePostingTemplate(t PostingTemplate) (*PostingTemplate, error) {
+	if err := validateTemplate(&t); err != nil {
+		return nil, err
+	}
+	if err := checkTemplateGLs(s.db, t.Lines); err != nil {
+		return nil, err
+	}
+	var currency sql.NullString
+	if t.Currency != "" {
+		if _, err := currencyDecimals(s.db, t.Currency); err != nil {
+			return nil, err
+		}
+		currency = sql.NullString{String: t.Currency, Valid: true}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO posting_templates (event_type, product_id, currency, description)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`, t.EventType, t.ProductID, currency, t.Description).Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return nil, fmt.Errorf("%w: a %s template for this product and currency already exists", ErrInvalidTemplate, t.EventType)
+		}
+		return nil, fmt.Errorf("failed to create posting template: %w", err)
+	}
+	if err := insertTemplateLines(tx, t.ID, t.Lines); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &t, nil
+}
+
+func insertTemplateLines(tx *sql.Tx, templateID uuid.UUID, lines []PostingTemplateLine) error {
+	for i, l := range lines {
+		var glCode sql.NullString
+		if l.GLCode != "" {
+			glCode = sql.NullString{String: l.GLCode, Valid: true}
+		}
+		_, err := tx.Exec(`
+			INSERT INTO posting_template_lines (template_id, line_no, direction, account_role, gl_code)
+			VALUES ($1, $2, $3, $4, $5)
+		`, templateID, i+1, l.Direction, l.Role, glCode)
+		if err != nil {
+			return fmt.Errorf("failed to insert template line: %w", err)
+		}
+	}
+	return nil
+}
+
+// UpdatePostingTemplate replaces a template's description and lines. Its event
+// type and scope are fixed.
+func (s *Service) UpdatePostingTemplate(id uuid.UUID, description string, lines []PostingTemplateLine) (*PostingTemplate, error) {
+	t, err := s.GetPostingTemplate(id)
+	if err != nil {
+		return nil, err
+	}
+	t.Description, t.Lines = description, lines
+	if err := validateTemplate(t); err != nil {
+		return nil, err
+	}
+	if err := checkTemplateGLs(s.db, t.Lines); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		UPDATE posting_templates SET description = $1, updated_at = NOW()
+		WHERE id = $2
+		RETURNING updated_at
+	`, description, id).Scan(&t.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update posting template: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM posting_template_lines WHERE template_id = $1`, id); err != nil {
+		return nil, fmt.Errorf("failed to replace template lines: %w", err)
+	}
+	if err := insertTemplateLines(tx, id, lines); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return t, nil
+}
+
+// DeletePostingTemplate removes a template. Events it covered fall back to a less
+// specific template.
+func (s *Service) DeletePostingTemplate(id uuid.UUID) error {
+	res, err := s.db.Exec(`DELETE FROM posting_templates WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete posting template: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("%w: %s", ErrTemplateNotFound, id)
+	}
+	return nil
+}
+
+const templateColumns = `id, event_type, product_id, COALESCE(currency, ''), description, created_at, updated_at`
+
+func scanTemplate(row rowScanner) (*PostingTemplate, error) {
+	var t PostingTemplate
+	if err := row.Scan(&t.ID, &t.EventType, &t.ProductID, &t.Currency, &t.Description, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// GetPostingTemplate returns a template with its lines.
+func (s *Service) GetPostingTemplate(id uuid.UUID) (*PostingTemplate, error) {
+	t, err := scanTemplate(s.db.QueryRow(`SELECT `+templateColumns+` FROM posting_templates WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load posting template: %w", err)
+	}
+	if t.Lines, err = s.templateLines(t.ID); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// ListPostingTemplates returns every template with its lines, by event type.
+func (s *Service) ListPostingTemplates() ([]*PostingTemplate, error) {
+	rows, err := s.db.Query(`
+		SELECT ` + templateColumns + ` FROM posting_templates
+		ORDER BY event_type, product_id NULLS FIRST, currency NULLS FIRST
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list posting templates: %w", err)
+	}
+	var templates []*PostingTemplate
+	for rows.Next() {
+		t, err := scanTemplate(rows)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan posting template: %w", err)
+		}
+		templates = append(templates, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, t := range templates {
+		if t.Lines, err = s.templateLines(t.ID); err != nil {
+			return nil, err
+		}
+	}
+	return templates, nil
+}
+
+func (s *Service) templateLines(templateID uuid.UUID) ([]PostingTemplateLine, error) {
+	rows, err := s.db.Query(`
+		SELECT direction, account_role, COALESCE(gl_code, '') FROM posting_template_lines
+		WHERE template_id = $1
+		ORDER BY line_no
+	`, templateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load template lines: %w", err)
+	}
+	defer rows.Close()
+
+	var lines []PostingTemplateLine
+	for rows.Next() {
+		var l PostingTemplateLine
+		if err := rows.Scan(&l.Direction, &l.Role, &l.GLCode); err != nil {
+			return nil, fmt.Errorf("failed to scan template line: %w", err)
+		}
+		lines = append(lines, l)
+	}
+	return lines, rows.Err()
+}
+
+// ResolvePostingTemplate picks the template for an event: one for the product and
+// currency, then the product, then the currency, then the default.
+func (s *Service) ResolvePostingTemplate(event EventType, productID *uuid.UUID, currency string) (*PostingTemplate, error) {
+	t, err := scanTemplate(s.db.QueryRow(`
+		SELECT `+templateColumns+` FROM posting_templates
+		WHERE event_type = $1
+		  AND (product_id IS NULL OR product_id = $2)
+		  AND (currency IS NULL OR currency = $3)
+		ORDER BY product_id IS NULL, currency IS NULL
+		LIMIT 1
+	`, event, productID, currency))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s in %s", ErrNoPostingTemplate, event, currency)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve posting template: %w", err)
+	}
+	if t.Lines, err = s.templateLines(t.ID); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// PostEvent posts a business event through its template. The amount is in the
+// account's currency; GL lines post to the GL code's system account in that
+// currency. When a transfer counterparty holds another currency, its line is
+// converted at the latest rate and balanced through the FX Position accounts.
+func (s *Service) PostEvent(ev PostingEvent) (*Transaction, error) {
+	req, err := s.eventRequest(ev)
+	if err != nil {
+		return nil, err
+	}
+	return s.Post(*req)
+}
+
+// AuthorizeEvent records a business event as a pending transaction, expanded
+// as PostEvent would post it. See Authorize.
+func (s *Service) AuthorizeEvent(ev PostingEvent, expiresAt time.Time) (*Transaction, error) {
+	req, err := s.eventRequest(ev)
+	if err != nil {
+		return nil, err
+	}
+	return s.Authorize(*req, expiresAt)
+}
+
+// eventPoster is an event type resolved for one account: its posting template and
+// the system accounts of the template's GL lines. It builds posting requests without
+// touching the database, so they can be built inside a transaction from amounts read
+// there. Templates with a counterparty line are not supported.
+type eventPoster struct {
+	account        *Account
+	tmpl           *PostingTemplate
+	systemAccounts map[string]uuid.UUID
+}
+
+// resolveEventPoster looks up the account and the event's template and gets or
+// creates the system accounts the template posts to.
+func (s *Service) resolveEventPoster(eventType EventType, accountID uuid.UUID) (*eventPoster, error) {
+	acc, err := s.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+	if acc == nil {
+		return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, accountID)
+	}
+	tmpl, err := s.ResolvePostingTemplate(eventType, acc.ProductID, acc.Currency)
+	if err != nil {
+		return nil, err
+	}
+	p := &eventPoster{account: acc, tmpl: tmpl, systemAccounts: make(map[string]uuid.UUID)}
+	for _, l := range tmpl.Lines {
+		switch l.Role {
+		case RoleAccount:
+		case RoleCounterparty:
+			return nil, fmt.Errorf("%w: %s template cannot have a counterparty line", ErrInvalidTemplate, eventType)
+		default:
+			if _, ok := p.systemAccounts[l.GLCode]; ok {
+				continue
+			}
+			if p.systemAccounts[l.GLCode], err = s.GetOrCreateGLSystemAccount(l.GLCode, acc.Currency); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return p, nil
+}
+
+// request builds the posting request for amount, as eventRequest would.
+func (p *eventPoster) request(amount int64, reference string, valueDate time.Time) (*PostingRequest, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+	entries, err := expandTemplate(p.tmpl.Lines, amount, p.account.Currency, func(l PostingTemplateLine) (uuid.UUID, error) {
+		if l.Role == RoleAccount {
+			return p.account.ID, nil
+		}
+		return p.systemAccounts[l.GLCode], nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &PostingRequest{
+		Reference:   reference,
+		Description: p.tmpl.Description,
+		Entries:     entries,
+		ValueDate:   valueDate,
+	}, nil
+}
+
+// eventRequest expands a business event through its template into a posting request.
+func (s *Service) eventRequest(ev PostingEvent) (*PostingRequest, error) {
+	if ev.Amount <= 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+	acc, err := s.GetAccount(ev.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if acc == nil {
+		return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, ev.AccountID)
+	}
+	tmpl, err := s.ResolvePostingTemplate(ev.Type, acc.ProductID, acc.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	var counterparty *Account
+	systemAccounts := make(map[string]uuid.UUID)
+	entries, err := expandTemplate(tmpl.Lines, ev.Amount, acc.Currency, func(l PostingTemplateLine) (uuid.UUID, error) {
+		switch l.Role {
+		case RoleAccount:
+			return acc.ID, nil
+		case RoleCounterparty:
+			if ev.CounterpartyID == uuid.Nil {
+				return uuid.Nil, fmt.Errorf("%w: %s template needs a counterparty account", ErrInvalidTemplate, ev.Type)
+			}
+			if counterparty == nil {
+				c, err := s.GetAccount(ev.CounterpartyID)
+				if err != nil {
+					return uuid.Nil, err
+				}
+				if c == nil {
+					return uuid.Nil, fmt.Errorf("%w: %s", ErrAccountNotFound, ev.CounterpartyID)
+				}
+				counterparty = c
+			}
+			return counterparty.ID, nil
+		default:
+			id, ok := systemAccounts[l.GLCode]
+			if !ok {
+				var err error
+				if id, err = s.GetOrCreateGLSystemAccount(l.GLCode, acc.Currency); err != nil {
+					return uuid.Nil, err
+				}
+				systemAccounts[l.GLCode] = id
+			}
+			return id, nil
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	description := ev.Description
+	if description == "" {
+		description = tmpl.Description
+	}
+
+	// Cross-currency counterparty: convert its lines and add the FX legs
+	if counterparty != nil && counterparty.Currency != acc.Currency {
+		converted, fx, err := s.ConvertAmount(ev.Amount, acc.Currency, counterparty.Currency)
+		if err != nil {
+			return nil, err
+		}
+		if converted <= 0 {
+			return nil, fmt.Errorf("amount %d %s converts to nothing in %s", ev.Amount, acc.Currency, counterparty.Currency)
+		}
+		fromPosition, err := s.GetOrCreateGLSystemAccount(GLCodeFXPosition, acc.Currency)
+		if err != nil {
+			return nil, err
+		}
+		toPosition, err := s.GetOrCreateGLSystemAccount(GLCodeFXPosition, counterparty.Currency)
+		if err != nil {
+			return nil, err
+		}
+		for i, e := range entries {
+			if e.AccountID != counterparty.ID {
+				continue
+			}
+			opposite := Debit
+			if e.Direction == Debit {
+				opposite = Credit
+			}
+			entries[i].Amount, entries[i].Currency = converted, counterparty.Currency
+			entries = append(entries,
+				Entry{AccountID: fromPosition, Direction: e.Direction, Amount: ev.Amount, Currency: acc.Currency},
+				Entry{AccountID: toPosition, Direction: opposite, Amount: converted, Currency: counterparty.Currency},
+			)
+		}
+		description = fmt.Sprintf("%s (FX %s/%s %s as of %s)", description, fx.Base, fx.Quote, fx.Rate, fx.AsOf.Format(time.RFC3339))
+	}
+
+	return &PostingRequest{
+		Reference:     ev.Reference,
+		Description:   description,
+		Entries:       entries,
+		ValueDate:     ev.ValueDate,
+		EffectiveDate: ev.EffectiveDate,
+		Metadata:      ev.Metadata,
+	}, nil
+}
@@ -0,0 +1,132 @@
+package ledger
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PostingPolicy bounds how far value and effective dates may stray from today.
+type PostingPolicy struct {
+	MaxBackdateDays int // Oldest allowed date is today minus this many days
+	MaxForwardDays  int // Latest allowed date is today plus this many days
+}
+
+// DefaultPostingPolicy allows corrections up to a month back and scheduled
+// postings up to a month ahead.
+var DefaultPostingPolicy = PostingPolicy{MaxBackdateDays: 30, MaxForwardDays: 30}
+
+// PostingRequest is a transaction to post. Zero dates mean today.
+type PostingRequest struct {
+	Reference     string
+	Description   string
+	Entries       []Entry
+	ValueDate     time.Time
+	EffectiveDate time.Time
+	Metadata      Metadata
+	noFundsCheck  bool // Charges the bank applies regardless of available funds
+}
+
+// SetPostingPolicy replaces the back/forward-dating policy.
+func (s *Service) SetPostingPolicy(p PostingPolicy) {
+	s.policy = p
+}
+
+// Post records a transaction with optional value and effective dates.
+// Dates outside the posting policy are rejected with ErrDateOutOfPolicy.
+// Forward-dated postings update the cached balance immediately, but only count
+// towards GetBalanceAsOf from their value date. A posting that hits a deadlock
+// or serialization failure is retried.
+func (s *Service) Post(req PostingRequest) (*Transaction, error) {
+	var transaction *Transaction
+	err := s.inTx(func(tx *sql.Tx) error {
+		var err error
+		transaction, err = s.postRequestInTx(tx, req)
+		return err
+	})
+	return transaction, err
+}
+
+// postRequestInTx validates a posting request against the entry and metadata rules
+// and the dating policy and posts it within tx.
+func (s *Service) postRequestInTx(tx *sql.Tx, req PostingRequest) (*Transaction, error) {
+	if err := s.validateRequest(req); err != nil {
+		return nil, err
+	}
+	return s.postInTx(tx, &Transaction{
+		Reference:     req.Reference,
+		Description:   req.Description,
+		ValueDate:     DateOf(req.ValueDate),
+		EffectiveDate: DateOf(req.EffectiveDate),
+		Metadata:      req.Metadata,
+		Entries:       req.Entries,
+		noFundsCheck:  req.noFundsCheck,
+	})
+}
+
+// validateRequest checks a posting request's entries and metadata and that its dates
+// are within the posting policy.
+func (s *Service) validateRequest(req PostingRequest) error {
+	if err := validateEntries(req.Entries); err != nil {
+		return err
+	}
+	if err := validateMetadata(req.Metadata); err != nil {
+		return err
+	}
+	today := DateOf(time.Now())
+	for _, d := range []struct {
+		name string
+		date time.Time
+	}{{"value date", req.ValueDate}, {"effective date", req.EffectiveDate}} {
+		if err := s.policy.check(d.name, d.date, today); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p PostingPolicy) check(name string, date, today time.Time) error {
+	if date.IsZero() {
+		return nil
+	}
+	date = DateOf(date)
+	if earliest := today.AddDate(0, 0, -p.MaxBackdateDays); date.Before(earliest) {
+		return fmt.Errorf("%w: %s %s is before %s", ErrDateOutOfPolicy, name, date.Format("2006-01-02"), earliest.Format("2006-01-02"))
+	}
+	if latest := today.AddDate(0, 0, p.MaxForwardDays); date.After(latest) {
+		return fmt.Errorf("%w: %s %s is after %s", ErrDateOutOfPolicy, name, date.Format("2006-01-02"), latest.Format("2006-01-02"))
+	}
+	return nil
+}
+
+// DateOf truncates t to its calendar date (UTC midnight). The zero time stays zero.
+func DateOf(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// GetBalanceAsOf returns the signed balance (debit +, credit -) of an account from
+// the entries whose value date is on or before asOf. Unlike accounts.balance it
+// ignores forward-dated postings and reflects back-dated ones.
+func (s *Service) GetBalanceAsOf(accountID uuid.UUID, asOf time.Time) (int64, error) {
+	return balanceAsOf(s.db, accountID, asOf)
+}
+
+func balanceAsOf(q rowQuerier, accountID uuid.UUID, asOf time.Time) (int64, error) {
+	query := `
+		SELECT COALESCE(SUM(CASE WHEN e.direction = 'DEBIT' THEN e.amount ELSE -e.amount END), 0)
+		FROM entries e
+		JOIN transactions t ON t.id = e.transaction_id
+		WHERE e.account_id = $1 AND t.value_date <= $2::DATE
+	`
+	var balance int64
+	if err := q.QueryRow(query, accountID, asOf.Format("2006-01-02")).Scan(&balance); err != nil {
+		return 0, fmt.Errorf("failed to compute balance as of %s: %w", asOf.Format("2006-01-02"), err)
+	}
+	return balance, nil
+}
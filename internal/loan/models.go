@@ -0,0 +1,196 @@
+package loan
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Sentinel errors returned by the loan service.
+var (
+	ErrNotFound            = errors.New("loan not found")
+	ErrProductNotFound     = errors.New("loan product not found")
+	ErrInvalidProduct      = errors.New("invalid loan product")
+	ErrInvalidLoan         = errors.New("invalid loan")
+	ErrInvalidRepayment    = errors.New("invalid repayment")
+	ErrInvalidStatus       = errors.New("invalid loan status")
+	ErrRepaymentInProgress = errors.New("another repayment of this loan is in progress")
+)
+
+// ScheduleType is how a loan's principal is amortized over its monthly installments.
+type ScheduleType string
+
+const (
+	Annuity      ScheduleType = "ANNUITY"       // Equal installments of principal and interest
+	StraightLine ScheduleType = "STRAIGHT_LINE" // Equal principal plus interest on the outstanding balance
+	Bullet       ScheduleType = "BULLET"        // Interest only; the principal is due with the last installment
+)
+
+// Component is a part of an installment a repayment can pay.
+type Component string
+
+const (
+	Fees      Component = "FEES"
+	Interest  Component = "INTEREST"
+	Principal Component = "PRINCIPAL"
+)
+
+// Waterfall is the order in which a repayment pays the components of an installment.
+// It names every component exactly once.
+type Waterfall []Component
+
+// DefaultWaterfall pays fees first, then interest, then principal.
+var DefaultWaterfall = Waterfall{Fees, Interest, Principal}
+
+// Value implements driver.Valuer.
+func (w Waterfall) Value() (driver.Value, error) {
+	return json.Marshal(w)
+}
+
+// Scan implements sql.Scanner.
+func (w *Waterfall) Scan(src any) error {
+	b, ok := src.([]byte)
+	if !ok {
+		return fmt.Errorf("cannot scan %T into Waterfall", src)
+	}
+	return json.Unmarshal(b, w)
+}
+
+// Product holds the terms a loan copies when it is disbursed. Amounts are in minor
+// units of the loan's currency.
+type Product struct {
+	ID              uuid.UUID    `json:"id"`
+	Name            string       `json:"name"`
+	InterestRateBPS int64        `json:"interest_rate_bps"` // Nominal annual rate, charged monthly
+	ScheduleType    ScheduleType `json:"schedule_type"`
+	TermMonths      int          `json:"term_months"`
+	LateFee         int64        `json:"late_fee"`   // Charged once on each installment paid late
+	GraceDays       int          `json:"grace_days"` // Days past due before the late fee is charged
+	Waterfall       Waterfall    `json:"waterfall"`
+	CreatedAt       time.Time    `json:"created_at"`
+}
+
+type Status string
+
+const (
+	StatusPending   Status = "PENDING" // Disbursement in progress
+	StatusActive    Status = "ACTIVE"
+	StatusPaidOff   Status = "PAID_OFF"
+	StatusCancelled Status = "CANCELLED" // Disbursement failed
+)
+
+// DelinquencyBucket groups loans by how many days their oldest unpaid installment is overdue.
+type DelinquencyBucket string
+
+const (
+	BucketCurrent DelinquencyBucket = "CURRENT"
+	Bucket1To30   DelinquencyBucket = "DPD_1_30"
+	Bucket31To60  DelinquencyBucket = "DPD_31_60"
+	Bucket61To90  DelinquencyBucket = "DPD_61_90"
+	Bucket90Plus  DelinquencyBucket = "DPD_90_PLUS"
+)
+
+// Loan is a disbursed (or disbursing) loan. Its terms are copied from the product,
+// so later product changes do not affect it. LoanAccountID is the ASSET ledger
+// account holding the outstanding principal.
+type Loan struct {
+	ID                        uuid.UUID         `json:"id"`
+	ProductID                 uuid.UUID         `json:"product_id"`
+	CustomerAccountID         uuid.UUID         `json:"customer_account_id"`
+	LoanAccountID             uuid.UUID         `json:"loan_account_id"`
+	Currency                  string            `json:"currency"`
+	Principal                 int64             `json:"principal"`
+	InterestRateBPS           int64             `json:"interest_rate_bps"`
+	ScheduleType              ScheduleType      `json:"schedule_type"`
+	TermMonths                int               `json:"term_months"`
+	LateFee                   int64             `json:"late_fee"`
+	GraceDays                 int               `json:"grace_days"`
+	Waterfall                 Waterfall         `json:"waterfall"`
+	StartDate                 time.Time         `json:"start_date"` // Disbursement date; installments fall due monthly from it
+	Status                    Status            `json:"status"`
+	DisbursementTransactionID *uuid.UUID        `json:"disbursement_transaction_id,omitempty"`
+	DaysPastDue               int               `json:"days_past_due"`
+	DelinquencyBucket         DelinquencyBucket `json:"delinquency_bucket"`
+	CreatedAt                 time.Time         `json:"created_at"`
+	UpdatedAt                 time.Time         `json:"updated_at"`
+}
+
+// Installment is one line of a loan's amortization schedule.
+type Installment struct {
+	Number         int        `json:"number"`
+	DueDate        time.Time  `json:"due_date"`
+	Principal      int64      `json:"principal"`
+	Interest       int64      `json:"interest"`
+	Fees           int64      `json:"fees"`
+	PrincipalPaid  int64      `json:"principal_paid"`
+	InterestPaid   int64      `json:"interest_paid"`
+	FeesPaid       int64      `json:"fees_paid"`
+	LateFeeCharged bool       `json:"late_fee_charged"`
+	PaidAt         *time.Time `json:"paid_at,omitempty"`
+}
+
+// Outstanding returns what is still owed on the installment.
+func (i *Installment) Outstanding() int64 {
+	return i.Principal - i.PrincipalPaid + i.Interest - i.InterestPaid + i.Fees - i.FeesPaid
+}
+
+// Allocation is the part of a repayment paid into one installment.
+type Allocation struct {
+	Installment int   `json:"installment"`
+	Fees        int64 `json:"fees"`
+	Interest    int64 `json:"interest"`
+	Principal   int64 `json:"principal"`
+}
+
+// Allocations are stored as JSON on the repayment.
+type Allocations []Allocation
+
+// Value implements driver.Valuer.
+func (a Allocations) Value() (driver.Value, error) {
+	return json.Marshal(a)
+}
+
+// Scan implements sql.Scanner.
+func (a *Allocations) Scan(src any) error {
+	b, ok := src.([]byte)
+	if !ok {
+		return fmt.Errorf("cannot scan %T into Allocations", src)
+	}
+	return json.Unmarshal(b, a)
+}
+
+type RepaymentStatus string
+
+const (
+	RepaymentProcessing RepaymentStatus = "PROCESSING" // Transaction being posted
+	RepaymentPosted     RepaymentStatus = "POSTED"
+	RepaymentFailed     RepaymentStatus = "FAILED"
+)
+
+// Repayment is a payment towards a loan and how it was allocated.
+type Repayment struct {
+	ID            uuid.UUID       `json:"id"`
+	LoanID        uuid.UUID       `json:"loan_id"`
+	FromAccountID uuid.UUID       `json:"from_account_id"`
+	Amount        int64           `json:"amount"`
+	Fees          int64           `json:"fees"`
+	Interest      int64           `json:"interest"`
+	Principal     int64           `json:"principal"`
+	Allocations   Allocations     `json:"allocations"`
+	Status        RepaymentStatus `json:"status"`
+	TransactionID *uuid.UUID      `json:"transaction_id,omitempty"`
+	Error         string          `json:"error,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	FinishedAt    *time.Time      `json:"finished_at,omitempty"`
+}
+
+// DelinquencyResult summarises one UpdateDelinquency run.
+type DelinquencyResult struct {
+	Loans       int `json:"loans"`
+	Delinquent  int `json:"delinquent"`
+	FeesCharged int `json:"fees_charged"`
+}
@@ -0,0 +1,195 @@
+package loan
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/nathanmocogni/core-banking-system/internal/ledger"
+)
+
+// maxTermMonths bounds a loan's term at 50 years.
+const maxTermMonths = 600
+
+// validateProduct checks a product's terms. Defaults must already be applied.
+func validateProduct(p *Product) error {
+	switch {
+	case p.Name == "":
+		return fmt.Errorf("%w: name is required", ErrInvalidProduct)
+	case p.InterestRateBPS < 0:
+		return fmt.Errorf("%w: interest_rate_bps cannot be negative", ErrInvalidProduct)
+	case p.ScheduleType != Annuity && p.ScheduleType != StraightLine && p.ScheduleType != Bullet:
+		return fmt.Errorf("%w: schedule_type must be ANNUITY, STRAIGHT_LINE or BULLET", ErrInvalidProduct)
+	case p.TermMonths < 1 || p.TermMonths > maxTermMonths:
+		return fmt.Errorf("%w: term_months must be between 1 and %d", ErrInvalidProduct, maxTermMonths)
+	case p.LateFee < 0:
+		return fmt.Errorf("%w: late_fee cannot be negative", ErrInvalidProduct)
+	case p.GraceDays < 0:
+		return fmt.Errorf("%w: grace_days cannot be negative", ErrInvalidProduct)
+	}
+	return validateWaterfall(p.Waterfall)
+}
+
+// validateWaterfall checks that a waterfall names fees, interest and principal once each.
+func validateWaterfall(w Waterfall) error {
+	seen := map[Component]bool{}
+	for _, c := range w {
+		if c != Fees && c != Interest && c != Principal {
+			return fmt.Errorf("%w: unknown waterfall component %q", ErrInvalidProduct, c)
+		}
+		if seen[c] {
+			return fmt.Errorf("%w: waterfall names %s twice", ErrInvalidProduct, c)
+		}
+		seen[c] = true
+	}
+	if len(seen) != 3 {
+		return fmt.Errorf("%w: waterfall must order FEES, INTEREST and PRINCIPAL", ErrInvalidProduct)
+	}
+	return nil
+}
+
+// dueDate returns the date the nth installment (from 1) falls due: n months after
+// start, or the month's last day if it is shorter. A loan started on Jan 31 is due
+// on Feb 28, Mar 31, Apr 30.
+func dueDate(start time.Time, n int) time.Time {
+	first := time.Date(start.Year(), start.Month()+time.Month(n), 1, 0, 0, 0, 0, time.UTC)
+	day := start.Day()
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// monthlyInterest returns a month's interest on balance at an annual rate in basis
+// points, rounded half up to the minor unit.
+func monthlyInterest(balance, rateBPS int64) int64 {
+	num := new(big.Int).Mul(big.NewInt(balance), big.NewInt(rateBPS))
+	num.Add(num, big.NewInt(60000))
+	return num.Quo(num, big.NewInt(120000)).Int64()
+}
+
+// annuityPayment returns the level monthly payment that repays principal with
+// interest over term months, rounded to the minor unit.
+func annuityPayment(principal, rateBPS int64, term int) int64 {
+	if rateBPS == 0 {
+		return (principal + int64(term) - 1) / int64(term)
+	}
+	r := float64(rateBPS) / 120000
+	return int64(math.Round(float64(principal) * r / (1 - math.Pow(1+r, -float64(term)))))
+}
+
+// BuildSchedule returns the amortization schedule of a loan of principal disbursed
+// on start, with one installment a month for term months. Interest is charged
+// monthly on the outstanding principal; whatever rounding leaves over is repaid
+// with the last installment, which always clears the principal.
+func BuildSchedule(principal, rateBPS int64, term int, schedule ScheduleType, start time.Time) ([]Installment, error) {
+	switch {
+	case principal <= 0:
+		return nil, fmt.Errorf("%w: principal must be positive", ErrInvalidLoan)
+	case rateBPS < 0:
+		return nil, fmt.Errorf("%w: interest rate cannot be negative", ErrInvalidLoan)
+	case term < 1 || term > maxTermMonths:
+		return nil, fmt.Errorf("%w: term must be between 1 and %d months", ErrInvalidLoan, maxTermMonths)
+	case schedule != Annuity && schedule != StraightLine && schedule != Bullet:
+		return nil, fmt.Errorf("%w: unknown schedule type %q", ErrInvalidLoan, schedule)
+	}
+	start = ledger.DateOf(start)
+
+	payment := annuityPayment(principal, rateBPS, term)
+	installments := make([]Installment, term)
+	outstanding := principal
+	for n := 1; n <= term; n++ {
+		interest := monthlyInterest(outstanding, rateBPS)
+		var repaid int64
+		switch {
+		case n == term:
+			repaid = outstanding
+		case schedule == Annuity:
+			repaid = min(max(payment-interest, 0), outstanding)
+		case schedule == StraightLine:
+			repaid = min(principal/int64(term), outstanding)
+		}
+		installments[n-1] = Installment{Number: n, DueDate: dueDate(start, n), Principal: repaid, Interest: interest}
+		outstanding -= repaid
+	}
+	return installments, nil
+}
+
+// Allocate splits a repayment over a loan's installments: the earliest installment
+// with anything outstanding is paid first, its components in waterfall order, and
+// what is left pays the next one. Amounts beyond what is due pay later installments
+// in advance. A repayment larger than the whole outstanding balance is refused.
+func Allocate(installments []Installment, amount int64, w Waterfall) ([]Allocation, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidRepayment)
+	}
+	var total int64
+	for i := range installments {
+		total += installments[i].Outstanding()
+	}
+	if amount > total {
+		return nil, fmt.Errorf("%w: amount %d exceeds the outstanding balance %d", ErrInvalidRepayment, amount, total)
+	}
+
+	var allocations []Allocation
+	for i := range installments {
+		inst := &installments[i]
+		if amount == 0 {
+			break
+		}
+		if inst.Outstanding() == 0 {
+			continue
+		}
+		a := Allocation{Installment: inst.Number}
+		for _, c := range w {
+			var owed int64
+			var paid *int64
+			switch c {
+			case Fees:
+				owed, paid = inst.Fees-inst.FeesPaid, &a.Fees
+			case Interest:
+				owed, paid = inst.Interest-inst.InterestPaid, &a.Interest
+			case Principal:
+				owed, paid = inst.Principal-inst.PrincipalPaid, &a.Principal
+			}
+			*paid = min(owed, amount)
+			amount -= *paid
+		}
+		allocations = append(allocations, a)
+	}
+	return allocations, nil
+}
+
+// DaysPastDue returns how many days the earliest installment with anything
+// outstanding is overdue on asOf. An installment due on asOf is not yet overdue.
+func DaysPastDue(installments []Installment, asOf time.Time) int {
+	asOf = ledger.DateOf(asOf)
+	for i := range installments {
+		if installments[i].Outstanding() == 0 {
+			continue
+		}
+		due := ledger.DateOf(installments[i].DueDate)
+		if !due.Before(asOf) {
+			return 0
+		}
+		return int(asOf.Sub(due).Hours() / 24)
+	}
+	return 0
+}
+
+// BucketFor returns the delinquency bucket of a loan that is dpd days past due.
+func BucketFor(dpd int) DelinquencyBucket {
+	switch {
+	case dpd <= 0:
+		return BucketCurrent
+	case dpd <= 30:
+		return Bucket1To30
+	case dpd <= 60:
+		return Bucket31To60
+	case dpd <= 90:
+		return Bucket61To90
+	default:
+		return Bucket90Plus
+	}
+}
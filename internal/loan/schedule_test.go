@@ -0,0 +1,203 @@
+package loan
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestBuildSchedule(t *testing.T) {
+	start := date(2025, 1, 15)
+
+	// 1,000.00 at 12% over a year: 1% a month, a level payment of 88.85
+	annuity, err := BuildSchedule(100000, 1200, 12, Annuity, start)
+	if err != nil {
+		t.Fatalf("BuildSchedule failed: %v", err)
+	}
+	var repaid int64
+	for _, inst := range annuity {
+		repaid += inst.Principal
+		if inst.Number < 12 && inst.Principal+inst.Interest != 8885 {
+			t.Errorf("annuity installment %d: got %d, want 8885", inst.Number, inst.Principal+inst.Interest)
+		}
+	}
+	if repaid != 100000 {
+		t.Errorf("annuity principal: got %d, want 100000", repaid)
+	}
+	if annuity[0].Interest != 1000 || annuity[1].Interest != 921 {
+		t.Errorf("annuity interest: got %d, %d, want 1000, 921", annuity[0].Interest, annuity[1].Interest)
+	}
+	if last := annuity[11]; last.Principal+last.Interest < 8880 || last.Principal+last.Interest > 8890 {
+		t.Errorf("annuity last installment: got %d, want about 8885", last.Principal+last.Interest)
+	}
+
+	tests := []struct {
+		name      string
+		principal int64
+		rateBPS   int64
+		term      int
+		schedule  ScheduleType
+		want      [][2]int64 // Principal, interest
+	}{
+		{"straight line", 1200, 1200, 3, StraightLine, [][2]int64{{400, 12}, {400, 8}, {400, 4}}},
+		{"straight line remainder", 1000, 0, 3, StraightLine, [][2]int64{{333, 0}, {333, 0}, {334, 0}}},
+		{"bullet", 100000, 600, 3, Bullet, [][2]int64{{0, 500}, {0, 500}, {100000, 500}}},
+		{"interest-free annuity", 1000, 0, 3, Annuity, [][2]int64{{334, 0}, {334, 0}, {332, 0}}},
+		{"half up rounding", 50, 1200, 1, Annuity, [][2]int64{{50, 1}}},
+	}
+	for _, tt := range tests {
+		got, err := BuildSchedule(tt.principal, tt.rateBPS, tt.term, tt.schedule, start)
+		if err != nil {
+			t.Errorf("%s: BuildSchedule failed: %v", tt.name, err)
+			continue
+		}
+		var lines [][2]int64
+		for _, inst := range got {
+			lines = append(lines, [2]int64{inst.Principal, inst.Interest})
+		}
+		if !reflect.DeepEqual(lines, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, lines, tt.want)
+		}
+	}
+
+	for name, build := range map[string]func() ([]Installment, error){
+		"zero principal": func() ([]Installment, error) { return BuildSchedule(0, 1200, 12, Annuity, start) },
+		"negative rate":  func() ([]Installment, error) { return BuildSchedule(1000, -1, 12, Annuity, start) },
+		"zero term":      func() ([]Installment, error) { return BuildSchedule(1000, 1200, 0, Annuity, start) },
+		"unknown type":   func() ([]Installment, error) { return BuildSchedule(1000, 1200, 12, "BALLOON", start) },
+	} {
+		if _, err := build(); !errors.Is(err, ErrInvalidLoan) {
+			t.Errorf("%s: expected ErrInvalidLoan, got %v", name, err)
+		}
+	}
+}
+
+func TestDueDates(t *testing.T) {
+	schedule, err := BuildSchedule(300, 0, 4, StraightLine, date(2025, 1, 31))
+	if err != nil {
+		t.Fatalf("BuildSchedule failed: %v", err)
+	}
+	want := []time.Time{date(2025, 2, 28), date(2025, 3, 31), date(2025, 4, 30), date(2025, 5, 31)}
+	for i, inst := range schedule {
+		if !inst.DueDate.Equal(want[i]) {
+			t.Errorf("installment %d: got %s, want %s", inst.Number, inst.DueDate.Format("2006-01-02"), want[i].Format("2006-01-02"))
+		}
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	installments := func() []Installment {
+		return []Installment{
+			{Number: 1, Principal: 100, Interest: 10, Fees: 5},
+			{Number: 2, Principal: 100, Interest: 10},
+		}
+	}
+	tests := []struct {
+		name      string
+		paid      func([]Installment)
+		amount    int64
+		waterfall Waterfall
+		want      []Allocation
+	}{
+		{"fees, interest, principal", nil, 50, DefaultWaterfall, []Allocation{{Installment: 1, Fees: 5, Interest: 10, Principal: 35}}},
+		{"principal first", nil, 50, Waterfall{Principal, Interest, Fees}, []Allocation{{Installment: 1, Principal: 50}}},
+		{"interest first", nil, 12, Waterfall{Interest, Fees, Principal}, []Allocation{{Installment: 1, Interest: 10, Fees: 2}}},
+		{"into the next installment", nil, 120, DefaultWaterfall, []Allocation{
+			{Installment: 1, Fees: 5, Interest: 10, Principal: 100},
+			{Installment: 2, Interest: 5},
+		}},
+		{"skips paid installments", func(i []Installment) { i[0].FeesPaid, i[0].InterestPaid, i[0].PrincipalPaid = 5, 10, 100 }, 20, DefaultWaterfall,
+			[]Allocation{{Installment: 2, Interest: 10, Principal: 10}}},
+		{"partly paid installment", func(i []Installment) { i[0].FeesPaid, i[0].InterestPaid = 5, 4 }, 10, DefaultWaterfall,
+			[]Allocation{{Installment: 1, Interest: 6, Principal: 4}}},
+		{"whole balance", nil, 225, DefaultWaterfall, []Allocation{
+			{Installment: 1, Fees: 5, Interest: 10, Principal: 100},
+			{Installment: 2, Interest: 10, Principal: 100},
+		}},
+	}
+	for _, tt := range tests {
+		insts := installments()
+		if tt.paid != nil {
+			tt.paid(insts)
+		}
+		got, err := Allocate(insts, tt.amount, tt.waterfall)
+		if err != nil {
+			t.Errorf("%s: Allocate failed: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+
+	for name, amount := range map[string]int64{"zero": 0, "more than owed": 226} {
+		if _, err := Allocate(installments(), amount, DefaultWaterfall); !errors.Is(err, ErrInvalidRepayment) {
+			t.Errorf("%s: expected ErrInvalidRepayment, got %v", name, err)
+		}
+	}
+}
+
+func TestDelinquency(t *testing.T) {
+	installments := []Installment{
+		{Number: 1, DueDate: date(2025, 1, 31), Principal: 100},
+		{Number: 2, DueDate: date(2025, 2, 28), Principal: 100},
+	}
+	tests := []struct {
+		name   string
+		paid   int64 // Principal paid on the first installment
+		asOf   time.Time
+		dpd    int
+		bucket DelinquencyBucket
+	}{
+		{"before due", 0, date(2025, 1, 30), 0, BucketCurrent},
+		{"on the due date", 0, date(2025, 1, 31), 0, BucketCurrent},
+		{"a day late", 0, date(2025, 2, 1), 1, Bucket1To30},
+		{"oldest installment counts", 0, date(2025, 3, 5), 33, Bucket31To60},
+		{"partly paid still late", 99, date(2025, 3, 5), 33, Bucket31To60},
+		{"paid installment skipped", 100, date(2025, 3, 5), 5, Bucket1To30},
+		{"90 days", 0, date(2025, 5, 1), 90, Bucket61To90},
+		{"over 90 days", 0, date(2025, 5, 2), 91, Bucket90Plus},
+	}
+	for _, tt := range tests {
+		insts := append([]Installment(nil), installments...)
+		insts[0].PrincipalPaid = tt.paid
+		dpd := DaysPastDue(insts, tt.asOf)
+		if dpd != tt.dpd || BucketFor(dpd) != tt.bucket {
+			t.Errorf("%s: got %d days, %s; want %d days, %s", tt.name, dpd, BucketFor(dpd), tt.dpd, tt.bucket)
+		}
+	}
+}
+
+func TestValidateProduct(t *testing.T) {
+	valid := func() Product {
+		return Product{Name: "Personal Loan", InterestRateBPS: 900, ScheduleType: Annuity, TermMonths: 36, LateFee: 2500, Waterfall: DefaultWaterfall}
+	}
+	p := valid()
+	if err := validateProduct(&p); err != nil {
+		t.Fatalf("Expected a valid product, got %v", err)
+	}
+
+	for name, change := range map[string]func(*Product){
+		"no name":            func(p *Product) { p.Name = "" },
+		"negative rate":      func(p *Product) { p.InterestRateBPS = -1 },
+		"unknown schedule":   func(p *Product) { p.ScheduleType = "BALLOON" },
+		"zero term":          func(p *Product) { p.TermMonths = 0 },
+		"term too long":      func(p *Product) { p.TermMonths = maxTermMonths + 1 },
+		"negative late fee":  func(p *Product) { p.LateFee = -1 },
+		"negative grace":     func(p *Product) { p.GraceDays = -1 },
+		"missing component":  func(p *Product) { p.Waterfall = Waterfall{Fees, Interest} },
+		"repeated component": func(p *Product) { p.Waterfall = Waterfall{Fees, Interest, Interest} },
+		"unknown component":  func(p *Product) { p.Waterfall = Waterfall{Fees, Interest, "PENALTY"} },
+	} {
+		p := valid()
+		change(&p)
+		if err := validateProduct(&p); !errors.Is(err, ErrInvalidProduct) {
+			t.Errorf("%s: expected ErrInvalidProduct, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,672 @@
+package loan
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nathanmocogni/core-banking-system/internal/ledger"
+)
+
+// Service disburses loans, allocates their repayments and tracks their delinquency.
+// Every movement of money is a ledger transaction against the loan's ASSET account.
+//
+// The loan account holds the outstanding principal only. Interest and fees are
+// recognised as income (GL 4200 and 4100) when a repayment pays them; until then
+// they are tracked on the installments.
+type Service struct {
+	db     *sql.DB
+	ledger *ledger.Service
+}
+
+func NewService(db *sql.DB, l *ledger.Service) *Service {
+	return &Service{db: db, ledger: l}
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+const productColumns = `id, name, interest_rate_bps, schedule_type, term_months, late_fee, grace_days, waterfall, created_at`
+
+func scanProduct(row rowScanner) (*Product, error) {
+	var p Product
+	err := row.Scan(&p.ID, &p.Name, &p.InterestRateBPS, &p.ScheduleType, &p.TermMonths, &p.LateFee, &p.GraceDays, &p.Waterfall, &p.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// CreateProduct stores a loan product. ScheduleType defaults to ANNUITY and
+// Waterfall to DefaultWaterfall.
+func (s *Service) CreateProduct(p Product) (*Product, error) {
+	if p.ScheduleType == "" {
+		p.ScheduleType = Annuity
+	}
+	if len(p.Waterfall) == 0 {
+		p.Waterfall = DefaultWaterfall
+	}
+	if err := validateProduct(&p); err != nil {
+		return nil, err
+	}
+	err := s.db.QueryRow(`
+		INSERT INTO loan_products (name, interest_rate_bps, schedule_type, term_months, late_fee, grace_days, waterfall)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`, p.Name, p.InterestRateBPS, p.ScheduleType, p.TermMonths, p.LateFee, p.GraceDays, p.Waterfall).Scan(&p.ID, &p.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create loan product: %w", err)
+	}
+	return &p, nil
+}
+
+// GetProduct returns a loan product.
+func (s *Service) GetProduct(id uuid.UUID) (*Product, error) {
+	p, err := scanProduct(s.db.QueryRow(`SELECT `+productColumns+` FROM loan_products WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load loan product: %w", err)
+	}
+	return p, nil
+}
+
+// ListProducts returns all loan products, oldest first.
+func (s *Service) ListProducts() ([]*Product, error) {
+	rows, err := s.db.Query(`SELECT ` + productColumns + ` FROM loan_products ORDER BY created_at, id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list loan products: %w", err)
+	}
+	defer rows.Close()
+
+	var products []*Product
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan loan product: %w", err)
+		}
+		products = append(products, p)
+	}
+	return products, rows.Err()
+}
+
+const loanColumns = `
+	id, product_id, customer_account_id, loan_account_id, currency, principal, interest_rate_bps, schedule_type,
+	term_months, late_fee, grace_days, waterfall, start_date, status, disbursement_transaction_id,
+	days_past_due, delinquency_bucket, created_at, updated_at`
+
+func scanLoan(row rowScanner) (*Loan, error) {
+	var l Loan
+	err := row.Scan(&l.ID, &l.ProductID, &l.CustomerAccountID, &l.LoanAccountID, &l.Currency, &l.Principal, &l.InterestRateBPS, &l.ScheduleType,
+		&l.TermMonths, &l.LateFee, &l.GraceDays, &l.Waterfall, &l.StartDate, &l.Status, &l.DisbursementTransactionID,
+		&l.DaysPastDue, &l.DelinquencyBucket, &l.CreatedAt, &l.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// DisbursementRequest asks for a loan of Principal, in the customer account's
+// currency, on a product's terms. TermMonths defaults to the product's term.
+type DisbursementRequest struct {
+	ProductID         uuid.UUID `json:"product_id"`
+	CustomerAccountID uuid.UUID `json:"customer_account_id"`
+	Principal         int64     `json:"principal"`
+	TermMonths        int       `json:"term_months"`
+}
+
+// Disburse opens a loan account for the customer, mapped to GL 1400 (Customer
+// Loans), and pays the principal from it into the customer account. The loan and
+// its schedule are stored as PENDING before the transaction posts; if posting
+// fails the loan is cancelled, its account closed and the posting error returned.
+func (s *Service) Disburse(req DisbursementRequest) (*Loan, error) {
+	product, err := s.GetProduct(req.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	customer, err := s.ledger.GetAccount(req.CustomerAccountID)
+	if err != nil {
+		return nil, err
+	}
+	if customer == nil {
+		return nil, fmt.Errorf("%w: %s", ledger.ErrAccountNotFound, req.CustomerAccountID)
+	}
+	if req.TermMonths == 0 {
+		req.TermMonths = product.TermMonths
+	}
+	start := ledger.DateOf(time.Now())
+	installments, err := BuildSchedule(req.Principal, product.InterestRateBPS, req.TermMonths, product.ScheduleType, start)
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. Open the Loan Account
+	var clientID *uuid.UUID
+	if customer.ClientID.Valid {
+		clientID = &customer.ClientID.UUID
+	}
+	account, err := s.ledger.CreateAccount("Loan: "+product.Name, ledger.Asset, customer.Currency, "LOAN", customer.OwnershipType.String, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ledger.SetAccountGL(account.ID, ledger.GLCodeCustomerLoans); err != nil {
+		s.closeLoanAccount(account.ID)
+		return nil, err
+	}
+
+	// 2. Record the Loan and its Schedule
+	l := &Loan{
+		ProductID:         product.ID,
+		CustomerAccountID: customer.ID,
+		LoanAccountID:     account.ID,
+		Currency:          customer.Currency,
+		Principal:         req.Principal,
+		InterestRateBPS:   product.InterestRateBPS,
+		ScheduleType:      product.ScheduleType,
+		TermMonths:        req.TermMonths,
+		LateFee:           product.LateFee,
+		GraceDays:         product.GraceDays,
+		Waterfall:         product.Waterfall,
+		StartDate:         start,
+		Status:            StatusPending,
+		DelinquencyBucket: BucketCurrent,
+	}
+	if err := s.createLoan(l, installments); err != nil {
+		s.closeLoanAccount(account.ID)
+		return nil, err
+	}
+
+	// 3. Pay Out the Principal
+	txn, postErr := s.ledger.PostTransaction("LOAN-"+l.ID.String()+"-DISBURSE", "Loan disbursement", []ledger.Entry{
+		{AccountID: l.LoanAccountID, Direction: ledger.Debit, Amount: l.Principal, Narrative: "Principal"},
+		{AccountID: l.CustomerAccountID, Direction: ledger.Credit, Amount: l.Principal, Narrative: "Loan disbursement"},
+	})
+	if postErr != nil {
+		if err := s.setStatus(l, StatusCancelled, nil); err != nil {
+			log.Printf("Loan %s: disbursement failed but cancelling it failed: %v", l.ID, err)
+		}
+		s.closeLoanAccount(account.ID)
+		return nil, postErr
+	}
+	if err := s.setStatus(l, StatusActive, &txn.ID); err != nil {
+		return nil, fmt.Errorf("disbursement %s posted but recording it failed: %w", txn.ID, err)
+	}
+	return l, nil
+}
+
+func (s *Service) createLoan(l *Loan, installments []Installment) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO loans (product_id, customer_account_id, loan_account_id, currency, principal, interest_rate_bps, schedule_type,
+		                   term_months, late_fee, grace_days, waterfall, start_date, status, delinquency_bucket)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12::DATE, $13, $14)
+		RETURNING id, created_at, updated_at
+	`, l.ProductID, l.CustomerAccountID, l.LoanAccountID, l.Currency, l.Principal, l.InterestRateBPS, l.ScheduleType,
+		l.TermMonths, l.LateFee, l.GraceDays, l.Waterfall, l.StartDate, l.Status, l.DelinquencyBucket).Scan(&l.ID, &l.CreatedAt, &l.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create loan: %w", err)
+	}
+	for _, inst := range installments {
+		_, err := tx.Exec(`
+			INSERT INTO loan_installments (loan_id, number, due_date, principal, interest)
+			VALUES ($1, $2, $3::DATE, $4, $5)
+		`, l.ID, inst.Number, inst.DueDate, inst.Principal, inst.Interest)
+		if err != nil {
+			return fmt.Errorf("failed to store installment %d: %w", inst.Number, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit loan: %w", err)
+	}
+	return nil
+}
+
+// setStatus settles a pending disbursement.
+func (s *Service) setStatus(l *Loan, status Status, transactionID *uuid.UUID) error {
+	err := s.db.QueryRow(`
+		UPDATE loans SET status = $1, disbursement_transaction_id = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $3
+		RETURNING updated_at
+	`, status, transactionID, l.ID).Scan(&l.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update loan: %w", err)
+	}
+	l.Status, l.DisbursementTransactionID = status, transactionID
+	return nil
+}
+
+// closeLoanAccount closes the account of a loan that was never disbursed. It has
+// no postings, so a failure only leaves an unused empty account behind.
+func (s *Service) closeLoanAccount(id uuid.UUID) {
+	_, err := s.ledger.SetAccountStatus(id, ledger.AccountStatusChange{Status: ledger.AccountClosed, Reason: "Loan not disbursed"})
+	if err != nil {
+		log.Printf("Loan account %s: closing it failed: %v", id, err)
+	}
+}
+
+// Get returns a loan.
+func (s *Service) Get(id uuid.UUID) (*Loan, error) {
+	l, err := scanLoan(s.db.QueryRow(`SELECT `+loanColumns+` FROM loans WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load loan: %w", err)
+	}
+	return l, nil
+}
+
+// List returns the loans disbursed to a customer account, oldest first.
+func (s *Service) List(customerAccountID uuid.UUID) ([]*Loan, error) {
+	rows, err := s.db.Query(`
+		SELECT `+loanColumns+` FROM loans
+		WHERE customer_account_id = $1
+		ORDER BY created_at, id
+	`, customerAccountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list loans: %w", err)
+	}
+	defer rows.Close()
+
+	var loans []*Loan
+	for rows.Next() {
+		l, err := scanLoan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan loan: %w", err)
+		}
+		loans = append(loans, l)
+	}
+	return loans, rows.Err()
+}
+
+// Schedule returns a loan's installments in order.
+func (s *Service) Schedule(id uuid.UUID) ([]Installment, error) {
+	if _, err := s.Get(id); err != nil {
+		return nil, err
+	}
+	return loadInstallments(s.db, id)
+}
+
+type querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func loadInstallments(q querier, loanID uuid.UUID) ([]Installment, error) {
+	rows, err := q.Query(`
+		SELECT number, due_date, principal, interest, fees, principal_paid, interest_paid, fees_paid, late_fee_charged, paid_at
+		FROM loan_installments
+		WHERE loan_id = $1
+		ORDER BY number
+	`, loanID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load installments: %w", err)
+	}
+	defer rows.Close()
+
+	var installments []Installment
+	for rows.Next() {
+		var i Installment
+		err := rows.Scan(&i.Number, &i.DueDate, &i.Principal, &i.Interest, &i.Fees, &i.PrincipalPaid, &i.InterestPaid, &i.FeesPaid, &i.LateFeeCharged, &i.PaidAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan installment: %w", err)
+		}
+		installments = append(installments, i)
+	}
+	return installments, rows.Err()
+}
+
+// saveInstallment writes back the fees and paid amounts of an installment of a locked loan.
+func saveInstallment(tx *sql.Tx, loanID uuid.UUID, i *Installment) error {
+	_, err := tx.Exec(`
+		UPDATE loan_installments
+		SET fees = $1, principal_paid = $2, interest_paid = $3, fees_paid = $4, late_fee_charged = $5, paid_at = $6
+		WHERE loan_id = $7 AND number = $8
+	`, i.Fees, i.PrincipalPaid, i.InterestPaid, i.FeesPaid, i.LateFeeCharged, i.PaidAt, loanID, i.Number)
+	if err != nil {
+		return fmt.Errorf("failed to update installment %d: %w", i.Number, err)
+	}
+	return nil
+}
+
+func lockLoan(tx *sql.Tx, id uuid.UUID) (*Loan, error) {
+	l, err := scanLoan(tx.QueryRow(`SELECT `+loanColumns+` FROM loans WHERE id = $1 FOR UPDATE`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load loan: %w", err)
+	}
+	return l, nil
+}
+
+// saveDelinquency writes back the status and delinquency of a locked loan.
+func saveDelinquency(tx *sql.Tx, l *Loan) error {
+	err := tx.QueryRow(`
+		UPDATE loans SET status = $1, days_past_due = $2, delinquency_bucket = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $4
+		RETURNING updated_at
+	`, l.Status, l.DaysPastDue, l.DelinquencyBucket, l.ID).Scan(&l.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update loan: %w", err)
+	}
+	return nil
+}
+
+// Repay pays amount towards a loan from an account in the loan's currency, the
+// customer account when fromAccountID is uuid.Nil. The amount is split by Allocate
+// using the loan's waterfall and posted as one transaction: principal to the loan
+// account, interest to Interest Income and fees to Fee Income. The repayment is
+// recorded as PROCESSING before it posts and as POSTED or FAILED after; a failed
+// posting is returned along with the recorded repayment. A loan whose installments
+// are all paid is PAID_OFF.
+func (s *Service) Repay(loanID, fromAccountID uuid.UUID, amount int64) (*Repayment, error) {
+	l, err := s.Get(loanID)
+	if err != nil {
+		return nil, err
+	}
+	if fromAccountID == uuid.Nil {
+		fromAccountID = l.CustomerAccountID
+	}
+	if fromAccountID == l.LoanAccountID {
+		return nil, fmt.Errorf("%w: cannot repay a loan from its own account", ErrInvalidRepayment)
+	}
+	from, err := s.ledger.GetAccount(fromAccountID)
+	if err != nil {
+		return nil, err
+	}
+	if from == nil {
+		return nil, fmt.Errorf("%w: %s", ledger.ErrAccountNotFound, fromAccountID)
+	}
+	if from.Currency != l.Currency {
+		return nil, fmt.Errorf("%w: loan is in %s, account %s is in %s", ledger.ErrCurrencyMismatch, l.Currency, from.ID, from.Currency)
+	}
+	interestIncome, err := s.ledger.GetOrCreateGLSystemAccount(ledger.GLCodeInterestIncome, l.Currency)
+	if err != nil {
+		return nil, err
+	}
+	feeIncome, err := s.ledger.GetOrCreateGLSystemAccount(ledger.GLCodeFeeIncome, l.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. Claim and Allocate the Repayment
+	r, err := s.claimRepayment(loanID, fromAccountID, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Post
+	entries := []ledger.Entry{{AccountID: r.FromAccountID, Direction: ledger.Debit, Amount: r.Amount, Narrative: "Loan repayment"}}
+	for _, part := range []struct {
+		account   uuid.UUID
+		amount    int64
+		narrative string
+	}{
+		{l.LoanAccountID, r.Principal, "Principal"},
+		{interestIncome, r.Interest, "Interest"},
+		{feeIncome, r.Fees, "Fees"},
+	} {
+		if part.amount > 0 {
+			entries = append(entries, ledger.Entry{AccountID: part.account, Direction: ledger.Credit, Amount: part.amount, Narrative: part.narrative})
+		}
+	}
+	txn, postErr := s.ledger.PostTransaction("LOAN-REPAY-"+r.ID.String(), "Loan repayment "+l.ID.String(), entries)
+
+	// 3. Settle the Repayment and Apply It to the Schedule
+	if err := s.settleRepayment(r, txn, postErr, time.Now()); err != nil {
+		if postErr == nil {
+			return r, fmt.Errorf("repayment %s posted but recording it failed: %w", txn.ID, err)
+		}
+		return r, err
+	}
+	return r, postErr
+}
+
+func (s *Service) claimRepayment(loanID, fromAccountID uuid.UUID, amount int64) (*Repayment, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	l, err := lockLoan(tx, loanID)
+	if err != nil {
+		return nil, err
+	}
+	if l.Status != StatusActive {
+		return nil, fmt.Errorf("%w: loan is %s", ErrInvalidStatus, l.Status)
+	}
+	var busy bool
+	err = tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM loan_repayments WHERE loan_id = $1 AND status = 'PROCESSING')`, loanID).Scan(&busy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check repayments: %w", err)
+	}
+	if busy {
+		return nil, ErrRepaymentInProgress
+	}
+	installments, err := loadInstallments(tx, loanID)
+	if err != nil {
+		return nil, err
+	}
+	allocations, err := Allocate(installments, amount, l.Waterfall)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Repayment{LoanID: loanID, FromAccountID: fromAccountID, Amount: amount, Allocations: allocations, Status: RepaymentProcessing}
+	for _, a := range allocations {
+		r.Fees += a.Fees
+		r.Interest += a.Interest
+		r.Principal += a.Principal
+	}
+	err = tx.QueryRow(`
+		INSERT INTO loan_repayments (loan_id, from_account_id, amount, fees, interest, principal, allocations, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`, r.LoanID, r.FromAccountID, r.Amount, r.Fees, r.Interest, r.Principal, r.Allocations, r.Status).Scan(&r.ID, &r.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record repayment: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit repayment: %w", err)
+	}
+	return r, nil
+}
+
+func (s *Service) settleRepayment(r *Repayment, txn *ledger.Transaction, postErr error, now time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	l, err := lockLoan(tx, r.LoanID)
+	if err != nil {
+		return err
+	}
+
+	r.Status = RepaymentPosted
+	if txn != nil {
+		r.TransactionID = &txn.ID
+	}
+	if postErr != nil {
+		r.Status, r.Error = RepaymentFailed, postErr.Error()
+	}
+	err = tx.QueryRow(`
+		UPDATE loan_repayments
+		SET status = $1, transaction_id = $2, error = NULLIF($3, ''), finished_at = CURRENT_TIMESTAMP
+		WHERE id = $4
+		RETURNING finished_at
+	`, r.Status, r.TransactionID, r.Error, r.ID).Scan(&r.FinishedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record repayment: %w", err)
+	}
+
+	if r.Status == RepaymentPosted {
+		installments, err := loadInstallments(tx, l.ID)
+		if err != nil {
+			return err
+		}
+		// The claim kept other repayments out, and fees only grow, so the allocations still fit
+		paidOff := true
+		for i := range installments {
+			inst := &installments[i]
+			for _, a := range r.Allocations {
+				if a.Installment != inst.Number {
+					continue
+				}
+				inst.FeesPaid += a.Fees
+				inst.InterestPaid += a.Interest
+				inst.PrincipalPaid += a.Principal
+				if inst.Outstanding() == 0 {
+					inst.PaidAt = &now
+				}
+				if err := saveInstallment(tx, l.ID, inst); err != nil {
+					return err
+				}
+			}
+			paidOff = paidOff && inst.Outstanding() == 0
+		}
+		if paidOff {
+			l.Status = StatusPaidOff
+		}
+		l.DaysPastDue = DaysPastDue(installments, now)
+		l.DelinquencyBucket = BucketFor(l.DaysPastDue)
+		if err := saveDelinquency(tx, l); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit repayment: %w", err)
+	}
+	return nil
+}
+
+// ListRepayments returns a loan's repayments, newest first.
+func (s *Service) ListRepayments(loanID uuid.UUID) ([]*Repayment, error) {
+	rows, err := s.db.Query(`
+		SELECT id, loan_id, from_account_id, amount, fees, interest, principal, allocations, status, transaction_id,
+		       COALESCE(error, ''), created_at, finished_at
+		FROM loan_repayments
+		WHERE loan_id = $1
+		ORDER BY created_at DESC, id
+	`, loanID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repayments: %w", err)
+	}
+	defer rows.Close()
+
+	var repayments []*Repayment
+	for rows.Next() {
+		var r Repayment
+		err := rows.Scan(&r.ID, &r.LoanID, &r.FromAccountID, &r.Amount, &r.Fees, &r.Interest, &r.Principal, &r.Allocations, &r.Status, &r.TransactionID,
+			&r.Error, &r.CreatedAt, &r.FinishedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan repayment: %w", err)
+		}
+		repayments = append(repayments, &r)
+	}
+	return repayments, rows.Err()
+}
+
+// UpdateDelinquency brings every active loan's days past due and delinquency
+// bucket up to asOf, and charges the late fee on each installment that is more
+// than the loan's grace days overdue, once per installment. The fee is added to
+// the installment and collected by the next repayments through the waterfall.
+// A loan that cannot be updated is logged and skipped; the run then returns an
+// error after updating the rest.
+func (s *Service) UpdateDelinquency(asOf time.Time) (*DelinquencyResult, error) {
+	rows, err := s.db.Query(`SELECT id FROM loans WHERE status = 'ACTIVE' ORDER BY created_at, id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find active loans: %w", err)
+	}
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan loan: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to find active loans: %w", err)
+	}
+
+	result := &DelinquencyResult{}
+	failed := 0
+	for _, id := range ids {
+		dpd, charged, err := s.updateDelinquency(id, asOf)
+		if err != nil {
+			log.Printf("Loan %s: %v", id, err)
+			failed++
+			continue
+		}
+		result.Loans++
+		result.FeesCharged += charged
+		if dpd > 0 {
+			result.Delinquent++
+		}
+	}
+	if failed > 0 {
+		return result, fmt.Errorf("failed to update delinquency of %d loans", failed)
+	}
+	return result, nil
+}
+
+func (s *Service) updateDelinquency(id uuid.UUID, asOf time.Time) (dpd, charged int, err error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	l, err := lockLoan(tx, id)
+	if err != nil {
+		return 0, 0, err
+	}
+	if l.Status != StatusActive {
+		return 0, 0, nil // Paid off since it was selected
+	}
+	installments, err := loadInstallments(tx, id)
+	if err != nil {
+		return 0, 0, err
+	}
+	asOf = ledger.DateOf(asOf)
+	for i := range installments {
+		inst := &installments[i]
+		if l.LateFee == 0 || inst.LateFeeCharged || inst.Outstanding() == 0 {
+			continue
+		}
+		if !asOf.After(ledger.DateOf(inst.DueDate).AddDate(0, 0, l.GraceDays)) {
+			continue
+		}
+		inst.Fees += l.LateFee
+		inst.LateFeeCharged = true
+		if err := saveInstallment(tx, id, inst); err != nil {
+			return 0, 0, err
+		}
+		charged++
+	}
+
+	l.DaysPastDue = DaysPastDue(installments, asOf)
+	l.DelinquencyBucket = BucketFor(l.DaysPastDue)
+	if err := saveDelinquency(tx, l); err != nil {
+		return 0, 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit loan: %w", err)
+	}
+	return l.DaysPastDue, charged, nil
+}
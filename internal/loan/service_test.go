@@ -0,0 +1,155 @@
+package loan
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	"github.com/nathanmocogni/core-banking-system/internal/ledger"
+)
+
+func connectDB(t *testing.T) *sql.DB {
+	host := os.Getenv("DB_HOST")
+	if host == "" {
+		host = "localhost"
+	}
+	port := os.Getenv("DB_PORT")
+	if port == "" {
+		port = "5433"
+	}
+	user := os.Getenv("DB_USER")
+	if user == "" {
+		user = "user"
+	}
+	password := os.Getenv("DB_PASSWORD")
+	if password == "" {
+		password = "password"
+	}
+	dbname := os.Getenv("DB_NAME")
+	if dbname == "" {
+		dbname = "ledger"
+	}
+
+	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable", host, port, user, password, dbname)
+	db, err := sql.Open("postgres", connStr)
+	if err != nil {
+		t.Skipf("Skipping test: could not connect to database: %v", err)
+	}
+	if err := db.Ping(); err != nil {
+		t.Skipf("Skipping test: database not reachable: %v", err)
+	}
+	return db
+}
+
+func TestLoanLifecycle(t *testing.T) {
+	db := connectDB(t)
+	defer db.Close()
+
+	ledgerService := ledger.NewService(db)
+	service := NewService(db, ledgerService)
+
+	customer, err := ledgerService.CreateAccount("Loan Customer", ledger.Liability, "USD", "CASH", "INDIVIDUAL", nil)
+	if err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+	cash, err := ledgerService.GetOrCreateSystemAccount("Cash In", ledger.Asset, "USD")
+	if err != nil {
+		t.Fatalf("Failed to get cash account: %v", err)
+	}
+	_, err = ledgerService.PostTransaction(fmt.Sprintf("LOAN-FUND-%d", time.Now().UnixNano()), "Funding", []ledger.Entry{
+		{AccountID: cash, Direction: ledger.Debit, Amount: 200},
+		{AccountID: customer.ID, Direction: ledger.Credit, Amount: 200},
+	})
+	if err != nil {
+		t.Fatalf("Failed to fund account: %v", err)
+	}
+
+	product, err := service.CreateProduct(Product{Name: "Test Loan", InterestRateBPS: 1200, ScheduleType: StraightLine, TermMonths: 3, LateFee: 50})
+	if err != nil {
+		t.Fatalf("CreateProduct failed: %v", err)
+	}
+	l, err := service.Disburse(DisbursementRequest{ProductID: product.ID, CustomerAccountID: customer.ID, Principal: 3000})
+	if err != nil {
+		t.Fatalf("Disburse failed: %v", err)
+	}
+	if l.Status != StatusActive || l.DisbursementTransactionID == nil {
+		t.Fatalf("Expected an active, disbursed loan, got %+v", l)
+	}
+	balance := func(id uuid.UUID, want int64) {
+		t.Helper()
+		acc, err := ledgerService.GetAccount(id)
+		if err != nil {
+			t.Fatalf("GetAccount failed: %v", err)
+		}
+		if acc.Balance != want {
+			t.Errorf("Expected %s balance %d, got %d", acc.Name, want, acc.Balance)
+		}
+	}
+	balance(l.LoanAccountID, 3000)
+	balance(customer.ID, -3200)
+
+	// The first installment is ten days late: the late fee is charged
+	schedule, err := service.Schedule(l.ID)
+	if err != nil {
+		t.Fatalf("Schedule failed: %v", err)
+	}
+	if len(schedule) != 3 || schedule[0].Principal != 1000 || schedule[0].Interest != 30 {
+		t.Fatalf("Unexpected schedule %+v", schedule)
+	}
+	if _, err := service.UpdateDelinquency(schedule[0].DueDate.AddDate(0, 0, 10)); err != nil {
+		t.Fatalf("UpdateDelinquency failed: %v", err)
+	}
+	got, err := service.Get(l.ID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.DaysPastDue != 10 || got.DelinquencyBucket != Bucket1To30 {
+		t.Errorf("Expected 10 days past due in %s, got %d in %s", Bucket1To30, got.DaysPastDue, got.DelinquencyBucket)
+	}
+
+	// Fee, interest and principal of the first installment
+	r, err := service.Repay(l.ID, uuid.Nil, 1080)
+	if err != nil {
+		t.Fatalf("Repay failed: %v", err)
+	}
+	if r.Status != RepaymentPosted || r.Fees != 50 || r.Interest != 30 || r.Principal != 1000 {
+		t.Errorf("Unexpected repayment %+v", r)
+	}
+	balance(l.LoanAccountID, 2000)
+	got, err = service.Get(l.ID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.DaysPastDue != 0 || got.DelinquencyBucket != BucketCurrent {
+		t.Errorf("Expected the loan to be current again, got %d days in %s", got.DaysPastDue, got.DelinquencyBucket)
+	}
+
+	// The rest pays the loan off
+	if _, err := service.Repay(l.ID, uuid.Nil, 2030); err != nil {
+		t.Fatalf("Repay failed: %v", err)
+	}
+	balance(l.LoanAccountID, 0)
+	balance(customer.ID, -90)
+	got, err = service.Get(l.ID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Status != StatusPaidOff {
+		t.Errorf("Expected a paid off loan, got %s", got.Status)
+	}
+	if _, err := service.Repay(l.ID, uuid.Nil, 1); !errors.Is(err, ErrInvalidStatus) {
+		t.Errorf("Expected ErrInvalidStatus repaying a paid off loan, got %v", err)
+	}
+	repayments, err := service.ListRepayments(l.ID)
+	if err != nil {
+		t.Fatalf("ListRepayments failed: %v", err)
+	}
+	if len(repayments) != 2 {
+		t.Errorf("Expected 2 repayments, got %d", len(repayments))
+	}
+}
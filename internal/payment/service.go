@@ -20,82 +20,99 @@ func NewService(l *ledger.Service) *Service {
 // and crediting the user's account.
 // It performs the following steps:
 // 1. Simulates an external gateway call.
-// 2. Gets or creates the settlement account (Asset).
-// 3. Posts a transaction debiting the settlement account and crediting the user account.
+// 2. Posts the DEPOSIT posting template (by default debiting the settlement account
+// and crediting the user account).
 func (s *Service) Deposit(accountID uuid.UUID, amount int64, currency string) (*ledger.Transaction, error) {
 	if amount <= 0 {
 		return nil, fmt.Errorf("amount must be positive")
 	}
 
+	if _, err := s.resolveCurrency(accountID, currency); err != nil {
+		return nil, err
+	}
+
 	// 1. Simulate External Gateway Call
 	if err := s.mockExternalGateway(); err != nil {
 		return nil, fmt.Errorf("external gateway failed: %w", err)
 	}
 
-	// 2. Get/Create Settlement Account (Asset)
-	// This represents the money held by the Payment Processor on our behalf.
-	settlementID, err := s.ledger.GetOrCreateSystemAccount("Payment Gateway Settlement", ledger.Asset)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get settlement account: %w", err)
-	}
-
-	// 3. Post Transaction
-	// Debit: Settlement Account (Asset increases)
-	// Credit: User Account (Liability increases)
-	entries := []ledger.Entry{
-		{AccountID: settlementID, Direction: ledger.Debit, Amount: amount},
-		{AccountID: accountID, Direction: ledger.Credit, Amount: amount},
-	}
-
-	ref := fmt.Sprintf("DEP-%s", uuid.New().String())
-	return s.ledger.PostTransaction(ref, "External Deposit", entries)
+	// 2. Post Transaction through the DEPOSIT template
+	// Default: Debit Payment Gateway Settlement (GL 1200, the money held by the
+	// payment processor on our behalf), Credit the user account.
+	return s.ledger.PostEvent(ledger.PostingEvent{
+		Type:      ledger.EventDeposit,
+		AccountID: accountID,
+		Amount:    amount,
+		Reference: fmt.Sprintf("DEP-%s", uuid.New().String()),
+	})
 }
 
+// withdrawalAuthTTL bounds how long a withdrawal may wait on the gateway before
+// its authorization expires and the held funds are released.
+const withdrawalAuthTTL = 15 * time.Minute
+
 // Withdraw simulates sending money to an external bank account.
 // It performs the following steps:
-// 1. Gets or creates the settlement account.
-// 2. Posts a transaction debiting the user account and crediting the settlement account.
-// 3. Simulates an external gateway call.
+// 1. Authorizes the WITHDRAWAL posting template (by default debiting the user account
+// and crediting the settlement account) as a pending transaction, holding the funds.
+// 2. Simulates an external gateway call.
+// 3. Posts the pending transaction once the gateway confirms, or voids it.
 func (s *Service) Withdraw(accountID uuid.UUID, amount int64, currency string) (*ledger.Transaction, error) {
 	if amount <= 0 {
 		return nil, fmt.Errorf("amount must be positive")
 	}
 
-	// 1. Check Balance (Optional, PostTransaction will fail if we enforce overdraft rules,
-	// but currently we allow negative balances for Liabilities.
-	// In a real app, we'd check available funds here).
-
-	// 2. Get/Create Settlement Account
-	settlementID, err := s.ledger.GetOrCreateSystemAccount("Payment Gateway Settlement", ledger.Asset)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get settlement account: %w", err)
-	}
-
-	// 3. Post Transaction (Hold Funds / Execute)
-	// Debit: User Account (Liability decreases)
-	// Credit: Settlement Account (Asset decreases)
-	entries := []ledger.Entry{
-		{AccountID: accountID, Direction: ledger.Debit, Amount: amount},
-		{AccountID: settlementID, Direction: ledger.Credit, Amount: amount},
+	if _, err := s.resolveCurrency(accountID, currency); err != nil {
+		return nil, err
 	}
 
-	ref := fmt.Sprintf("WD-%s", uuid.New().String())
-	tx, err := s.ledger.PostTransaction(ref, "External Withdrawal", entries)
+	// 1. Authorize (Hold Funds) through the WITHDRAWAL template
+	// Default: Debit the user account, Credit Payment Gateway Settlement (GL 1200).
+	// Authorize enforces available balance (net of holds) and the overdraft limit,
+	// returning ledger.ErrInsufficientFunds before anything is sent to the gateway.
+	pending, err := s.ledger.AuthorizeEvent(ledger.PostingEvent{
+		Type:      ledger.EventWithdrawal,
+		AccountID: accountID,
+		Amount:    amount,
+		Reference: fmt.Sprintf("WD-%s", uuid.New().String()),
+	}, time.Now().Add(withdrawalAuthTTL))
 	if err != nil {
 		return nil, fmt.Errorf("transaction failed: %w", err)
 	}
 
-	// 4. Simulate External Gateway Call
-	// If this fails, we should technically reverse the transaction (Saga pattern),
-	// but for this prototype we assume success or panic.
+	// 2. Simulate External Gateway Call
+	// If this fails, the authorization is voided and the hold released; nothing was posted.
 	if err := s.mockExternalGateway(); err != nil {
-		// TODO: Reverse transaction
+		if _, voidErr := s.ledger.VoidPending(pending.ID, "external gateway failed"); voidErr != nil {
+			return nil, fmt.Errorf("external gateway failed: %v; void of %s also failed: %w", err, pending.ID, voidErr)
+		}
 		return nil, fmt.Errorf("external gateway failed: %w", err)
 	}
 
+	// 3. Post the Confirmed Withdrawal
+	tx, err := s.ledger.PostPending(pending.ID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("posting confirmed withdrawal %s failed: %w", pending.ID, err)
+	}
 	return tx, nil
 }
 
+// resolveCurrency checks a requested currency against the account's currency.
+// An empty currency means "the account's currency".
+func (s *Service) resolveCurrency(accountID uuid.UUID, currency string) (string, error) {
+	acc, err := s.ledger.GetAccount(accountID)
+	if err != nil {
+		return "", err
+	}
+	if acc == nil {
+		return "", fmt.Errorf("%w: %s", ledger.ErrAccountNotFound, accountID)
+	}
+	if currency != "" && currency != acc.Currency {
+		return "", fmt.Errorf("%w: requested %s, account %s is in %s", ledger.ErrCurrencyMismatch, currency, accountID, acc.Currency)
+	}
+	return acc.Currency, nil
+}
+
 func (s *Service) mockExternalGateway() error {
 	// Simulate network latency
 	time.Sleep(200 * time.Millisecond)
@@ -103,6 +120,8 @@ func (s *Service) mockExternalGateway() error {
 }
 
 // Transfer moves funds between two internal accounts.
+// amount and currency are in the source account's currency. When the destination
+// holds a different currency the ledger converts through FX legs at the latest rate.
 func (s *Service) Transfer(fromAccountID, toAccountID uuid.UUID, amount int64, currency string) (*ledger.Transaction, error) {
 	if amount <= 0 {
 		return nil, fmt.Errorf("amount must be positive")
@@ -110,6 +129,9 @@ func (s *Service) Transfer(fromAccountID, toAccountID uuid.UUID, amount int64, c
 	if fromAccountID == toAccountID {
 		return nil, fmt.Errorf("cannot transfer to same account")
 	}
+	if _, err := s.resolveCurrency(fromAccountID, currency); err != nil {
+		return nil, err
+	}
 
 	// Debit FromAccount, Credit ToAccount
 	// Note: For Liability accounts (Customer accounts), Debit decreases balance (money leaving),
@@ -121,11 +143,6 @@ func (s *Service) Transfer(fromAccountID, toAccountID uuid.UUID, amount int64, c
 	// FromAccount (Liability) -> Debit (Decrease)
 	// ToAccount (Liability) -> Credit (Increase)
 
-	entries := []ledger.Entry{
-		{AccountID: fromAccountID, Direction: ledger.Debit, Amount: amount},
-		{AccountID: toAccountID, Direction: ledger.Credit, Amount: amount},
-	}
-
 	ref := fmt.Sprintf("TRF-%s", uuid.New().String())
-	return s.ledger.PostTransaction(ref, fmt.Sprintf("Transfer from %s to %s", fromAccountID, toAccountID), entries)
+	return s.ledger.PostTransfer(ref, fmt.Sprintf("Transfer from %s to %s", fromAccountID, toAccountID), fromAccountID, toAccountID, amount)
 }
@@ -2,6 +2,7 @@ package payment
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"testing"
@@ -47,7 +48,7 @@ func TestDeposit(t *testing.T) {
 	db := connectDB(t)
 	defer db.Close()
 
-	ledgerService := ledger.NewService(db, nil)
+	ledgerService := ledger.NewService(db)
 	paymentService := NewService(ledgerService)
 
 	// Create a user account
@@ -88,7 +89,7 @@ func TestWithdraw(t *testing.T) {
 	db := connectDB(t)
 	defer db.Close()
 
-	ledgerService := ledger.NewService(db, nil)
+	ledgerService := ledger.NewService(db)
 	paymentService := NewService(ledgerService)
 
 	// Create a user account
@@ -130,7 +131,7 @@ func TestTransfer(t *testing.T) {
 	db := connectDB(t)
 	defer db.Close()
 
-	ledgerService := ledger.NewService(db, nil)
+	ledgerService := ledger.NewService(db)
 	paymentService := NewService(ledgerService)
 
 	// Create two accounts
@@ -179,7 +180,7 @@ func TestTransfer_SameAccount(t *testing.T) {
 	db := connectDB(t)
 	defer db.Close()
 
-	ledgerService := ledger.NewService(db, nil)
+	ledgerService := ledger.NewService(db)
 	paymentService := NewService(ledgerService)
 
 	acc1, err := ledgerService.CreateAccount("User Same", ledger.Liability, "USD", "CASH", "INDIVIDUAL", nil)
@@ -192,3 +193,47 @@ func TestTransfer_SameAccount(t *testing.T) {
 		t.Error("Expected error when transferring to same account")
 	}
 }
+
+func TestTransfer_CrossCurrency(t *testing.T) {
+	db := connectDB(t)
+	defer db.Close()
+
+	ledgerService := ledger.NewService(db)
+	paymentService := NewService(ledgerService)
+
+	usdAcc, err := ledgerService.CreateAccount("User USD", ledger.Liability, "USD", "CASH", "INDIVIDUAL", nil)
+	if err != nil {
+		t.Fatalf("Failed to create usd account: %v", err)
+	}
+	eurAcc, err := ledgerService.CreateAccount("User EUR", ledger.Liability, "EUR", "CASH", "INDIVIDUAL", nil)
+	if err != nil {
+		t.Fatalf("Failed to create eur account: %v", err)
+	}
+	if _, err := ledgerService.SetFXRate("USD", "EUR", "0.5"); err != nil {
+		t.Fatalf("Failed to set FX rate: %v", err)
+	}
+
+	if _, err := paymentService.Deposit(usdAcc.ID, 1000, "USD"); err != nil {
+		t.Fatalf("Failed to deposit: %v", err)
+	}
+	if _, err := paymentService.Deposit(usdAcc.ID, 1000, "EUR"); !errors.Is(err, ledger.ErrCurrencyMismatch) {
+		t.Errorf("Expected ErrCurrencyMismatch for EUR deposit into USD account, got %v", err)
+	}
+
+	tx, err := paymentService.Transfer(usdAcc.ID, eurAcc.ID, 400, "USD")
+	if err != nil {
+		t.Fatalf("Failed to transfer: %v", err)
+	}
+	if len(tx.Entries) != 4 {
+		t.Errorf("Expected 4 entries (two FX legs), got %d", len(tx.Entries))
+	}
+
+	uUSD, _ := ledgerService.GetAccount(usdAcc.ID)
+	uEUR, _ := ledgerService.GetAccount(eurAcc.ID)
+	if uUSD.Balance != -600 {
+		t.Errorf("Expected USD balance -600, got %d", uUSD.Balance)
+	}
+	if uEUR.Balance != -200 {
+		t.Errorf("Expected EUR balance -200, got %d", uEUR.Balance)
+	}
+}
@@ -0,0 +1,97 @@
+package standingorder
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Sentinel errors returned by the standing order service.
+var (
+	ErrNotFound      = errors.New("standing order not found")
+	ErrInvalidOrder  = errors.New("invalid standing order")
+	ErrInvalidStatus = errors.New("invalid standing order status change")
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+)
+
+type Status string
+
+const (
+	StatusActive    Status = "ACTIVE"
+	StatusPaused    Status = "PAUSED"
+	StatusCompleted Status = "COMPLETED" // End date or maximum number of executions reached
+	StatusCancelled Status = "CANCELLED"
+)
+
+// RetryPolicy says how often, and how far apart, an occurrence that failed for
+// insufficient funds is attempted again before it is recorded as FAILED and skipped.
+type RetryPolicy struct {
+	MaxRetries    int           `json:"max_retries"`
+	RetryInterval time.Duration `json:"retry_interval"`
+}
+
+// DefaultRetryPolicy retries a payment three times, four hours apart.
+var DefaultRetryPolicy = RetryPolicy{MaxRetries: 3, RetryInterval: 4 * time.Hour}
+
+// StandingOrder is an instruction to transfer a fixed amount between two internal
+// accounts on a schedule. Amount is in minor units of the source account's currency.
+type StandingOrder struct {
+	ID            uuid.UUID   `json:"id"`
+	FromAccountID uuid.UUID   `json:"from_account_id"`
+	ToAccountID   uuid.UUID   `json:"to_account_id"`
+	Amount        int64       `json:"amount"`
+	Currency      string      `json:"currency"`
+	Description   string      `json:"description,omitempty"`
+	Frequency     Frequency   `json:"frequency"`
+	Interval      int         `json:"interval"`               // Every Interval days, weeks or months
+	DayOfMonth    int         `json:"day_of_month,omitempty"` // MONTHLY: defaults to the start date's day; 29-31 fall back to the month's last day
+	StartDate     time.Time   `json:"start_date"`
+	EndDate       *time.Time  `json:"end_date,omitempty"`       // Last date an occurrence may fall on
+	MaxExecutions *int        `json:"max_executions,omitempty"` // Successful transfers after which the order completes
+	Retry         RetryPolicy `json:"retry"`
+
+	ExecutionCount int        `json:"execution_count"`
+	NextRunDate    *time.Time `json:"next_run_date,omitempty"` // Unset once the order is completed
+	RetryAt        *time.Time `json:"retry_at,omitempty"`      // Set while a failed occurrence waits for its retry
+	FailedAttempts int        `json:"failed_attempts"`         // Of the current occurrence
+	Status         Status     `json:"status"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+type ExecutionStatus string
+
+const (
+	ExecutionProcessing     ExecutionStatus = "PROCESSING" // Transfer in progress
+	ExecutionSucceeded      ExecutionStatus = "SUCCEEDED"
+	ExecutionRetryScheduled ExecutionStatus = "RETRY_SCHEDULED" // Insufficient funds; will be tried again
+	ExecutionFailed         ExecutionStatus = "FAILED"          // Occurrence skipped
+)
+
+// Execution records one attempt to pay an occurrence of a standing order.
+type Execution struct {
+	ID              uuid.UUID       `json:"id"`
+	StandingOrderID uuid.UUID       `json:"standing_order_id"`
+	ScheduledDate   time.Time       `json:"scheduled_date"`
+	Attempt         int             `json:"attempt"`
+	Status          ExecutionStatus `json:"status"`
+	TransactionID   *uuid.UUID      `json:"transaction_id,omitempty"`
+	Error           string          `json:"error,omitempty"`
+	StartedAt       time.Time       `json:"started_at"`
+	FinishedAt      *time.Time      `json:"finished_at,omitempty"`
+}
+
+// RunResult summarises one ExecuteDue run.
+type RunResult struct {
+	Succeeded int `json:"succeeded"`
+	Retrying  int `json:"retrying"`
+	Failed    int `json:"failed"`
+}
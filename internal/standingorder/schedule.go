@@ -0,0 +1,86 @@
+package standingorder
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nathanmocogni/core-banking-system/internal/ledger"
+)
+
+// validate checks an order's amount, accounts and schedule. Defaults must already be applied.
+func validate(o *StandingOrder) error {
+	switch {
+	case o.Amount <= 0:
+		return fmt.Errorf("%w: amount must be positive", ErrInvalidOrder)
+	case o.FromAccountID == o.ToAccountID:
+		return fmt.Errorf("%w: cannot transfer to the same account", ErrInvalidOrder)
+	case o.Frequency != Daily && o.Frequency != Weekly && o.Frequency != Monthly:
+		return fmt.Errorf("%w: frequency must be DAILY, WEEKLY or MONTHLY", ErrInvalidOrder)
+	case o.Interval < 1:
+		return fmt.Errorf("%w: interval must be at least 1", ErrInvalidOrder)
+	case o.DayOfMonth != 0 && o.Frequency != Monthly:
+		return fmt.Errorf("%w: day_of_month only applies to MONTHLY orders", ErrInvalidOrder)
+	case o.DayOfMonth < 0 || o.DayOfMonth > 31:
+		return fmt.Errorf("%w: day_of_month must be between 1 and 31", ErrInvalidOrder)
+	case o.EndDate != nil && o.EndDate.Before(o.StartDate):
+		return fmt.Errorf("%w: end_date is before start_date", ErrInvalidOrder)
+	case o.MaxExecutions != nil && *o.MaxExecutions < 1:
+		return fmt.Errorf("%w: max_executions must be at least 1", ErrInvalidOrder)
+	case o.Retry.MaxRetries < 0 || (o.Retry.MaxRetries > 0 && o.Retry.RetryInterval < time.Minute):
+		return fmt.Errorf("%w: retries need a retry interval of at least a minute", ErrInvalidOrder)
+	}
+	return nil
+}
+
+// occurrence returns the nth scheduled date of an order, counting from 0 at the
+// start date. Monthly occurrences fall on DayOfMonth, or the month's last day if
+// it is shorter, without drifting: a 31st order pays on Jan 31, Feb 28, Mar 31.
+func occurrence(o *StandingOrder, n int) time.Time {
+	switch o.Frequency {
+	case Daily:
+		return o.StartDate.AddDate(0, 0, n*o.Interval)
+	case Weekly:
+		return o.StartDate.AddDate(0, 0, 7*n*o.Interval)
+	default:
+		first := time.Date(o.StartDate.Year(), o.StartDate.Month()+time.Month(n*o.Interval), 1, 0, 0, 0, 0, time.UTC)
+		day := o.DayOfMonth
+		if last := first.AddDate(0, 1, -1).Day(); day > last {
+			day = last
+		}
+		return first.AddDate(0, 0, day-1)
+	}
+}
+
+// NextOccurrence returns the first scheduled date of an order on or after from.
+// It returns false when the schedule ends before then.
+func NextOccurrence(o *StandingOrder, from time.Time) (time.Time, bool) {
+	from = ledger.DateOf(from)
+	if from.Before(o.StartDate) {
+		from = o.StartDate
+	}
+
+	// Start from an estimate at or before the answer and step forward
+	var n int
+	switch o.Frequency {
+	case Daily:
+		n = int(from.Sub(o.StartDate).Hours()/24) / o.Interval
+	case Weekly:
+		n = int(from.Sub(o.StartDate).Hours()/24) / (7 * o.Interval)
+	default:
+		months := (from.Year()-o.StartDate.Year())*12 + int(from.Month()-o.StartDate.Month())
+		n = months/o.Interval - 1
+		if n < 0 {
+			n = 0
+		}
+	}
+	next := occurrence(o, n)
+	for next.Before(from) {
+		n++
+		next = occurrence(o, n)
+	}
+
+	if o.EndDate != nil && next.After(*o.EndDate) {
+		return time.Time{}, false
+	}
+	return next, true
+}
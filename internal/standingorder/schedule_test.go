@@ -0,0 +1,71 @@
+package standingorder
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestNextOccurrence(t *testing.T) {
+	end := date(2025, 6, 30)
+	tests := []struct {
+		name  string
+		order StandingOrder
+		from  time.Time
+		want  time.Time
+		ok    bool
+	}{
+		{"daily before start", StandingOrder{Frequency: Daily, Interval: 1, StartDate: date(2025, 1, 10)}, date(2025, 1, 1), date(2025, 1, 10), true},
+		{"every 3 days", StandingOrder{Frequency: Daily, Interval: 3, StartDate: date(2025, 1, 1)}, date(2025, 1, 5), date(2025, 1, 7), true},
+		{"weekly on the day", StandingOrder{Frequency: Weekly, Interval: 1, StartDate: date(2025, 1, 6)}, date(2025, 1, 20), date(2025, 1, 20), true},
+		{"fortnightly", StandingOrder{Frequency: Weekly, Interval: 2, StartDate: date(2025, 1, 6)}, date(2025, 1, 14), date(2025, 1, 20), true},
+		{"monthly 31st in February", StandingOrder{Frequency: Monthly, Interval: 1, DayOfMonth: 31, StartDate: date(2025, 1, 31)}, date(2025, 2, 1), date(2025, 2, 28), true},
+		{"monthly 31st does not drift", StandingOrder{Frequency: Monthly, Interval: 1, DayOfMonth: 31, StartDate: date(2025, 1, 31)}, date(2025, 3, 1), date(2025, 3, 31), true},
+		{"monthly day before start day", StandingOrder{Frequency: Monthly, Interval: 1, DayOfMonth: 5, StartDate: date(2025, 1, 20)}, date(2025, 1, 20), date(2025, 2, 5), true},
+		{"quarterly across years", StandingOrder{Frequency: Monthly, Interval: 3, DayOfMonth: 15, StartDate: date(2024, 11, 15)}, date(2025, 1, 1), date(2025, 2, 15), true},
+		{"after end date", StandingOrder{Frequency: Monthly, Interval: 1, DayOfMonth: 15, StartDate: date(2025, 1, 15), EndDate: &end}, date(2025, 7, 1), time.Time{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := NextOccurrence(&tt.order, tt.from)
+		if ok != tt.ok || !got.Equal(tt.want) {
+			t.Errorf("%s: NextOccurrence = %s, %v; want %s, %v", tt.name, got.Format("2006-01-02"), ok, tt.want.Format("2006-01-02"), tt.ok)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := func() StandingOrder {
+		return StandingOrder{
+			FromAccountID: uuid.New(), ToAccountID: uuid.New(), Amount: 100,
+			Frequency: Monthly, Interval: 1, DayOfMonth: 1, StartDate: date(2025, 1, 1), Retry: DefaultRetryPolicy,
+		}
+	}
+	o := valid()
+	if err := validate(&o); err != nil {
+		t.Fatalf("Expected a valid order, got %v", err)
+	}
+
+	zero := 0
+	for name, change := range map[string]func(*StandingOrder){
+		"zero amount":         func(o *StandingOrder) { o.Amount = 0 },
+		"same account":        func(o *StandingOrder) { o.ToAccountID = o.FromAccountID },
+		"unknown frequency":   func(o *StandingOrder) { o.Frequency = "YEARLY" },
+		"day on weekly order": func(o *StandingOrder) { o.Frequency = Weekly },
+		"day 32":              func(o *StandingOrder) { o.DayOfMonth = 32 },
+		"end before start":    func(o *StandingOrder) { end := date(2024, 12, 31); o.EndDate = &end },
+		"zero max executions": func(o *StandingOrder) { o.MaxExecutions = &zero },
+		"retry without delay": func(o *StandingOrder) { o.Retry.RetryInterval = 0 },
+	} {
+		o := valid()
+		change(&o)
+		if err := validate(&o); !errors.Is(err, ErrInvalidOrder) {
+			t.Errorf("%s: expected ErrInvalidOrder, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,505 @@
+package standingorder
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nathanmocogni/core-banking-system/internal/ledger"
+	"github.com/nathanmocogni/core-banking-system/internal/payment"
+)
+
+// Service stores standing orders and executes the due ones through payment transfers.
+type Service struct {
+	db       *sql.DB
+	ledger   *ledger.Service
+	payments *payment.Service
+}
+
+func NewService(db *sql.DB, l *ledger.Service, p *payment.Service) *Service {
+	return &Service{db: db, ledger: l, payments: p}
+}
+
+const orderColumns = `
+	id, from_account_id, to_account_id, amount, currency, COALESCE(description, ''), frequency, interval_count,
+	COALESCE(day_of_month, 0), start_date, end_date, max_executions, max_retries, retry_interval_minutes,
+	execution_count, next_run_date, retry_at, failed_attempts, status, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanOrder(row rowScanner) (*StandingOrder, error) {
+	var o StandingOrder
+	var retryMinutes int
+	err := row.Scan(&o.ID, &o.FromAccountID, &o.ToAccountID, &o.Amount, &o.Currency, &o.Description, &o.Frequency, &o.Interval,
+		&o.DayOfMonth, &o.StartDate, &o.EndDate, &o.MaxExecutions, &o.Retry.MaxRetries, &retryMinutes,
+		&o.ExecutionCount, &o.NextRunDate, &o.RetryAt, &o.FailedAttempts, &o.Status, &o.CreatedAt, &o.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	o.Retry.RetryInterval = time.Duration(retryMinutes) * time.Minute
+	return &o, nil
+}
+
+// Create stores a new active standing order. Interval defaults to 1, StartDate to
+// today and a monthly order's DayOfMonth to the start date's day; Currency must be
+// the source account's currency or empty. Retry is used as given, so callers usually
+// start from DefaultRetryPolicy. An order whose start date has passed runs from its
+// next occurrence.
+func (s *Service) Create(o StandingOrder) (*StandingOrder, error) {
+	today := ledger.DateOf(time.Now())
+	if o.Interval == 0 {
+		o.Interval = 1
+	}
+	o.StartDate = ledger.DateOf(o.StartDate)
+	if o.StartDate.IsZero() {
+		o.StartDate = today
+	}
+	if o.Frequency == Monthly && o.DayOfMonth == 0 {
+		o.DayOfMonth = o.StartDate.Day()
+	}
+	if o.EndDate != nil {
+		end := ledger.DateOf(*o.EndDate)
+		o.EndDate = &end
+	}
+	if err := validate(&o); err != nil {
+		return nil, err
+	}
+
+	from, err := s.ledger.GetAccount(o.FromAccountID)
+	if err != nil {
+		return nil, err
+	}
+	if from == nil {
+		return nil, fmt.Errorf("%w: %s", ledger.ErrAccountNotFound, o.FromAccountID)
+	}
+	to, err := s.ledger.GetAccount(o.ToAccountID)
+	if err != nil {
+		return nil, err
+	}
+	if to == nil {
+		return nil, fmt.Errorf("%w: %s", ledger.ErrAccountNotFound, o.ToAccountID)
+	}
+	if o.Currency == "" {
+		o.Currency = from.Currency
+	}
+	if o.Currency != from.Currency {
+		return nil, fmt.Errorf("%w: requested %s, account %s is in %s", ledger.ErrCurrencyMismatch, o.Currency, from.ID, from.Currency)
+	}
+
+	next, ok := NextOccurrence(&o, today)
+	if !ok {
+		return nil, fmt.Errorf("%w: the schedule has no occurrence from today", ErrInvalidOrder)
+	}
+	o.NextRunDate = &next
+	o.Status = StatusActive
+
+	err = s.db.QueryRow(`
+		INSERT INTO standing_orders (from_account_id, to_account_id, amount, currency, description, frequency, interval_count,
+		                             day_of_month, start_date, end_date, max_executions, max_retries, retry_interval_minutes,
+		                             next_run_date, status)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, NULLIF($8, 0), $9::DATE, $10::DATE, $11, $12, $13, $14::DATE, $15)
+		RETURNING id, created_at, updated_at
+	`, o.FromAccountID, o.ToAccountID, o.Amount, o.Currency, o.Description, o.Frequency, o.Interval,
+		o.DayOfMonth, o.StartDate, o.EndDate, o.MaxExecutions, o.Retry.MaxRetries, int(o.Retry.RetryInterval/time.Minute),
+		next, o.Status).Scan(&o.ID, &o.CreatedAt, &o.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create standing order: %w", err)
+	}
+	return &o, nil
+}
+
+// Get returns a standing order.
+func (s *Service) Get(id uuid.UUID) (*StandingOrder, error) {
+	o, err := scanOrder(s.db.QueryRow(`SELECT `+orderColumns+` FROM standing_orders WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load standing order: %w", err)
+	}
+	return o, nil
+}
+
+// List returns the standing orders paying from or into an account, oldest first.
+func (s *Service) List(accountID uuid.UUID) ([]*StandingOrder, error) {
+	rows, err := s.db.Query(`
+		SELECT `+orderColumns+` FROM standing_orders
+		WHERE from_account_id = $1 OR to_account_id = $1
+		ORDER BY created_at, id
+	`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list standing orders: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []*StandingOrder
+	for rows.Next() {
+		o, err := scanOrder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan standing order: %w", err)
+		}
+		orders = append(orders, o)
+	}
+	return orders, rows.Err()
+}
+
+// Changes are the fields of a standing order that can be updated; nil fields are kept.
+type Changes struct {
+	Amount        *int64       `json:"amount"`
+	Description   *string      `json:"description"`
+	EndDate       *time.Time   `json:"end_date"`
+	MaxExecutions *int         `json:"max_executions"`
+	Retry         *RetryPolicy `json:"retry"`
+}
+
+// Update changes the amount, description, end or retry policy of an active or paused
+// order. An order whose next occurrence falls past the new end, or that already made
+// the new maximum number of transfers, completes.
+func (s *Service) Update(id uuid.UUID, c Changes) (*StandingOrder, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	o, err := lockOrder(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if o.Status != StatusActive && o.Status != StatusPaused {
+		return nil, fmt.Errorf("%w: order is %s", ErrInvalidStatus, o.Status)
+	}
+	if c.Amount != nil {
+		o.Amount = *c.Amount
+	}
+	if c.Description != nil {
+		o.Description = *c.Description
+	}
+	if c.EndDate != nil {
+		end := ledger.DateOf(*c.EndDate)
+		o.EndDate = &end
+	}
+	if c.MaxExecutions != nil {
+		o.MaxExecutions = c.MaxExecutions
+	}
+	if c.Retry != nil {
+		o.Retry = *c.Retry
+	}
+	if err := validate(o); err != nil {
+		return nil, err
+	}
+	if o.NextRunDate != nil {
+		if _, ok := NextOccurrence(o, *o.NextRunDate); !ok {
+			complete(o)
+		}
+	}
+	if o.MaxExecutions != nil && o.ExecutionCount >= *o.MaxExecutions {
+		complete(o)
+	}
+
+	if err := saveOrder(tx, o); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit standing order: %w", err)
+	}
+	return o, nil
+}
+
+// validTransitions lists the status changes allowed through SetStatus.
+var validTransitions = map[Status][]Status{
+	StatusActive: {StatusPaused, StatusCancelled},
+	StatusPaused: {StatusActive, StatusCancelled},
+}
+
+// SetStatus pauses, resumes or cancels an order. A resumed order continues from its
+// next occurrence on or after today; occurrences missed while paused are not paid.
+func (s *Service) SetStatus(id uuid.UUID, status Status) (*StandingOrder, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	o, err := lockOrder(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	allowed := false
+	for _, to := range validTransitions[o.Status] {
+		allowed = allowed || to == status
+	}
+	if !allowed {
+		return nil, fmt.Errorf("%w: %s to %s", ErrInvalidStatus, o.Status, status)
+	}
+
+	o.Status = status
+	switch status {
+	case StatusActive:
+		o.RetryAt, o.FailedAttempts = nil, 0
+		next, ok := NextOccurrence(o, time.Now())
+		if !ok {
+			complete(o)
+		} else {
+			o.NextRunDate = &next
+		}
+	case StatusCancelled:
+		o.NextRunDate, o.RetryAt = nil, nil
+	}
+
+	if err := saveOrder(tx, o); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit standing order: %w", err)
+	}
+	return o, nil
+}
+
+// ListExecutions returns the attempts made for a standing order, newest first.
+func (s *Service) ListExecutions(orderID uuid.UUID) ([]*Execution, error) {
+	rows, err := s.db.Query(`
+		SELECT id, standing_order_id, scheduled_date, attempt, status, transaction_id, COALESCE(error, ''), started_at, finished_at
+		FROM standing_order_executions
+		WHERE standing_order_id = $1
+		ORDER BY started_at DESC, id
+	`, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list executions: %w", err)
+	}
+	defer rows.Close()
+
+	var executions []*Execution
+	for rows.Next() {
+		var e Execution
+		if err := rows.Scan(&e.ID, &e.StandingOrderID, &e.ScheduledDate, &e.Attempt, &e.Status, &e.TransactionID, &e.Error, &e.StartedAt, &e.FinishedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan execution: %w", err)
+		}
+		executions = append(executions, &e)
+	}
+	return executions, rows.Err()
+}
+
+// dueCondition selects active orders whose occurrence is due by $1 (a date) and
+// whose retry, if any, is due by $2, and which have no transfer in progress.
+const dueCondition = `
+	status = 'ACTIVE' AND next_run_date <= $1::DATE AND (retry_at IS NULL OR retry_at <= $2)
+	AND NOT EXISTS (
+	    SELECT 1 FROM standing_order_executions x
+	    WHERE x.standing_order_id = standing_orders.id AND x.status = 'PROCESSING'
+	)`
+
+// ExecuteDue pays every occurrence due by now. Occurrences missed while the job did
+// not run are paid one after another. A transfer that fails for insufficient funds is
+// retried according to the order's retry policy; other failures, and insufficient
+// funds once the retries are used up, skip the occurrence. Every attempt is recorded.
+func (s *Service) ExecuteDue(now time.Time) (*RunResult, error) {
+	result := &RunResult{}
+	for {
+		rows, err := s.db.Query(`SELECT id FROM standing_orders WHERE `+dueCondition+` ORDER BY next_run_date, id`, now.Format("2006-01-02"), now)
+		if err != nil {
+			return result, fmt.Errorf("failed to find due standing orders: %w", err)
+		}
+		var ids []uuid.UUID
+		for rows.Next() {
+			var id uuid.UUID
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return result, fmt.Errorf("failed to scan standing order: %w", err)
+			}
+			ids = append(ids, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return result, fmt.Errorf("failed to find due standing orders: %w", err)
+		}
+
+		progress := false
+		for _, id := range ids {
+			status, err := s.execute(id, now)
+			if err != nil {
+				log.Printf("Standing order %s: %v", id, err)
+			}
+			switch status {
+			case ExecutionSucceeded:
+				result.Succeeded++
+			case ExecutionRetryScheduled:
+				result.Retrying++
+			case ExecutionFailed:
+				result.Failed++
+			default:
+				continue // Taken by another run, or could not be claimed
+			}
+			progress = true
+		}
+		// Stop when nothing was due, or another run holds everything that is
+		if !progress {
+			return result, nil
+		}
+	}
+}
+
+// execute makes one attempt at an order's current occurrence. The attempt is
+// claimed (committed as PROCESSING) before the transfer and settled after it, so a
+// crash in between leaves a visible PROCESSING attempt instead of a second payment.
+// It returns "" if the order was no longer due or was claimed by another run.
+func (s *Service) execute(id uuid.UUID, now time.Time) (ExecutionStatus, error) {
+	// 1. Claim the Occurrence
+	o, executionID, err := s.claim(id, now)
+	if err != nil || o == nil {
+		return "", err
+	}
+
+	// 2. Transfer
+	tx, transferErr := s.payments.Transfer(o.FromAccountID, o.ToAccountID, o.Amount, o.Currency)
+
+	// 3. Settle the Attempt and Advance the Schedule
+	status := ExecutionSucceeded
+	if transferErr != nil {
+		status = ExecutionFailed
+		if errors.Is(transferErr, ledger.ErrInsufficientFunds) && o.FailedAttempts < o.Retry.MaxRetries {
+			status = ExecutionRetryScheduled
+		}
+	}
+	if err := s.settle(o.ID, executionID, status, tx, transferErr, now); err != nil {
+		if status == ExecutionSucceeded {
+			return status, fmt.Errorf("transfer %s posted but recording it failed: %w", tx.ID, err)
+		}
+		return status, err
+	}
+	return status, nil
+}
+
+func (s *Service) claim(id uuid.UUID, now time.Time) (*StandingOrder, uuid.UUID, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, uuid.Nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	o, err := scanOrder(tx.QueryRow(`
+		SELECT `+orderColumns+` FROM standing_orders
+		WHERE id = $3 AND `+dueCondition+`
+		FOR UPDATE SKIP LOCKED
+	`, now.Format("2006-01-02"), now, id))
+	if err == sql.ErrNoRows {
+		return nil, uuid.Nil, nil
+	}
+	if err != nil {
+		return nil, uuid.Nil, fmt.Errorf("failed to claim standing order: %w", err)
+	}
+
+	var executionID uuid.UUID
+	err = tx.QueryRow(`
+		INSERT INTO standing_order_executions (standing_order_id, scheduled_date, attempt, status)
+		VALUES ($1, $2::DATE, $3, $4)
+		RETURNING id
+	`, o.ID, *o.NextRunDate, o.FailedAttempts+1, ExecutionProcessing).Scan(&executionID)
+	if err != nil {
+		return nil, uuid.Nil, fmt.Errorf("failed to record execution: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, uuid.Nil, fmt.Errorf("failed to commit claim: %w", err)
+	}
+	return o, executionID, nil
+}
+
+func (s *Service) settle(id, executionID uuid.UUID, status ExecutionStatus, transfer *ledger.Transaction, transferErr error, now time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The order may have been updated, paused or cancelled while the transfer ran
+	o, err := lockOrder(tx, id)
+	if err != nil {
+		return err
+	}
+
+	var transactionID *uuid.UUID
+	var errorText string
+	if transfer != nil {
+		transactionID = &transfer.ID
+	}
+	if transferErr != nil {
+		errorText = transferErr.Error()
+	}
+	_, err = tx.Exec(`
+		UPDATE standing_order_executions
+		SET status = $1, transaction_id = $2, error = NULLIF($3, ''), finished_at = CURRENT_TIMESTAMP
+		WHERE id = $4
+	`, status, transactionID, errorText, executionID)
+	if err != nil {
+		return fmt.Errorf("failed to record execution: %w", err)
+	}
+
+	switch status {
+	case ExecutionRetryScheduled:
+		o.FailedAttempts++
+		retryAt := now.Add(o.Retry.RetryInterval)
+		o.RetryAt = &retryAt
+	default:
+		if status == ExecutionSucceeded {
+			o.ExecutionCount++
+		}
+		o.FailedAttempts, o.RetryAt = 0, nil
+		if o.NextRunDate != nil {
+			next, ok := NextOccurrence(o, o.NextRunDate.AddDate(0, 0, 1))
+			if !ok || (o.MaxExecutions != nil && o.ExecutionCount >= *o.MaxExecutions) {
+				complete(o)
+			} else {
+				o.NextRunDate = &next
+			}
+		}
+	}
+
+	if err := saveOrder(tx, o); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit execution: %w", err)
+	}
+	return nil
+}
+
+// complete ends an order's schedule. Cancelled orders stay cancelled.
+func complete(o *StandingOrder) {
+	if o.Status == StatusActive || o.Status == StatusPaused {
+		o.Status = StatusCompleted
+	}
+	o.NextRunDate, o.RetryAt = nil, nil
+}
+
+func lockOrder(tx *sql.Tx, id uuid.UUID) (*StandingOrder, error) {
+	o, err := scanOrder(tx.QueryRow(`SELECT `+orderColumns+` FROM standing_orders WHERE id = $1 FOR UPDATE`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load standing order: %w", err)
+	}
+	return o, nil
+}
+
+// saveOrder writes back the mutable fields of a locked order.
+func saveOrder(tx *sql.Tx, o *StandingOrder) error {
+	err := tx.QueryRow(`
+		UPDATE standing_orders
+		SET amount = $1, description = NULLIF($2, ''), end_date = $3::DATE, max_executions = $4, max_retries = $5,
+		    retry_interval_minutes = $6, execution_count = $7, next_run_date = $8::DATE, retry_at = $9,
+		    failed_attempts = $10, status = $11, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $12
+		RETURNING updated_at
+	`, o.Amount, o.Description, o.EndDate, o.MaxExecutions, o.Retry.MaxRetries, int(o.Retry.RetryInterval/time.Minute),
+		o.ExecutionCount, o.NextRunDate, o.RetryAt, o.FailedAttempts, o.Status, o.ID).Scan(&o.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update standing order: %w", err)
+	}
+	return nil
+}
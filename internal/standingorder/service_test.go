@@ -0,0 +1,142 @@
+package standingorder
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/nathanmocogni/core-banking-system/internal/ledger"
+	"github.com/nathanmocogni/core-banking-system/internal/payment"
+)
+
+func connectDB(t *testing.T) *sql.DB {
+	host := os.Getenv("DB_HOST")
+	if host == "" {
+		host = "localhost"
+	}
+	port := os.Getenv("DB_PORT")
+	if port == "" {
+		port = "5433"
+	}
+	user := os.Getenv("DB_USER")
+	if user == "" {
+		user = "user"
+	}
+	password := os.Getenv("DB_PASSWORD")
+	if password == "" {
+		password = "password"
+	}
+	dbname := os.Getenv("DB_NAME")
+	if dbname == "" {
+		dbname = "ledger"
+	}
+
+	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable", host, port, user, password, dbname)
+	db, err := sql.Open("postgres", connStr)
+	if err != nil {
+		t.Skipf("Skipping test: could not connect to database: %v", err)
+	}
+	if err := db.Ping(); err != nil {
+		t.Skipf("Skipping test: database not reachable: %v", err)
+	}
+	return db
+}
+
+func TestExecuteDue(t *testing.T) {
+	db := connectDB(t)
+	defer db.Close()
+
+	ledgerService := ledger.NewService(db)
+	service := NewService(db, ledgerService, payment.NewService(ledgerService))
+
+	cash, err := ledgerService.GetOrCreateSystemAccount("Cash In", ledger.Asset, "USD")
+	if err != nil {
+		t.Fatalf("Failed to get cash account: %v", err)
+	}
+	from, err := ledgerService.CreateAccount("Standing Order Payer", ledger.Liability, "USD", "CASH", "INDIVIDUAL", nil)
+	if err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+	to, err := ledgerService.CreateAccount("Standing Order Payee", ledger.Liability, "USD", "CASH", "INDIVIDUAL", nil)
+	if err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+	fund := func(amount int64) {
+		_, err := ledgerService.PostTransaction(fmt.Sprintf("SO-FUND-%d", time.Now().UnixNano()), "Funding", []ledger.Entry{
+			{AccountID: cash, Direction: ledger.Debit, Amount: amount},
+			{AccountID: from.ID, Direction: ledger.Credit, Amount: amount},
+		})
+		if err != nil {
+			t.Fatalf("Failed to fund account: %v", err)
+		}
+	}
+	fund(150)
+
+	max := 2
+	order, err := service.Create(StandingOrder{
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        100,
+		Frequency:     Daily,
+		MaxExecutions: &max,
+		Retry:         RetryPolicy{MaxRetries: 1, RetryInterval: time.Minute},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	run := func(now time.Time, want RunResult) {
+		t.Helper()
+		got, err := service.ExecuteDue(now)
+		if err != nil {
+			t.Fatalf("ExecuteDue failed: %v", err)
+		}
+		// Other orders in the database may be due too; only check this one's effect
+		if got.Succeeded < want.Succeeded || got.Retrying < want.Retrying || got.Failed < want.Failed {
+			t.Errorf("ExecuteDue(%s) = %+v, want at least %+v", now.Format(time.RFC3339), got, want)
+		}
+	}
+
+	now := time.Now()
+	run(now, RunResult{Succeeded: 1})
+	run(now, RunResult{}) // Nothing more due today
+
+	// Tomorrow only 50 is left: retried once, then skipped
+	tomorrow := now.AddDate(0, 0, 1)
+	run(tomorrow, RunResult{Retrying: 1})
+	run(tomorrow.Add(2*time.Minute), RunResult{Failed: 1})
+
+	// The next day it succeeds and the order completes
+	fund(100)
+	run(now.AddDate(0, 0, 2), RunResult{Succeeded: 1})
+
+	got, err := service.Get(order.ID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Status != StatusCompleted || got.ExecutionCount != 2 || got.NextRunDate != nil {
+		t.Errorf("Expected a completed order with 2 executions, got %+v", got)
+	}
+	executions, err := service.ListExecutions(order.ID)
+	if err != nil {
+		t.Fatalf("ListExecutions failed: %v", err)
+	}
+	var statuses []ExecutionStatus
+	for i := len(executions) - 1; i >= 0; i-- {
+		statuses = append(statuses, executions[i].Status)
+	}
+	want := []ExecutionStatus{ExecutionSucceeded, ExecutionRetryScheduled, ExecutionFailed, ExecutionSucceeded}
+	if fmt.Sprint(statuses) != fmt.Sprint(want) {
+		t.Errorf("Expected executions %v, got %v", want, statuses)
+	}
+	acc, err := ledgerService.GetAccount(to.ID)
+	if err != nil {
+		t.Fatalf("GetAccount failed: %v", err)
+	}
+	if acc.Balance != -200 {
+		t.Errorf("Expected payee balance -200, got %d", acc.Balance)
+	}
+}